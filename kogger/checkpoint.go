@@ -0,0 +1,174 @@
+package kogger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// checkpointDataKey is the ConfigMap key holding the checkpoints.
+const checkpointDataKey = "checkpoints.json"
+
+// checkpoint records how far the logs of a container were collected. The
+// container ID tells whether the container restarted since.
+type checkpoint struct {
+	ContainerID string    `json:"containerId"`
+	LastTime    time.Time `json:"lastTime"`
+}
+
+// checkpoints are keyed by namespace/pod/container.
+type checkpoints map[string]checkpoint
+
+func checkpointKey(namespace, pod, container string) string {
+	return fmt.Sprintf("%s/%s/%s", namespace, pod, container)
+}
+
+// checkpointStore persists the checkpoints between two collector runs.
+type checkpointStore interface {
+	Load(ctx context.Context) (checkpoints, error)
+	Save(ctx context.Context, cps checkpoints) error
+}
+
+// newCheckpointStore builds the store selected by COLLECTOR_CHECKPOINT, either
+// "file" or "configmap". It returns nil when checkpoints are disabled.
+func newCheckpointStore() (checkpointStore, error) {
+	switch kind := getEnv("COLLECTOR_CHECKPOINT", ""); kind {
+	case "":
+		return nil, nil
+	case "file":
+		return &fileCheckpointStore{
+			path: getEnv("COLLECTOR_CHECKPOINT_PATH", "/var/lib/kogger/checkpoints/checkpoints.json"),
+		}, nil
+	case "configmap":
+		namespace, ok := os.LookupEnv("POD_NAMESPACE")
+		if !ok {
+			return nil, fmt.Errorf("POD_NAMESPACE environment variable is not set")
+		}
+
+		clientset := Clientset
+		if clientset == nil {
+			config, err := rest.InClusterConfig()
+			if err != nil {
+				return nil, err
+			}
+			if clientset, err = kubernetes.NewForConfig(config); err != nil {
+				return nil, err
+			}
+		}
+
+		return &configMapCheckpointStore{
+			clientset: clientset,
+			namespace: namespace,
+			name:      getEnv("COLLECTOR_CHECKPOINT_CONFIGMAP", "kogger-service-checkpoints"),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown checkpoint store %q, expected file or configmap", kind)
+	}
+}
+
+// fileCheckpointStore keeps the checkpoints in a local JSON file, which must
+// sit on a persistent volume for the checkpoints to outlive the job pod.
+type fileCheckpointStore struct {
+	path string
+}
+
+func (s *fileCheckpointStore) Load(ctx context.Context) (checkpoints, error) {
+	cps := checkpoints{}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return cps, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &cps); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %s", s.path, err)
+	}
+	return cps, nil
+}
+
+func (s *fileCheckpointStore) Save(ctx context.Context, cps checkpoints) error {
+	data, err := json.Marshal(cps)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// configMapCheckpointStore keeps the checkpoints in a ConfigMap.
+type configMapCheckpointStore struct {
+	clientset kubernetes.Interface
+	namespace string
+	name      string
+}
+
+func (s *configMapCheckpointStore) Load(ctx context.Context) (checkpoints, error) {
+	cps := checkpoints{}
+
+	configMap, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return cps, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := configMap.Data[checkpointDataKey]
+	if !ok {
+		return cps, nil
+	}
+	if err := json.Unmarshal([]byte(data), &cps); err != nil {
+		return nil, fmt.Errorf("invalid checkpoints in configmap %s: %s", s.name, err)
+	}
+	return cps, nil
+}
+
+func (s *configMapCheckpointStore) Save(ctx context.Context, cps checkpoints) error {
+	data, err := json.Marshal(cps)
+	if err != nil {
+		return err
+	}
+
+	configMap, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = s.clientset.CoreV1().ConfigMaps(s.namespace).Create(ctx, &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.name,
+				Namespace: s.namespace,
+			},
+			Data: map[string]string{
+				checkpointDataKey: string(data),
+			},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[checkpointDataKey] = string(data)
+	_, err = s.clientset.CoreV1().ConfigMaps(s.namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+	return err
+}
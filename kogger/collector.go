@@ -0,0 +1,382 @@
+package kogger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	logger "github.com/ZolaraProject/library/logger"
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// collectorMaxMsgSize matches the message size accepted by the server.
+	collectorMaxMsgSize = 16 * 1024 * 1024
+	// defaultCollectorPageSize is the number of entries fetched at once,
+	// unless set by COLLECTOR_PAGE_SIZE.
+	defaultCollectorPageSize = 1000
+)
+
+// getEnv returns the value of the environment variable, or def when unset.
+func getEnv(key, def string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+	return value
+}
+
+// parseIntEnv returns the value of the environment variable as a non negative
+// integer, or def when unset or empty.
+func parseIntEnv(key string, def int) (int, error) {
+	value := getEnv(key, "")
+	if len(value) == 0 {
+		return def, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a positive integer", key, value)
+	}
+	return parsed, nil
+}
+
+// RunCollector implements the cronjob mode. It lists the namespaces and pods
+// through the kogger service at KoggerHost:KoggerPort, fetches the logs of
+// every pod and writes them to the sinks listed in COLLECTOR_SINKS. The
+// namespaces can be restricted with a comma separated COLLECTOR_NAMESPACES.
+// When COLLECTOR_CHECKPOINT is set, only the lines written since the previous
+// run are collected.
+func RunCollector(ctx context.Context, grpcToken string) error {
+	pageSize, err := parseIntEnv("COLLECTOR_PAGE_SIZE", defaultCollectorPageSize)
+	if err != nil || pageSize == 0 || pageSize > maxLogPageSize {
+		err = fmt.Errorf("invalid COLLECTOR_PAGE_SIZE %q, expected a page size between 1 and %d", getEnv("COLLECTOR_PAGE_SIZE", ""), maxLogPageSize)
+		logger.Err(grpcToken, "Failed to configure the collector: %s", err)
+		return err
+	}
+
+	store, err := newCheckpointStore()
+	if err != nil {
+		logger.Err(grpcToken, "Failed to configure checkpoints: %s", err)
+		return err
+	}
+	var cps checkpoints
+	if store != nil {
+		if cps, err = store.Load(ctx); err != nil {
+			logger.Err(grpcToken, "Failed to load checkpoints: %s", err)
+			return err
+		}
+		logger.Debug(grpcToken, "Loaded %d checkpoints", len(cps))
+	}
+
+	sinks, err := newLogSinks(grpcToken, getEnv("COLLECTOR_SINKS", "stdout"))
+	if err != nil {
+		logger.Err(grpcToken, "Failed to configure sinks: %s", err)
+		return err
+	}
+	closed := false
+	defer func() {
+		if closed {
+			return
+		}
+		if err := closeLogSinks(sinks); err != nil {
+			logger.Err(grpcToken, "Failed to close sinks: %s", err)
+		}
+	}()
+
+	logger.Info(grpcToken, "Connecting to kogger service at %s:%s", KoggerHost, KoggerPort)
+	conn, err := grpc.NewClient(fmt.Sprintf("%v:%v", KoggerHost, KoggerPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(collectorMaxMsgSize)),
+	)
+	if err != nil {
+		logger.Err(grpcToken, "Failed to create gRPC client: %s", err)
+		return err
+	}
+	defer conn.Close()
+	client := NewKoggerServiceClient(conn)
+
+	namespaces, err := collectorNamespaces(ctx, client)
+	if err != nil {
+		logger.Err(grpcToken, "Failed to list namespaces: %s", err)
+		return err
+	}
+
+	collected, failed := 0, 0
+	listed, seen := map[string]bool{}, map[string]bool{}
+	for _, namespace := range namespaces {
+		resources, err := client.ListResources(ctx, &ListResourcesRequest{
+			Namespace:    namespace,
+			ResourceType: ResourceTypeToString(ResourceType_RESOURCE_TYPE_POD),
+		})
+		if err != nil {
+			logger.Warn(grpcToken, "Failed to list pods in namespace %s: %s", namespace, err)
+			failed++
+			continue
+		}
+		listed[namespace] = true
+
+		for _, list := range resources.GetResourcesList() {
+			for _, pod := range list.GetResources() {
+				seen[namespace+"/"+pod.GetName()] = true
+				if err := collectPod(ctx, grpcToken, client, sinks, cps, pageSize, namespace, pod.GetName()); err != nil {
+					logger.Warn(grpcToken, "Failed to collect logs of pod %s in namespace %s: %s", pod.GetName(), namespace, err)
+					failed++
+					continue
+				}
+				collected++
+			}
+		}
+	}
+
+	// Sinks may buffer entries until closed, the checkpoints must only move
+	// forward once they have been flushed.
+	closed = true
+	if err := closeLogSinks(sinks); err != nil {
+		logger.Err(grpcToken, "Failed to close sinks, checkpoints are not saved: %s", err)
+		return err
+	}
+
+	if store != nil {
+		pruneCheckpoints(cps, listed, seen)
+		if err := store.Save(ctx, cps); err != nil {
+			logger.Err(grpcToken, "Failed to save checkpoints: %s", err)
+			return err
+		}
+	}
+
+	logger.Info(grpcToken, "Collected logs of %d pods in %d namespaces, %d failures", collected, len(namespaces), failed)
+	return nil
+}
+
+// pruneCheckpoints forgets the pods which no longer exist in the namespaces
+// that were listed successfully.
+func pruneCheckpoints(cps checkpoints, listed, seen map[string]bool) {
+	for key := range cps {
+		parts := strings.SplitN(key, "/", 3)
+		if len(parts) != 3 || (listed[parts[0]] && !seen[parts[0]+"/"+parts[1]]) {
+			delete(cps, key)
+		}
+	}
+}
+
+// collectorNamespaces returns the namespaces to collect, either those listed in
+// COLLECTOR_NAMESPACES or all the namespaces of the cluster.
+func collectorNamespaces(ctx context.Context, client KoggerServiceClient) ([]string, error) {
+	namespaces := []string{}
+	for _, namespace := range strings.Split(getEnv("COLLECTOR_NAMESPACES", ""), ",") {
+		if namespace = strings.TrimSpace(namespace); len(namespace) > 0 {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	if len(namespaces) > 0 {
+		return namespaces, nil
+	}
+
+	response, err := client.GetNamespaces(ctx, &Void{})
+	if err != nil {
+		return nil, err
+	}
+	for _, namespace := range response.GetNamespaces() {
+		namespaces = append(namespaces, namespace.GetName())
+	}
+	return namespaces, nil
+}
+
+// collectPod fetches the logs of a pod, pageSize entries at a time, and writes
+// every page to the sinks. With checkpoints, each container is fetched from
+// its own checkpoint and the lines already collected are dropped, while the
+// containers without checkpoint are fetched in full. A container whose ID
+// changed has restarted, so the end of the logs of its previous instance is
+// collected too. The checkpoints only move forward once every sink accepted
+// the logs.
+func collectPod(ctx context.Context, grpcToken string, client KoggerServiceClient, sinks []LogSink, cps checkpoints, pageSize int, namespace, pod string) error {
+	// Entries are filtered against the checkpoints of the previous run, as the
+	// pages already written move them up to entries sharing their timestamp
+	// with the next page.
+	previous := checkpoints{}
+	for key, cp := range cps {
+		if strings.HasPrefix(key, namespace+"/"+pod+"/") {
+			previous[key] = cp
+		}
+	}
+
+	requests := []*LogsRequest{{
+		Namespace: namespace,
+		Pod:       pod,
+		PageSize:  int32(pageSize),
+	}}
+	if len(previous) > 0 {
+		// The last line of every container lists the containers which have
+		// logs, along with the ID of their current instance.
+		latest, err := client.GetLogs(ctx, &LogsRequest{
+			Namespace: namespace,
+			Pod:       pod,
+			TailLines: 1,
+		})
+		if err != nil {
+			return err
+		}
+
+		requests = requests[:0]
+		listed := map[string]bool{}
+		for _, entry := range latest.GetEntries() {
+			container := entry.GetContainer()
+			if listed[container] {
+				continue
+			}
+			listed[container] = true
+
+			req := &LogsRequest{
+				Namespace: namespace,
+				Pod:       pod,
+				Container: container,
+				PageSize:  int32(pageSize),
+			}
+			if cp, ok := previous[checkpointKey(namespace, pod, container)]; ok && cp.ContainerID == entry.GetContainerId() {
+				req.SinceTime = timestamppb.New(cp.LastTime)
+			}
+			requests = append(requests, req)
+		}
+	}
+
+	collected := 0
+	write := func(logs *Logs) error {
+		for _, sink := range sinks {
+			if err := sink.Write(ctx, logs); err != nil {
+				return err
+			}
+		}
+		collected += len(logs.GetEntries())
+
+		if cps != nil {
+			for _, entry := range logs.GetEntries() {
+				if entry.GetTime() == nil {
+					continue
+				}
+
+				key := checkpointKey(namespace, pod, entry.GetContainer())
+				cp, ok := cps[key]
+				if !ok || cp.ContainerID != entry.GetContainerId() || entry.GetTime().AsTime().After(cp.LastTime) {
+					cps[key] = checkpoint{
+						ContainerID: entry.GetContainerId(),
+						LastTime:    entry.GetTime().AsTime(),
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	checked := map[string]bool{}
+	collect := func(logs *Logs) error {
+		if cps == nil {
+			if len(logs.GetEntries()) == 0 {
+				return nil
+			}
+			return write(logs)
+		}
+
+		entries := []*LogEntry{}
+		for _, entry := range logs.GetEntries() {
+			container := entry.GetContainer()
+			if !checked[container] {
+				checked[container] = true
+				cp, ok := previous[checkpointKey(namespace, pod, container)]
+				if ok && cp.ContainerID != entry.GetContainerId() {
+					if err := collectPreviousLogs(ctx, client, pageSize, namespace, pod, container, cp, write); err != nil {
+						logger.Warn(grpcToken, "Failed to collect previous logs of container %s of pod %s in namespace %s: %s", container, pod, namespace, err)
+					}
+				}
+			}
+			if !isCollected(previous, namespace, pod, entry) {
+				entries = append(entries, entry)
+			}
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		logs.Entries = entries
+		return write(logs)
+	}
+	for _, req := range requests {
+		if err := pageLogs(ctx, client, req, collect); err != nil {
+			return err
+		}
+	}
+
+	if collected == 0 {
+		logger.Debug(grpcToken, "No logs found for pod %s in namespace %s", pod, namespace)
+		return nil
+	}
+	logger.Debug(grpcToken, "Collected %d log entries for pod %s in namespace %s", collected, pod, namespace)
+	return nil
+}
+
+// pageLogs calls fn with every page of the logs of req, following the next
+// cursors until the last page.
+func pageLogs(ctx context.Context, client KoggerServiceClient, req *LogsRequest, fn func(logs *Logs) error) error {
+	for {
+		logs, err := client.GetLogs(ctx, req)
+		if err != nil {
+			return err
+		}
+		if err := fn(logs); err != nil {
+			return err
+		}
+		if len(logs.GetNextCursor()) == 0 {
+			return nil
+		}
+		req.Cursor = logs.GetNextCursor()
+	}
+}
+
+// collectPreviousLogs writes the logs of the previous instance of a container
+// written after the checkpoint, provided it is the instance the checkpoint was
+// taken on.
+func collectPreviousLogs(ctx context.Context, client KoggerServiceClient, pageSize int, namespace, pod, container string, cp checkpoint, write func(logs *Logs) error) error {
+	req := &LogsRequest{
+		Namespace: namespace,
+		Pod:       pod,
+		Container: container,
+		Previous:  true,
+		SinceTime: timestamppb.New(cp.LastTime),
+		PageSize:  int32(pageSize),
+	}
+	errOtherInstance := fmt.Errorf("previous instance of container %s is not the checkpointed one", container)
+	err := pageLogs(ctx, client, req, func(logs *Logs) error {
+		entries := []*LogEntry{}
+		for _, entry := range logs.GetEntries() {
+			if entry.GetContainerId() != cp.ContainerID {
+				return errOtherInstance
+			}
+			if entry.GetTime() != nil && entry.GetTime().AsTime().After(cp.LastTime) {
+				entries = append(entries, entry)
+			}
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		logs.Entries = entries
+		return write(logs)
+	})
+	if err == errOtherInstance {
+		return nil
+	}
+	return err
+}
+
+// isCollected tells whether an entry was already collected by a previous run.
+// Entries without timestamp cannot be placed relative to the checkpoint and
+// are only collected on the first run.
+func isCollected(cps checkpoints, namespace, pod string, entry *LogEntry) bool {
+	cp, ok := cps[checkpointKey(namespace, pod, entry.GetContainer())]
+	if !ok || cp.ContainerID != entry.GetContainerId() {
+		return false
+	}
+	return entry.GetTime() == nil || !entry.GetTime().AsTime().After(cp.LastTime)
+}
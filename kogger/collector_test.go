@@ -0,0 +1,350 @@
+package kogger
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeLogsClient serves the logs of a single pod, one container after the
+// other as the server does, with cursors holding the offset in the entries.
+type fakeLogsClient struct {
+	KoggerServiceClient
+
+	// containers lists the containers of the pod in order, current and
+	// previous hold their entries.
+	containers []string
+	current    map[string][]*LogEntry
+	previous   map[string][]*LogEntry
+	requests   []*LogsRequest
+}
+
+func (c *fakeLogsClient) GetLogs(ctx context.Context, req *LogsRequest, opts ...grpc.CallOption) (*Logs, error) {
+	c.requests = append(c.requests, &LogsRequest{
+		Container: req.GetContainer(),
+		Previous:  req.GetPrevious(),
+		SinceTime: req.GetSinceTime(),
+		TailLines: req.GetTailLines(),
+		PageSize:  req.GetPageSize(),
+		Cursor:    req.GetCursor(),
+	})
+
+	source := c.current
+	if req.GetPrevious() {
+		source = c.previous
+	}
+	entries := []*LogEntry{}
+	for _, container := range c.containers {
+		if len(req.GetContainer()) > 0 && container != req.GetContainer() {
+			continue
+		}
+		containerEntries := source[container]
+		if tail := int(req.GetTailLines()); tail > 0 && tail < len(containerEntries) {
+			containerEntries = containerEntries[len(containerEntries)-tail:]
+		}
+		for _, entry := range containerEntries {
+			// The kubelet truncates sinceTime to the second.
+			if req.GetSinceTime() != nil && entry.GetTime().AsTime().Before(req.GetSinceTime().AsTime().Truncate(time.Second)) {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	offset := 0
+	if len(req.GetCursor()) > 0 {
+		var err error
+		if offset, err = strconv.Atoi(req.GetCursor()); err != nil {
+			return nil, err
+		}
+	}
+	end := len(entries)
+	if req.GetPageSize() > 0 {
+		end = min(offset+int(req.GetPageSize()), len(entries))
+	}
+	logs := &Logs{Namespace: "default", Pod: "web-0", Entries: entries[offset:end]}
+	if end < len(entries) {
+		logs.NextCursor = strconv.Itoa(end)
+	}
+	return logs, nil
+}
+
+// fakeKoggerServer serves the pod of a fakeLogsClient over gRPC, for
+// RunCollector to connect to.
+type fakeKoggerServer struct {
+	UnimplementedKoggerServiceServer
+
+	client *fakeLogsClient
+}
+
+func (s *fakeKoggerServer) ListResources(ctx context.Context, req *ListResourcesRequest) (*ResourcesResponse, error) {
+	return &ResourcesResponse{
+		Namespace: req.GetNamespace(),
+		ResourcesList: []*ResourcesList{{
+			ResourceType: req.GetResourceType(),
+			Resources:    []*ResourceInlist{{Name: "web-0"}},
+		}},
+	}, nil
+}
+
+func (s *fakeKoggerServer) GetLogs(ctx context.Context, req *LogsRequest) (*Logs, error) {
+	return s.client.GetLogs(ctx, req)
+}
+
+// useFakeKoggerServer points KoggerHost and KoggerPort to a gRPC server backed
+// by the client until the test ends.
+func useFakeKoggerServer(t *testing.T, client *fakeLogsClient) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	srv := grpc.NewServer()
+	RegisterKoggerServiceServer(srv, &fakeKoggerServer{client: client})
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousHost, previousPort := KoggerHost, KoggerPort
+	KoggerHost, KoggerPort = host, port
+	t.Cleanup(func() { KoggerHost, KoggerPort = previousHost, previousPort })
+}
+
+// recordingSink records the messages of every write.
+type recordingSink struct {
+	writes [][]string
+}
+
+func (s *recordingSink) Write(ctx context.Context, logs *Logs) error {
+	messages := []string{}
+	for _, entry := range logs.GetEntries() {
+		messages = append(messages, entry.GetMessage())
+	}
+	s.writes = append(s.writes, messages)
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	return nil
+}
+
+// testCollectorTime is the time of the entries of testCollectorEntries.
+var testCollectorTime = time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+// testCollectorEntries returns entries of the container, one per message,
+// the message being the offset in milliseconds from testCollectorTime.
+func testCollectorEntries(container, id string, offsets ...int) []*LogEntry {
+	entries := []*LogEntry{}
+	for _, offset := range offsets {
+		entries = append(entries, &LogEntry{
+			Container:   container,
+			ContainerId: id,
+			Message:     fmt.Sprintf("%s@%d", container, offset),
+			Time:        timestamppb.New(testCollectorTime.Add(time.Duration(offset) * time.Millisecond)),
+		})
+	}
+	return entries
+}
+
+func TestCollectPodPages(t *testing.T) {
+	tests := []struct {
+		name     string
+		pageSize int
+		cps      checkpoints
+		writes   string
+		requests int
+	}{
+		{
+			name:     "single page",
+			pageSize: 10,
+			writes:   "[[app@0 app@1000 app@1000 app@2000 app@3000]]",
+			requests: 1,
+		},
+		{
+			name:     "pages",
+			pageSize: 2,
+			writes:   "[[app@0 app@1000] [app@1000 app@2000] [app@3000]]",
+			requests: 3,
+		},
+		{
+			name:     "pages with checkpoints",
+			pageSize: 2,
+			cps:      checkpoints{"default/web-0/app": {ContainerID: "app-1", LastTime: testCollectorTime}},
+			writes:   "[[app@1000] [app@1000 app@2000] [app@3000]]",
+			requests: 4,
+		},
+		{
+			name:     "nothing new",
+			pageSize: 2,
+			cps:      checkpoints{"default/web-0/app": {ContainerID: "app-1", LastTime: testCollectorTime.Add(3 * time.Second)}},
+			writes:   "[]",
+			requests: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &fakeLogsClient{
+				containers: []string{"app"},
+				current:    map[string][]*LogEntry{"app": testCollectorEntries("app", "app-1", 0, 1000, 1000, 2000, 3000)},
+			}
+			sink := &recordingSink{}
+			cps := test.cps
+			if cps == nil {
+				cps = checkpoints{}
+			}
+
+			if err := collectPod(context.Background(), "", client, []LogSink{sink}, cps, test.pageSize, "default", "web-0"); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if writes := fmt.Sprint(sink.writes); writes != test.writes {
+				t.Errorf("expected writes %s, got %s", test.writes, writes)
+			}
+			if len(client.requests) != test.requests {
+				t.Errorf("expected %d requests, got %d", test.requests, len(client.requests))
+			}
+			for _, req := range client.requests {
+				if req.GetTailLines() == 0 && int(req.GetPageSize()) != test.pageSize {
+					t.Errorf("expected page size %d, got %d", test.pageSize, req.GetPageSize())
+				}
+			}
+
+			expected := checkpoint{ContainerID: "app-1", LastTime: testCollectorTime.Add(3 * time.Second)}
+			if cp := cps["default/web-0/app"]; cp != expected {
+				t.Errorf("expected checkpoint %+v, got %+v", expected, cp)
+			}
+		})
+	}
+}
+
+func TestCollectPodRestarted(t *testing.T) {
+	client := &fakeLogsClient{
+		containers: []string{"app"},
+		current:    map[string][]*LogEntry{"app": testCollectorEntries("app", "app-2", 5000, 6000)},
+		previous:   map[string][]*LogEntry{"app": testCollectorEntries("app", "app-1", 1000, 2000, 3000, 4000)},
+	}
+	sink := &recordingSink{}
+	cps := checkpoints{"default/web-0/app": {ContainerID: "app-1", LastTime: testCollectorTime.Add(2 * time.Second)}}
+
+	if err := collectPod(context.Background(), "", client, []LogSink{sink}, cps, 1, "default", "web-0"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if writes := fmt.Sprint(sink.writes); writes != "[[app@3000] [app@4000] [app@5000] [app@6000]]" {
+		t.Errorf("unexpected writes %s", writes)
+	}
+	expected := checkpoint{ContainerID: "app-2", LastTime: testCollectorTime.Add(6 * time.Second)}
+	if cp := cps["default/web-0/app"]; cp != expected {
+		t.Errorf("expected checkpoint %+v, got %+v", expected, cp)
+	}
+
+	previous := 0
+	for _, req := range client.requests {
+		if req.GetPrevious() {
+			previous++
+			if req.GetContainer() != "app" || !req.GetSinceTime().AsTime().Equal(testCollectorTime.Add(2*time.Second)) {
+				t.Errorf("unexpected request of the previous logs %v", req)
+			}
+		}
+	}
+	if previous != 3 {
+		t.Errorf("expected the previous logs to be requested in 3 pages, got %d", previous)
+	}
+}
+
+func TestCollectPodContainerCheckpoints(t *testing.T) {
+	tests := []struct {
+		name   string
+		cps    checkpoints
+		writes string
+		// since are the sinceTime requested for each container, in
+		// milliseconds from testCollectorTime, -1 when unset.
+		since map[string]int
+	}{
+		{
+			name:   "no checkpoint",
+			writes: "[[app@0 app@1000 app@5000 sidecar@0 sidecar@4000]]",
+			since:  map[string]int{"": -1},
+		},
+		{
+			name: "checkpoint of each container",
+			cps: checkpoints{
+				"default/web-0/app":     {ContainerID: "app-1", LastTime: testCollectorTime.Add(time.Second)},
+				"default/web-0/sidecar": {ContainerID: "sidecar-1", LastTime: testCollectorTime},
+			},
+			writes: "[[app@5000] [sidecar@4000]]",
+			since:  map[string]int{"app": 1000, "sidecar": 0},
+		},
+		{
+			name: "container without checkpoint",
+			cps: checkpoints{
+				"default/web-0/app": {ContainerID: "app-1", LastTime: testCollectorTime.Add(time.Second)},
+			},
+			writes: "[[app@5000] [sidecar@0 sidecar@4000]]",
+			since:  map[string]int{"app": 1000, "sidecar": -1},
+		},
+		{
+			name: "checkpoint of another instance",
+			cps: checkpoints{
+				"default/web-0/app":     {ContainerID: "app-1", LastTime: testCollectorTime.Add(time.Second)},
+				"default/web-0/sidecar": {ContainerID: "sidecar-0", LastTime: testCollectorTime.Add(5 * time.Second)},
+			},
+			writes: "[[app@5000] [sidecar@0 sidecar@4000]]",
+			since:  map[string]int{"app": 1000, "sidecar": -1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &fakeLogsClient{
+				containers: []string{"app", "sidecar"},
+				current: map[string][]*LogEntry{
+					"app":     testCollectorEntries("app", "app-1", 0, 1000, 5000),
+					"sidecar": testCollectorEntries("sidecar", "sidecar-1", 0, 4000),
+				},
+			}
+			sink := &recordingSink{}
+			cps := test.cps
+			if cps == nil {
+				cps = checkpoints{}
+			}
+
+			if err := collectPod(context.Background(), "", client, []LogSink{sink}, cps, 10, "default", "web-0"); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if writes := fmt.Sprint(sink.writes); writes != test.writes {
+				t.Errorf("expected writes %s, got %s", test.writes, writes)
+			}
+
+			since := map[string]int{}
+			for _, req := range client.requests {
+				if req.GetTailLines() > 0 || req.GetPrevious() {
+					continue
+				}
+				since[req.GetContainer()] = -1
+				if req.GetSinceTime() != nil {
+					since[req.GetContainer()] = int(req.GetSinceTime().AsTime().Sub(testCollectorTime).Milliseconds())
+				}
+			}
+			if fmt.Sprint(since) != fmt.Sprint(test.since) {
+				t.Errorf("expected sinceTime %v, got %v", test.since, since)
+			}
+
+			for container, last := range map[string]int{"app": 5000, "sidecar": 4000} {
+				cp := cps[checkpointKey("default", "web-0", container)]
+				if cp.ContainerID != container+"-1" || !cp.LastTime.Equal(testCollectorTime.Add(time.Duration(last)*time.Millisecond)) {
+					t.Errorf("unexpected checkpoint of %s %+v", container, cp)
+				}
+			}
+		})
+	}
+}
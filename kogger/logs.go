@@ -0,0 +1,468 @@
+package kogger
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	logger "github.com/ZolaraProject/library/logger"
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// logQuery is a LogsRequest along with the filters and multiline rules
+// compiled from it.
+type logQuery struct {
+	req          *LogsRequest
+	filter       *logFilter
+	continuation []*regexp.Regexp
+}
+
+// newLogQuery validates the log options of the request and compiles its
+// filters and multiline rules. Option combinations the kubelet would refuse
+// are rejected.
+func newLogQuery(req *LogsRequest) (*logQuery, error) {
+	if req.GetTailLines() < 0 || req.GetSinceSeconds() < 0 || req.GetLimitBytes() < 0 {
+		return nil, fmt.Errorf("tailLines, sinceSeconds and limitBytes must not be negative")
+	}
+	if req.GetSinceSeconds() > 0 && req.GetSinceTime() != nil {
+		return nil, fmt.Errorf("only one of sinceSeconds or sinceTime may be specified")
+	}
+
+	if req.GetPageSize() < 0 || req.GetPageSize() > maxLogPageSize {
+		return nil, fmt.Errorf("pageSize must be between 0 and %d", maxLogPageSize)
+	}
+	if (req.GetPageSize() > 0 || len(req.GetCursor()) > 0) && (req.GetTailLines() > 0 || req.GetLimitBytes() > 0) {
+		return nil, fmt.Errorf("tailLines and limitBytes cannot be combined with pagination")
+	}
+
+	filter, err := newLogFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	continuation, err := compileContinuationPatterns(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logQuery{
+		req:          req,
+		filter:       filter,
+		continuation: continuation,
+	}, nil
+}
+
+const (
+	// maxConcurrentPodLogs caps the number of pods whose logs are fetched at
+	// once when a request spans several pods.
+	maxConcurrentPodLogs = 10
+	// maxSelectorPods caps the number of pods a selector or workload query
+	// may span.
+	maxSelectorPods = 100
+	// defaultWorkloadTailLines is the number of lines fetched per container of
+	// a workload when the request sets neither a tail nor a since.
+	defaultWorkloadTailLines = 1000
+)
+
+// getPodLogs reads the logs of every requested container of the pod.
+func getPodLogs(ctx context.Context, grpcToken string, pod *v1.Pod, query *logQuery) ([]*LogEntry, error) {
+	containers, err := logContainers(pod, query.req)
+	if err != nil {
+		logger.Err(grpcToken, "Failed to select containers for pod %s in namespace %s: %s", pod.Name, pod.Namespace, err)
+		return nil, err
+	}
+
+	logs := []*LogEntry{}
+
+	for _, container := range containers {
+		logReq := Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, podLogOptions(query.req, container.name, false))
+		podLogs, err := logReq.Stream(ctx)
+		if err != nil {
+			logger.Err(grpcToken, "Failed to get logs for pod %s in namespace %s, container %s: %s", pod.Name, pod.Namespace, container.name, err)
+			continue
+		}
+
+		containerStart := len(logs)
+		parser := query.newParser(container)
+		err = readLogLines(podLogs, func(line string) bool {
+			if entry := parser.add(line); entry != nil {
+				logs = append(logs, entry)
+			}
+			return true
+		})
+		if entry := parser.flush(); entry != nil {
+			logs = append(logs, entry)
+		}
+		if missing := countMissingTimestamps(logs[containerStart:]); missing > 0 {
+			logger.Warn(grpcToken, "%d log entries without valid timestamp for pod %s in namespace %s, container %s", missing, pod.Name, pod.Namespace, container.name)
+		}
+		if err != nil {
+			logger.Err(grpcToken, "Failed to read logs for pod %s in namespace %s, container %s: %s", pod.Name, pod.Namespace, container.name, err)
+		}
+		if err := podLogs.Close(); err != nil {
+			logger.Err(grpcToken, "Failed to close log stream for pod %s in namespace %s, container %s: %s", pod.Name, pod.Namespace, container.name, err)
+		}
+	}
+
+	return logs, nil
+}
+
+// selectorPods lists the pods of the namespace matching the label and field
+// selectors of the request. Selectors matching more than maxSelectorPods pods
+// are refused rather than silently truncated.
+func selectorPods(ctx context.Context, req *LogsRequest) ([]v1.Pod, error) {
+	pods, err := Clientset.CoreV1().Pods(req.GetNamespace()).List(ctx, metav1.ListOptions{
+		LabelSelector: req.GetLabelSelector(),
+		FieldSelector: req.GetFieldSelector(),
+		Limit:         maxSelectorPods,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pods.Continue) > 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "selector matches more than %d pods, narrow it down", maxSelectorPods)
+	}
+	return pods.Items, nil
+}
+
+// collectPodsLogs fetches the logs of several pods concurrently. Pods whose
+// logs cannot be read are skipped.
+func collectPodsLogs(ctx context.Context, grpcToken string, pods []v1.Pod, query *logQuery) []*LogEntry {
+	results := make([][]*LogEntry, len(pods))
+	sem := make(chan struct{}, maxConcurrentPodLogs)
+	var wg sync.WaitGroup
+
+	for i := range pods {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			entries, err := getPodLogs(ctx, grpcToken, &pods[i], query)
+			if err != nil {
+				logger.Warn(grpcToken, "Skipping logs of pod %s in namespace %s: %s", pods[i].Name, pods[i].Namespace, err)
+				return
+			}
+			results[i] = entries
+		}(i)
+	}
+	wg.Wait()
+
+	logs := []*LogEntry{}
+	for _, entries := range results {
+		logs = append(logs, entries...)
+	}
+	return logs
+}
+
+// sortLogEntries orders entries coming from several containers or pods
+// chronologically, keeping the original order of simultaneous entries. An
+// entry without timestamp stays right after the entry that preceded it in its
+// container.
+func sortLogEntries(entries []*LogEntry) {
+	type streamKey struct {
+		pod       string
+		container string
+	}
+
+	last := make(map[streamKey]time.Time)
+	times := make(map[*LogEntry]time.Time, len(entries))
+	for _, entry := range entries {
+		key := streamKey{entry.GetPod(), entry.GetContainer()}
+		if entry.GetTime() != nil {
+			last[key] = entry.GetTime().AsTime()
+		}
+		times[entry] = last[key]
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return times[entries[i]].Before(times[entries[j]])
+	})
+}
+
+// logContainer is a container of a pod whose logs can be fetched.
+type logContainer struct {
+	pod          string
+	name         string
+	id           string
+	kind         ContainerKind
+	restartCount int32
+}
+
+// logContainers returns the init, regular and ephemeral containers of the pod
+// whose logs are requested, restricted to the requested container when one is
+// given. The container ID and restart count are the ones of the instance whose
+// logs are read, which is the previous one when previous logs are requested.
+func logContainers(pod *v1.Pod, req *LogsRequest) ([]logContainer, error) {
+	restartCounts := make(map[string]int32)
+	containerIDs := make(map[string]string)
+	for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses, pod.Status.EphemeralContainerStatuses} {
+		for _, status := range statuses {
+			restartCounts[status.Name] = status.RestartCount
+			containerIDs[status.Name] = status.ContainerID
+			if req.GetPrevious() {
+				containerIDs[status.Name] = ""
+				if status.LastTerminationState.Terminated != nil {
+					containerIDs[status.Name] = status.LastTerminationState.Terminated.ContainerID
+				}
+			}
+		}
+	}
+
+	containers := []logContainer{}
+	add := func(name string, kind ContainerKind) {
+		if len(req.GetContainer()) > 0 && name != req.GetContainer() {
+			return
+		}
+
+		restartCount := restartCounts[name]
+		if req.GetPrevious() && restartCount > 0 {
+			restartCount--
+		}
+
+		containers = append(containers, logContainer{
+			pod:          pod.Name,
+			name:         name,
+			id:           containerIDs[name],
+			kind:         kind,
+			restartCount: restartCount,
+		})
+	}
+
+	for _, c := range pod.Spec.InitContainers {
+		if c.RestartPolicy != nil && *c.RestartPolicy == v1.ContainerRestartPolicyAlways {
+			add(c.Name, ContainerKind_CONTAINER_KIND_SIDECAR)
+		} else {
+			add(c.Name, ContainerKind_CONTAINER_KIND_INIT)
+		}
+	}
+	for _, c := range pod.Spec.Containers {
+		add(c.Name, ContainerKind_CONTAINER_KIND_REGULAR)
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		add(c.Name, ContainerKind_CONTAINER_KIND_EPHEMERAL)
+	}
+
+	if len(req.GetContainer()) > 0 && len(containers) == 0 {
+		return nil, fmt.Errorf("container %s not found in pod %s", req.GetContainer(), pod.Name)
+	}
+	return containers, nil
+}
+
+// podLogOptions translates a LogsRequest into the options sent to the kubelet
+// for a single container.
+func podLogOptions(req *LogsRequest, container string, follow bool) *v1.PodLogOptions {
+	opts := &v1.PodLogOptions{
+		Container:  container,
+		Follow:     follow,
+		Previous:   req.GetPrevious(),
+		Timestamps: true,
+	}
+
+	if req.GetTailLines() > 0 {
+		tailLines := req.GetTailLines()
+		opts.TailLines = &tailLines
+	}
+	if req.GetSinceSeconds() > 0 {
+		sinceSeconds := req.GetSinceSeconds()
+		opts.SinceSeconds = &sinceSeconds
+	}
+	if req.GetSinceTime() != nil {
+		sinceTime := metav1.NewTime(req.GetSinceTime().AsTime())
+		opts.SinceTime = &sinceTime
+	}
+	if req.GetLimitBytes() > 0 {
+		limitBytes := req.GetLimitBytes()
+		opts.LimitBytes = &limitBytes
+	}
+
+	return opts
+}
+
+// readLogLines calls fn for every line read from r, trailing newline
+// included, until r is exhausted or fn returns false.
+func readLogLines(r io.Reader, fn func(line string) bool) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 && !fn(line) {
+			return nil
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// logRecord is a log line, or a group of lines in multiline mode, with the
+// timestamp added by the kubelet split from the message. The time is zero when
+// the line had no valid timestamp.
+type logRecord struct {
+	timestamp string
+	time      time.Time
+	message   string
+	start     int64
+	end       int64
+}
+
+// logParser builds the entries of a single container log stream. In
+// multiline mode a record is only complete once the next record starts, so
+// entries are handed out one line late and the last one on flush. The parser
+// keeps track of the byte offsets of the records in the stream so that
+// paginated reads can resume after an entry.
+type logParser struct {
+	query     *logQuery
+	container logContainer
+	pending   *logRecord
+	// offset is the number of bytes of the stream consumed so far.
+	offset int64
+	// start and end delimit the last entry handed out in the stream.
+	start int64
+	end   int64
+}
+
+func (q *logQuery) newParser(container logContainer) *logParser {
+	return &logParser{
+		query:     q,
+		container: container,
+	}
+}
+
+// add processes a raw log line and returns the entry it completes, or nil.
+func (p *logParser) add(line string) *LogEntry {
+	lineStart := p.offset
+	p.offset += int64(len(line))
+
+	line = strings.TrimRight(line, "\r\n")
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+
+	record := splitLogLine(line)
+	record.start = lineStart
+	record.end = p.offset
+
+	if len(p.query.continuation) == 0 {
+		return p.build(record)
+	}
+
+	if p.pending != nil && p.query.isContinuation(record.message) {
+		p.pending.message += "\n" + record.message
+		p.pending.end = record.end
+		return nil
+	}
+
+	entry := p.flush()
+	p.pending = record
+	return entry
+}
+
+// flush returns the multiline record still being grouped, or nil.
+func (p *logParser) flush() *LogEntry {
+	if p.pending == nil {
+		return nil
+	}
+
+	entry := p.build(p.pending)
+	p.pending = nil
+	return entry
+}
+
+// build creates the entry of a log record. JSON and logfmt messages are parsed
+// into the entry fields, with their level and message pulled out. It returns
+// nil when the record is filtered out by the query.
+func (p *logParser) build(record *logRecord) *LogEntry {
+	message := record.message
+
+	var level LogLevel
+	structured := parseStructuredLog(message)
+	if structured != nil {
+		level = structured.level
+		if level == LogLevel_LOG_LEVEL_UNKNOWN {
+			level = detectLogLevel(structured.message)
+		}
+	} else {
+		level = detectLogLevel(message)
+	}
+
+	if !p.query.filter.match(message, level) {
+		return nil
+	}
+
+	var fields *structpb.Struct
+	if structured != nil {
+		fields = structured.fields
+		if len(structured.message) > 0 {
+			message = structured.message
+		}
+	}
+
+	entry := &LogEntry{
+		Container:        p.container.name,
+		Timestamp:        record.timestamp,
+		Message:          message,
+		RestartCount:     p.container.restartCount,
+		ContainerKind:    p.container.kind,
+		Pod:              p.container.pod,
+		Level:            level,
+		Fields:           fields,
+		MissingTimestamp: record.time.IsZero(),
+		ContainerId:      p.container.id,
+	}
+	if !record.time.IsZero() {
+		entry.Time = timestamppb.New(record.time)
+	}
+
+	p.start = record.start
+	p.end = record.end
+	return entry
+}
+
+// splitLogLine splits the RFC3339Nano timestamp added by the kubelet from the
+// log message. Lines without a valid timestamp are kept whole as the message
+// and get a zero time.
+func splitLogLine(line string) *logRecord {
+	if prefix, message, found := strings.Cut(line, " "); found {
+		if t, err := time.Parse(time.RFC3339Nano, prefix); err == nil {
+			return &logRecord{
+				timestamp: prefix,
+				time:      t,
+				message:   message,
+			}
+		}
+	}
+
+	return &logRecord{
+		message: line,
+	}
+}
+
+// countMissingTimestamps returns the number of entries that had no valid
+// kubelet timestamp.
+func countMissingTimestamps(entries []*LogEntry) int64 {
+	var count int64
+	for _, entry := range entries {
+		if entry.GetMissingTimestamp() {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,100 @@
+package kogger
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	logger "github.com/ZolaraProject/library/logger"
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"github.com/klauspost/compress/zstd"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// downloadChunkSize is the size of the chunks of archive sent to the client.
+const downloadChunkSize = 64 * 1024
+
+// writeLogsArchive writes a compressed tar archive holding one file per
+// container of the pods, named <pod>/<container>.log. Archives are gzip
+// compressed unless the request asks otherwise.
+func writeLogsArchive(ctx context.Context, grpcToken string, w io.Writer, pods []v1.Pod, compression Compression) error {
+	var compressor io.WriteCloser
+	switch compression {
+	case Compression_COMPRESSION_UNKNOWN, Compression_COMPRESSION_GZIP:
+		compressor = gzip.NewWriter(w)
+	case Compression_COMPRESSION_ZSTD:
+		encoder, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		compressor = encoder
+	default:
+		return fmt.Errorf("unsupported compression: %s", compression)
+	}
+
+	archive := tar.NewWriter(compressor)
+	for i := range pods {
+		containers, err := logContainers(&pods[i], &LogsRequest{})
+		if err != nil {
+			return err
+		}
+
+		for _, container := range containers {
+			if err := addContainerLogs(ctx, archive, &pods[i], container.name); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				logger.Warn(grpcToken, "Skipping logs of pod %s in namespace %s, container %s: %s", pods[i].Name, pods[i].Namespace, container.name, err)
+			}
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return compressor.Close()
+}
+
+// addContainerLogs adds the logs of a container to the archive. The logs are
+// spooled to a temporary file first since tar headers need the file size.
+func addContainerLogs(ctx context.Context, archive *tar.Writer, pod *v1.Pod, container string) error {
+	podLogs, err := Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &v1.PodLogOptions{
+		Container:  container,
+		Timestamps: true,
+	}).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer podLogs.Close()
+
+	spool, err := os.CreateTemp("", "kogger-logs-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	size, err := io.Copy(spool, podLogs)
+	if err != nil {
+		return err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := archive.WriteHeader(&tar.Header{
+		Name:    fmt.Sprintf("%s/%s.log", pod.Name, container),
+		Mode:    0644,
+		Size:    size,
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err = io.Copy(archive, spool)
+	return err
+}
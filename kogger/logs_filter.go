@@ -0,0 +1,136 @@
+package kogger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+)
+
+// levelPattern finds a severity keyword near the start of a line, e.g.
+// "[ERROR]", "level=warn" or "INFO:". levelScanLength bounds the part of the
+// line that is looked at so a keyword in the message body is not mistaken for
+// the severity.
+var (
+	levelPattern    = regexp.MustCompile(`(?i)\b(trace|debug|info|notice|warn|warning|err|error|crit|critical|fatal|panic)\b`)
+	klogPattern     = regexp.MustCompile(`^([IWEF])\d{4} `)
+	levelScanLength = 80
+)
+
+var logLevels = map[string]LogLevel{
+	"trace":    LogLevel_LOG_LEVEL_TRACE,
+	"debug":    LogLevel_LOG_LEVEL_DEBUG,
+	"info":     LogLevel_LOG_LEVEL_INFO,
+	"notice":   LogLevel_LOG_LEVEL_INFO,
+	"warn":     LogLevel_LOG_LEVEL_WARN,
+	"warning":  LogLevel_LOG_LEVEL_WARN,
+	"err":      LogLevel_LOG_LEVEL_ERROR,
+	"error":    LogLevel_LOG_LEVEL_ERROR,
+	"crit":     LogLevel_LOG_LEVEL_FATAL,
+	"critical": LogLevel_LOG_LEVEL_FATAL,
+	"fatal":    LogLevel_LOG_LEVEL_FATAL,
+	"panic":    LogLevel_LOG_LEVEL_FATAL,
+}
+
+var klogLevels = map[string]LogLevel{
+	"I": LogLevel_LOG_LEVEL_INFO,
+	"W": LogLevel_LOG_LEVEL_WARN,
+	"E": LogLevel_LOG_LEVEL_ERROR,
+	"F": LogLevel_LOG_LEVEL_FATAL,
+}
+
+// detectLogLevel guesses the severity of a log message. It returns
+// LOG_LEVEL_UNKNOWN when no severity can be found.
+func detectLogLevel(message string) LogLevel {
+	if match := klogPattern.FindStringSubmatch(message); match != nil {
+		return klogLevels[match[1]]
+	}
+
+	head := message
+	if len(head) > levelScanLength {
+		head = head[:levelScanLength]
+	}
+	if match := levelPattern.FindString(head); len(match) > 0 {
+		return logLevels[strings.ToLower(match)]
+	}
+	return LogLevel_LOG_LEVEL_UNKNOWN
+}
+
+// logFilter keeps the log lines matching the filters of a LogsRequest.
+type logFilter struct {
+	include           *regexp.Regexp
+	exclude           *regexp.Regexp
+	includeSubstrings []string
+	excludeSubstrings []string
+	minLevel          LogLevel
+}
+
+// newLogFilter compiles the filters of the request. It returns nil when the
+// request has no filter.
+func newLogFilter(req *LogsRequest) (*logFilter, error) {
+	if len(req.GetIncludeRegex()) == 0 && len(req.GetExcludeRegex()) == 0 &&
+		len(req.GetIncludeSubstrings()) == 0 && len(req.GetExcludeSubstrings()) == 0 &&
+		req.GetMinLevel() == LogLevel_LOG_LEVEL_UNKNOWN {
+		return nil, nil
+	}
+
+	filter := &logFilter{
+		includeSubstrings: req.GetIncludeSubstrings(),
+		excludeSubstrings: req.GetExcludeSubstrings(),
+		minLevel:          req.GetMinLevel(),
+	}
+
+	var err error
+	if len(req.GetIncludeRegex()) > 0 {
+		if filter.include, err = regexp.Compile(req.GetIncludeRegex()); err != nil {
+			return nil, fmt.Errorf("invalid include regex: %s", err)
+		}
+	}
+	if len(req.GetExcludeRegex()) > 0 {
+		if filter.exclude, err = regexp.Compile(req.GetExcludeRegex()); err != nil {
+			return nil, fmt.Errorf("invalid exclude regex: %s", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// match reports whether a message passes the filter. A message must match the
+// include regex and contain at least one of the include substrings when they
+// are set, must not match the exclude regex nor contain any exclude substring,
+// and must have a known level at least as severe as the minimum level.
+func (f *logFilter) match(message string, level LogLevel) bool {
+	if f == nil {
+		return true
+	}
+
+	if f.minLevel != LogLevel_LOG_LEVEL_UNKNOWN && (level == LogLevel_LOG_LEVEL_UNKNOWN || level < f.minLevel) {
+		return false
+	}
+	if f.include != nil && !f.include.MatchString(message) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(message) {
+		return false
+	}
+	if len(f.includeSubstrings) > 0 {
+		found := false
+		for _, substring := range f.includeSubstrings {
+			if strings.Contains(message, substring) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, substring := range f.excludeSubstrings {
+		if strings.Contains(message, substring) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,161 @@
+package kogger
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+)
+
+func TestLogFilterMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *LogsRequest
+		message string
+		level   LogLevel
+		match   bool
+	}{
+		{
+			name:    "no filter",
+			req:     &LogsRequest{},
+			message: "anything",
+			match:   true,
+		},
+		{
+			name:    "include regex",
+			req:     &LogsRequest{IncludeRegex: `status=5\d\d`},
+			message: "GET /api status=503",
+			match:   true,
+		},
+		{
+			name:    "include regex not matching",
+			req:     &LogsRequest{IncludeRegex: `status=5\d\d`},
+			message: "GET /api status=200",
+		},
+		{
+			name:    "exclude regex",
+			req:     &LogsRequest{ExcludeRegex: `^GET /healthz`},
+			message: "GET /healthz 200",
+		},
+		{
+			name:    "exclude regex not matching",
+			req:     &LogsRequest{ExcludeRegex: `^GET /healthz`},
+			message: "GET /api 200",
+			match:   true,
+		},
+		{
+			name:    "include and exclude regex",
+			req:     &LogsRequest{IncludeRegex: `^GET`, ExcludeRegex: `healthz`},
+			message: "GET /healthz 200",
+		},
+		{
+			name:    "any include substring",
+			req:     &LogsRequest{IncludeSubstrings: []string{"timeout", "refused"}},
+			message: "dial tcp: connection refused",
+			match:   true,
+		},
+		{
+			name:    "no include substring",
+			req:     &LogsRequest{IncludeSubstrings: []string{"timeout", "refused"}},
+			message: "connected",
+		},
+		{
+			name:    "include substrings are case sensitive",
+			req:     &LogsRequest{IncludeSubstrings: []string{"Timeout"}},
+			message: "read timeout",
+		},
+		{
+			name:    "exclude substring",
+			req:     &LogsRequest{ExcludeSubstrings: []string{"healthz", "readyz"}},
+			message: "GET /readyz 200",
+		},
+		{
+			name:    "exclude substring over include substring",
+			req:     &LogsRequest{IncludeSubstrings: []string{"GET"}, ExcludeSubstrings: []string{"healthz"}},
+			message: "GET /healthz 200",
+		},
+		{
+			name:    "level above the minimum",
+			req:     &LogsRequest{MinLevel: LogLevel_LOG_LEVEL_WARN},
+			message: "disk full",
+			level:   LogLevel_LOG_LEVEL_ERROR,
+			match:   true,
+		},
+		{
+			name:    "level at the minimum",
+			req:     &LogsRequest{MinLevel: LogLevel_LOG_LEVEL_WARN},
+			message: "disk almost full",
+			level:   LogLevel_LOG_LEVEL_WARN,
+			match:   true,
+		},
+		{
+			name:    "level below the minimum",
+			req:     &LogsRequest{MinLevel: LogLevel_LOG_LEVEL_WARN},
+			message: "started",
+			level:   LogLevel_LOG_LEVEL_INFO,
+		},
+		{
+			name:    "unknown level with a minimum",
+			req:     &LogsRequest{MinLevel: LogLevel_LOG_LEVEL_TRACE},
+			message: "started",
+			level:   LogLevel_LOG_LEVEL_UNKNOWN,
+		},
+		{
+			name:    "unknown level without a minimum",
+			req:     &LogsRequest{IncludeSubstrings: []string{"started"}},
+			message: "started",
+			level:   LogLevel_LOG_LEVEL_UNKNOWN,
+			match:   true,
+		},
+		{
+			name:    "every filter",
+			req:     &LogsRequest{IncludeRegex: `^GET`, ExcludeRegex: `healthz`, IncludeSubstrings: []string{"/api"}, ExcludeSubstrings: []string{"200"}, MinLevel: LogLevel_LOG_LEVEL_WARN},
+			message: "GET /api 503",
+			level:   LogLevel_LOG_LEVEL_ERROR,
+			match:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := newLogFilter(test.req)
+			if err != nil {
+				t.Fatalf("failed to create filter: %s", err)
+			}
+			if match := filter.match(test.message, test.level); match != test.match {
+				t.Errorf("expected match %t, got %t", test.match, match)
+			}
+		})
+	}
+}
+
+func TestNewLogFilterInvalidRegex(t *testing.T) {
+	for _, req := range []*LogsRequest{{IncludeRegex: `(`}, {ExcludeRegex: `[`}} {
+		if _, err := newLogFilter(req); err == nil {
+			t.Errorf("expected an error for %v", req)
+		}
+	}
+}
+
+func TestDetectLogLevel(t *testing.T) {
+	tests := []struct {
+		message string
+		level   LogLevel
+	}{
+		{"[ERROR] connection lost", LogLevel_LOG_LEVEL_ERROR},
+		{"2026-10-17 12:00:00 WARNING disk almost full", LogLevel_LOG_LEVEL_WARN},
+		{"I1017 12:00:00.000000       1 main.go:10] started", LogLevel_LOG_LEVEL_INFO},
+		{"E1017 12:00:00.000000       1 main.go:10] failed", LogLevel_LOG_LEVEL_ERROR},
+		{"notice: reloading", LogLevel_LOG_LEVEL_INFO},
+		{"critical: out of memory", LogLevel_LOG_LEVEL_FATAL},
+		{"GET /api 200", LogLevel_LOG_LEVEL_UNKNOWN},
+		{"information", LogLevel_LOG_LEVEL_UNKNOWN},
+		{"request served" + strings.Repeat(".", levelScanLength) + " error", LogLevel_LOG_LEVEL_UNKNOWN},
+	}
+
+	for _, test := range tests {
+		if level := detectLogLevel(test.message); level != test.level {
+			t.Errorf("expected level %s for %q, got %s", test.level, test.message, level)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package kogger
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+)
+
+// multilineFlushDelay is how long a followed stream must stay idle before
+// its pending record is sent.
+const multilineFlushDelay = 500 * time.Millisecond
+
+// defaultContinuationPatterns match the lines folded into the previous record
+// in multiline mode when the request does not provide its own rules. They
+// cover Java, Python and Go stack traces.
+var defaultContinuationPatterns = []string{
+	`^\s`,
+	`^at `,
+	`^\.\.\. \d+ (more|common frames omitted)`,
+	`^Caused by:`,
+	`^Traceback `,
+	`^goroutine \d+ `,
+	`^created by `,
+	`^\[signal `,
+	`^[\w./*()\[\]-]+\(.*\)$`,
+}
+
+// compileContinuationPatterns returns the continuation rules of the request,
+// or nil when multiline mode is off.
+func compileContinuationPatterns(req *LogsRequest) ([]*regexp.Regexp, error) {
+	if !req.GetMultiline() {
+		return nil, nil
+	}
+
+	patterns := req.GetContinuationPatterns()
+	if len(patterns) == 0 {
+		patterns = defaultContinuationPatterns
+	}
+
+	continuation := []*regexp.Regexp{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid continuation pattern %q: %s", pattern, err)
+		}
+		continuation = append(continuation, re)
+	}
+	return continuation, nil
+}
+
+// isContinuation reports whether a message continues the previous record.
+func (q *logQuery) isContinuation(message string) bool {
+	for _, re := range q.continuation {
+		if re.MatchString(message) {
+			return true
+		}
+	}
+	return false
+}
+
+// followLogLines feeds the lines of a followed stream to the parser and sends
+// the entries, until the stream ends or send returns false. In multiline mode
+// the last record stays pending until the next line tells whether it goes on,
+// so it is sent once the stream has been idle for multilineFlushDelay rather
+// than when the container logs again. A continuation arriving later starts a
+// record of its own.
+func followLogLines(r io.Reader, parser *logParser, send func(entry *LogEntry) bool) error {
+	if len(parser.query.continuation) == 0 {
+		return readLogLines(r, func(line string) bool {
+			return send(parser.add(line))
+		})
+	}
+
+	lines := make(chan string)
+	done := make(chan error, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		done <- readLogLines(r, func(line string) bool {
+			select {
+			case lines <- line:
+				return true
+			case <-stop:
+				return false
+			}
+		})
+	}()
+
+	idle := time.NewTimer(multilineFlushDelay)
+	idle.Stop()
+	defer idle.Stop()
+	for {
+		select {
+		case line := <-lines:
+			if !send(parser.add(line)) {
+				return nil
+			}
+			if parser.pending != nil {
+				idle.Reset(multilineFlushDelay)
+			}
+		case <-idle.C:
+			if !send(parser.flush()) {
+				return nil
+			}
+		case err := <-done:
+			return err
+		}
+	}
+}
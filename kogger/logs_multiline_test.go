@@ -0,0 +1,119 @@
+package kogger
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+)
+
+func TestIsContinuation(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		message  string
+		expected bool
+	}{
+		{"indented line", nil, "    at com.example.App.main(App.java:10)", true},
+		{"tab", nil, "\tmain.main()", true},
+		{"java frame", nil, "at com.example.App.main(App.java:10)", true},
+		{"java omitted frames", nil, "... 12 more", true},
+		{"java common frames", nil, "... 3 common frames omitted", true},
+		{"java cause", nil, "Caused by: java.io.IOException: closed", true},
+		{"python traceback", nil, "Traceback (most recent call last):", true},
+		{"go goroutine", nil, "goroutine 1 [running]:", true},
+		{"go created by", nil, "created by net/http.(*Server).Serve", true},
+		{"go signal", nil, "[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x0]", true},
+		{"go function", nil, "main.(*server).handle(0xc000010000, {0x0, 0x0})", true},
+		{"new record", nil, "GET /api 200", false},
+		{"panic", nil, "panic: runtime error: invalid memory address", false},
+		{"attribute", nil, "attempt 3 failed", false},
+		{"empty", nil, "", false},
+		{"custom pattern", []string{`^\|`}, "| row 2", true},
+		{"custom patterns replace the defaults", []string{`^\|`}, "\tmain.main()", false},
+		{"any custom pattern", []string{`^\|`, `^-{3}`}, "--- separator", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := newLogQuery(&LogsRequest{Multiline: true, ContinuationPatterns: test.patterns})
+			if err != nil {
+				t.Fatalf("failed to create query: %s", err)
+			}
+			if continuation := query.isContinuation(test.message); continuation != test.expected {
+				t.Errorf("expected continuation %t for %q, got %t", test.expected, test.message, continuation)
+			}
+		})
+	}
+}
+
+func TestIsContinuationWithoutMultiline(t *testing.T) {
+	query, err := newLogQuery(&LogsRequest{ContinuationPatterns: []string{`^\s`}})
+	if err != nil {
+		t.Fatalf("failed to create query: %s", err)
+	}
+	if query.isContinuation("\tmain.main()") {
+		t.Errorf("expected no continuation when multiline mode is off")
+	}
+}
+
+func TestInvalidContinuationPattern(t *testing.T) {
+	if _, err := newLogQuery(&LogsRequest{Multiline: true, ContinuationPatterns: []string{`(`}}); err == nil {
+		t.Errorf("expected an error for an invalid pattern")
+	}
+}
+
+func TestFollowLogLinesIdleFlush(t *testing.T) {
+	query, err := newLogQuery(&LogsRequest{Multiline: true})
+	if err != nil {
+		t.Fatalf("failed to create query: %s", err)
+	}
+
+	reader, writer := io.Pipe()
+	entries := make(chan *LogEntry, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- followLogLines(reader, query.newParser(logContainer{name: "app"}), func(entry *LogEntry) bool {
+			if entry != nil {
+				entries <- entry
+			}
+			return true
+		})
+	}()
+
+	write := func(line string) {
+		if _, err := writer.Write([]byte(line + "\n")); err != nil {
+			t.Fatalf("failed to write: %s", err)
+		}
+	}
+	receive := func() *LogEntry {
+		select {
+		case entry := <-entries:
+			return entry
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no entry sent while the stream is idle")
+			return nil
+		}
+	}
+
+	write("2026-10-17T12:00:00Z panic: boom")
+	write("2026-10-17T12:00:00Z goroutine 1 [running]:")
+	write("2026-10-17T12:00:00Z \tmain.main()")
+	if entry := receive(); entry.GetMessage() != "panic: boom\ngoroutine 1 [running]:\n\tmain.main()" {
+		t.Errorf("unexpected record %q", entry.GetMessage())
+	}
+
+	write("2026-10-17T12:00:01Z listening on :8080")
+	if entry := receive(); entry.GetMessage() != "listening on :8080" {
+		t.Errorf("unexpected record %q", entry.GetMessage())
+	}
+
+	writer.Close()
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("unexpected entry %q", (<-entries).GetMessage())
+	}
+}
@@ -0,0 +1,479 @@
+package kogger
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	logger "github.com/ZolaraProject/library/logger"
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxLogPageSize caps the number of entries of a single page.
+const maxLogPageSize = 10000
+
+// maxBackwardWindowStep bounds how far back the window of a backward page is
+// moved at once, past which the page is read from the anchor.
+const maxBackwardWindowStep = 24 * time.Hour
+
+// logCursor is a position in the logs of a pod. The kubelet log stream of the
+// container is always opened from the same sinceTime anchor, which makes the
+// byte offset in that stream stable from one page to the other. A forward
+// cursor points right after the last entry of a page, a backward cursor right
+// before the first one.
+//
+// The kubelet cannot start a stream at a byte offset, so a cursor also carries
+// a window: a later sinceTime, whose stream is the stream of the anchor from
+// WindowOffset on, as the kubelet timestamps of a container come in order.
+// Pages are read from the window, which follows the entries handed out, so
+// that reaching the offset of a cursor costs about a page rather than the
+// whole stream before it. Only going back into an earlier container reads it
+// whole, as the offset of its end is not known.
+//
+// Offsets only stay valid while the kubelet keeps the logs they were counted
+// in: once the log file of a container is rotated, the stream opened from the
+// same anchor starts at a different line, and a cursor from before the
+// rotation resumes at an arbitrary position in that container, skipping or
+// repeating entries. Clients paging through long lived containers should start
+// over without cursor when the entries stop lining up.
+type logCursor struct {
+	// Pod identifies the pod the cursor was handed out for.
+	Pod          string `json:"p"`
+	Container    string `json:"c"`
+	SinceTime    string `json:"t,omitempty"`
+	Offset       int64  `json:"o"`
+	Backward     bool   `json:"b,omitempty"`
+	WindowTime   string `json:"wt,omitempty"`
+	WindowOffset int64  `json:"wo,omitempty"`
+}
+
+func (c *logCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeLogCursor(cursor string) (*logCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %s", err)
+	}
+
+	decoded := &logCursor{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %s", err)
+	}
+	for _, since := range []string{decoded.SinceTime, decoded.WindowTime} {
+		if len(since) > 0 {
+			if _, err := time.Parse(time.RFC3339, since); err != nil {
+				return nil, fmt.Errorf("invalid cursor: %s", err)
+			}
+		}
+	}
+	if decoded.Offset < 0 || decoded.WindowOffset < 0 {
+		return nil, fmt.Errorf("invalid cursor: negative offset")
+	}
+	if decoded.WindowOffset > decoded.Offset || (decoded.WindowOffset > 0 && len(decoded.WindowTime) == 0) {
+		return nil, fmt.Errorf("invalid cursor: window past the offset")
+	}
+	return decoded, nil
+}
+
+// window returns the stream the cursor is read from.
+func (c *logCursor) window() logWindow {
+	if len(c.WindowTime) == 0 {
+		return logWindow{since: c.SinceTime}
+	}
+	return logWindow{since: c.WindowTime, base: c.WindowOffset}
+}
+
+// logCursorPod identifies a pod in cursors. The UID tells apart the pods
+// recreated under the same name, whose logs are not the same.
+func logCursorPod(pod *v1.Pod) string {
+	sum := sha256.Sum256([]byte(pod.Namespace + "/" + pod.Name + "/" + string(pod.UID)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// logWindow is a container log stream opened from since, which holds the
+// stream of the cursor anchor from byte base on.
+type logWindow struct {
+	since string
+	base  int64
+}
+
+// pagedEntry is an entry along with its byte range in the container stream.
+// second is the offset of the first line of the second of the entry, where
+// a window opened at that second starts, or -1 when it is not known.
+type pagedEntry struct {
+	entry     *LogEntry
+	container string
+	start     int64
+	end       int64
+	second    int64
+}
+
+// getPodLogsPage returns a single page of the logs of a pod. Containers are
+// paged one after the other, in the order returned by logContainers.
+func getPodLogsPage(ctx context.Context, grpcToken string, pod *v1.Pod, query *logQuery) (*Logs, error) {
+	req := query.req
+
+	containers, err := logContainers(pod, req)
+	if err != nil {
+		logger.Err(grpcToken, "Failed to select containers for pod %s in namespace %s: %s", pod.Name, pod.Namespace, err)
+		return nil, err
+	}
+	if len(containers) == 0 {
+		return &Logs{Pod: pod.Name, Namespace: pod.Namespace}, nil
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize == 0 {
+		pageSize = maxLogPageSize
+	}
+
+	var cursor *logCursor
+	if len(req.GetCursor()) > 0 {
+		if cursor, err = decodeLogCursor(req.GetCursor()); err != nil {
+			logger.Err(grpcToken, "Failed to decode cursor for pod %s in namespace %s: %s", pod.Name, pod.Namespace, err)
+			return nil, err
+		}
+		if cursor.Pod != logCursorPod(pod) {
+			logger.Err(grpcToken, "Cursor does not belong to pod %s in namespace %s", pod.Name, pod.Namespace)
+			return nil, fmt.Errorf("cursor does not belong to pod %s in namespace %s", pod.Name, pod.Namespace)
+		}
+	} else {
+		cursor = &logCursor{
+			Pod:       logCursorPod(pod),
+			Container: containers[0].name,
+		}
+		if req.GetSinceTime() != nil {
+			cursor.SinceTime = req.GetSinceTime().AsTime().UTC().Format(time.RFC3339)
+		} else if req.GetSinceSeconds() > 0 {
+			cursor.SinceTime = time.Now().Add(-time.Duration(req.GetSinceSeconds()) * time.Second).UTC().Format(time.RFC3339)
+		}
+	}
+
+	index := -1
+	for i, container := range containers {
+		if container.name == cursor.Container {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		logger.Err(grpcToken, "Cursor container %s not found in pod %s in namespace %s", cursor.Container, pod.Name, pod.Namespace)
+		return nil, fmt.Errorf("cursor container %s not found in pod %s", cursor.Container, pod.Name)
+	}
+
+	anchor := logWindow{since: cursor.SinceTime}
+	window := cursor.window()
+
+	// read holds every entry read, the page along with the entries read before
+	// it by backward pages, which give the window of the previous cursor.
+	var page, read []pagedEntry
+	exhausted := false
+	if cursor.Backward {
+		page, read, window, err = readContainerBackward(ctx, grpcToken, pod, containers[index], query, cursor, pageSize)
+		for i := index - 1; err == nil && i >= 0 && len(page) < pageSize; i-- {
+			var previous []pagedEntry
+			previous, _, err = readContainerRange(ctx, grpcToken, pod, containers[i], query, anchor, 0, -1, time.Time{}, pageSize-len(page), true)
+			read = append(append([]pagedEntry{}, previous...), read...)
+			page = append(previous, page...)
+		}
+		// Backward reads go on up to twice the page, so a full page with
+		// nothing before it in the first container is the first page.
+		exhausted = len(page) < pageSize || (index == 0 && len(read) == len(page))
+	} else {
+		from, fromWindow := cursor.Offset, window
+		for i := index; err == nil && i < len(containers) && len(page) < pageSize; i++ {
+			var next []pagedEntry
+			next, _, err = readContainerRange(ctx, grpcToken, pod, containers[i], query, fromWindow, from, -1, time.Time{}, pageSize-len(page), false)
+			page = append(page, next...)
+			from, fromWindow = 0, anchor
+			exhausted = i == len(containers)-1 && len(page) < pageSize
+		}
+		read = page
+	}
+	if err != nil {
+		logger.Err(grpcToken, "Failed to read logs page for pod %s in namespace %s: %s", pod.Name, pod.Namespace, err)
+		return nil, err
+	}
+
+	logs := &Logs{
+		Pod:       pod.Name,
+		Namespace: pod.Namespace,
+		Entries:   []*LogEntry{},
+	}
+	for _, paged := range page {
+		logs.Entries = append(logs.Entries, paged.entry)
+	}
+	logs.MissingTimestamps = countMissingTimestamps(logs.Entries)
+
+	// The window of the cursor container may have moved back while reading;
+	// other containers are read from the anchor.
+	containerWindow := func(container string) logWindow {
+		if container == cursor.Container {
+			return window
+		}
+		return anchor
+	}
+	position := func(container string, offset int64, backward bool) string {
+		c := &logCursor{
+			Pod:       cursor.Pod,
+			Container: container,
+			SinceTime: cursor.SinceTime,
+			Offset:    offset,
+			Backward:  backward,
+		}
+		if w := cursorWindow(read, container, offset, backward, containerWindow(container)); w != anchor {
+			c.WindowTime, c.WindowOffset = w.since, w.base
+		}
+		return c.encode()
+	}
+
+	if len(page) == 0 {
+		if cursor.Backward {
+			logs.NextCursor = position(cursor.Container, cursor.Offset, false)
+		} else if len(req.GetCursor()) > 0 {
+			logs.PreviousCursor = position(cursor.Container, cursor.Offset, true)
+		}
+		return logs, nil
+	}
+
+	first, last := page[0], page[len(page)-1]
+	if !cursor.Backward || !exhausted {
+		logs.PreviousCursor = position(first.container, first.start, true)
+	}
+	if cursor.Backward || !exhausted {
+		logs.NextCursor = position(last.container, last.end, false)
+	}
+	if !cursor.Backward && len(req.GetCursor()) == 0 && index == 0 && first.start == 0 {
+		logs.PreviousCursor = ""
+	}
+
+	logger.Debug(grpcToken, "Returning page of %d log entries for pod %s in namespace %s", len(logs.Entries), pod.Name, pod.Namespace)
+	return logs, nil
+}
+
+// cursorWindow returns the window of a cursor at offset in a container. It is
+// opened at the second of one of the entries read, the earliest one for a
+// backward cursor so that the window holds the entries before the page, the
+// latest one for a forward cursor, and falls back to the window the entries
+// were read from.
+func cursorWindow(entries []pagedEntry, container string, offset int64, backward bool, fallback logWindow) logWindow {
+	window := fallback
+	for _, paged := range entries {
+		if paged.container != container || paged.second < 0 || paged.second > offset {
+			continue
+		}
+		window = logWindow{
+			since: paged.entry.GetTime().AsTime().Truncate(time.Second).UTC().Format(time.RFC3339),
+			base:  paged.second,
+		}
+		if backward {
+			break
+		}
+	}
+	return window
+}
+
+// readContainerBackward returns the last limit entries of the cursor container
+// before the cursor offset, along with every entry read, up to limit more
+// before the page. The entries are read from the window of the cursor, which
+// is moved back while it holds too few of them, so that the window of the
+// previous cursor holds a page as well. It returns the window the earliest
+// entries were read from.
+func readContainerBackward(ctx context.Context, grpcToken string, pod *v1.Pod, container logContainer, query *logQuery, cursor *logCursor, limit int) ([]pagedEntry, []pagedEntry, logWindow, error) {
+	anchor := logWindow{since: cursor.SinceTime}
+	window := cursor.window()
+
+	entries, _, err := readContainerRange(ctx, grpcToken, pod, container, query, window, window.base, cursor.Offset, time.Time{}, 2*limit, true)
+
+	// The window is first moved back by the time the missing entries should
+	// span at the pace of the entries read, then by twice as far on every try.
+	since, _ := time.Parse(time.RFC3339, window.since)
+	step := time.Second
+	if len(entries) > 0 && entries[len(entries)-1].entry.GetTime() != nil {
+		span := entries[len(entries)-1].entry.GetTime().AsTime().Sub(since)
+		step += span * time.Duration(2*limit-len(entries)) / time.Duration(len(entries))
+	}
+	for ; err == nil && len(entries) < 2*limit && window.base > 0; step *= 2 {
+		// The earlier stream joins the window at the first line of its second,
+		// which tells the offset of the earlier stream in the anchor one.
+		since, _ = time.Parse(time.RFC3339, window.since)
+		earlier := logWindow{since: since.Add(-step).UTC().Format(time.RFC3339)}
+		var previous []pagedEntry
+		joined := int64(-1)
+		if anchorTime, _ := time.Parse(time.RFC3339, anchor.since); step <= maxBackwardWindowStep && (len(anchor.since) == 0 || since.Add(-step).After(anchorTime)) {
+			previous, joined, err = readContainerRange(ctx, grpcToken, pod, container, query, earlier, 0, -1, since, 2*limit-len(entries), true)
+		}
+		if err == nil && (joined < 0 || joined > window.base) {
+			previous, _, err = readContainerRange(ctx, grpcToken, pod, container, query, anchor, 0, window.base, time.Time{}, 2*limit-len(entries), true)
+			entries = append(previous, entries...)
+			window = anchor
+			break
+		}
+
+		earlier.base = window.base - joined
+		for i := range previous {
+			previous[i].start += earlier.base
+			previous[i].end += earlier.base
+			if previous[i].second >= 0 {
+				previous[i].second += earlier.base
+			}
+		}
+		entries = append(previous, entries...)
+		window = earlier
+	}
+
+	split := max(len(entries)-limit, 0)
+	return entries[split:], entries, window, err
+}
+
+// readContainerRange reads the entries of a container stream, opened from the
+// window since, between the from and until byte offsets of the anchor stream
+// (until < 0 reads to the end). Forward reads stop after limit entries,
+// backward reads keep the last limit entries of the range. When stop is set,
+// the read also ends before the first line at stop or later, and the offset of
+// that line is returned, -1 when the read ended before.
+//
+// Like getPodLogs, a stream which cannot be opened or read is skipped with a
+// warning, keeping the entries read before the failure, so that a single
+// container does not fail the whole page.
+func readContainerRange(ctx context.Context, grpcToken string, pod *v1.Pod, container logContainer, query *logQuery, window logWindow, from, until int64, stop time.Time, limit int, backward bool) ([]pagedEntry, int64, error) {
+	opts := podLogOptions(query.req, container.name, false)
+	opts.SinceSeconds = nil
+	opts.SinceTime = nil
+	if len(window.since) > 0 {
+		anchor, err := time.Parse(time.RFC3339, window.since)
+		if err != nil {
+			return nil, -1, err
+		}
+		since := metav1.NewTime(anchor)
+		opts.SinceTime = &since
+	}
+	if until >= 0 {
+		// Have the kubelet end the stream at the until offset.
+		limitBytes := until - window.base
+		if limitBytes <= 0 || from >= until {
+			return nil, -1, nil
+		}
+		opts.LimitBytes = &limitBytes
+	}
+
+	stream, err := Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).Stream(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, -1, ctx.Err()
+		}
+		logger.Warn(grpcToken, "Skipping logs of pod %s in namespace %s, container %s: %s", pod.Name, pod.Namespace, container.name, err)
+		return nil, -1, nil
+	}
+	defer stream.Close()
+
+	if _, err := io.CopyN(io.Discard, stream, from-window.base); err == io.EOF {
+		return nil, -1, nil
+	} else if err != nil {
+		if ctx.Err() != nil {
+			return nil, -1, ctx.Err()
+		}
+		logger.Warn(grpcToken, "Skipping logs of pod %s in namespace %s, container %s: %s", pod.Name, pod.Namespace, container.name, err)
+		return nil, -1, nil
+	}
+
+	var reader io.Reader = stream
+	if until >= 0 {
+		reader = io.LimitReader(stream, until-from)
+	}
+
+	// seconds maps the seconds seen to the offset of their first line, as long
+	// as that line starts a record and the lines before it were read too,
+	// either in this range or through the anchor of the window.
+	seconds := map[int64]int64{}
+	latest := int64(-1)
+	ordered := true
+
+	entries := []pagedEntry{}
+	parser := query.newParser(container)
+	parser.offset = from
+	keep := func(entry *LogEntry) bool {
+		if entry == nil {
+			return true
+		}
+
+		second := int64(-1)
+		if entry.GetTime() != nil {
+			unix := entry.GetTime().AsTime().Unix()
+			if start, ok := seconds[unix]; ok {
+				second = start
+			}
+			for s := range seconds {
+				if s < unix {
+					delete(seconds, s)
+				}
+			}
+		}
+		entries = append(entries, pagedEntry{
+			entry:     entry,
+			container: container.name,
+			start:     parser.start,
+			end:       parser.end,
+			second:    second,
+		})
+		if backward && len(entries) > limit {
+			entries = entries[1:]
+		}
+		return backward || len(entries) < limit
+	}
+
+	full := false
+	joined := int64(-1)
+	err = readLogLines(reader, func(line string) bool {
+		record := splitLogLine(strings.TrimRight(line, "\r\n"))
+		if !record.time.IsZero() {
+			if !stop.IsZero() && !record.time.Before(stop) {
+				joined = parser.offset
+				return false
+			}
+
+			unix := record.time.Unix()
+			if unix < latest {
+				ordered = false
+			}
+			starts := len(query.continuation) == 0 || !query.isContinuation(record.message)
+			if unix > latest && starts && (latest >= 0 || from == window.base) {
+				seconds[unix] = parser.offset
+			}
+			latest = max(latest, unix)
+		}
+
+		full = !keep(parser.add(line))
+		return !full
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, -1, ctx.Err()
+		}
+		logger.Warn(grpcToken, "Partially read logs of pod %s in namespace %s, container %s: %s", pod.Name, pod.Namespace, container.name, err)
+	}
+	if !full {
+		keep(parser.flush())
+	}
+
+	// A window skips the lines older than its second, so windows only hold
+	// the stream of the anchor when the lines come in order.
+	if !ordered {
+		for i := range entries {
+			entries[i].second = -1
+		}
+	}
+	return entries, joined, nil
+}
@@ -0,0 +1,259 @@
+package kogger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+)
+
+// fakeKubelet serves the logs of the containers of a pod like the kubelet:
+// the lines at sinceTime or later, cut after limitBytes. It records the bytes
+// of every log stream read by the client.
+type fakeKubelet struct {
+	lines map[string][]string
+	read  []int64
+}
+
+func (k *fakeKubelet) logs(pod string, query url.Values) (int, string) {
+	var since time.Time
+	if len(query.Get("sinceTime")) > 0 {
+		var err error
+		if since, err = time.Parse(time.RFC3339, query.Get("sinceTime")); err != nil {
+			return http.StatusBadRequest, err.Error()
+		}
+	}
+
+	body := ""
+	for _, line := range k.lines[query.Get("container")] {
+		if record := splitLogLine(strings.TrimSuffix(line, "\n")); !record.time.Before(since) {
+			body += line
+		}
+	}
+	if limit, err := strconv.Atoi(query.Get("limitBytes")); err == nil && limit < len(body) {
+		body = body[:limit]
+	}
+	return http.StatusOK, body
+}
+
+func (k *fakeKubelet) transport(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := rt.RoundTrip(req)
+		if err == nil && strings.HasSuffix(req.URL.Path, "/log") {
+			resp.Body = &countingBody{ReadCloser: resp.Body, read: func(n int64) { k.read = append(k.read, n) }}
+		}
+		return resp, err
+	})
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// countingBody reports the bytes read from a response body once closed.
+type countingBody struct {
+	io.ReadCloser
+	n    int64
+	read func(n int64)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.read(b.n)
+	return b.ReadCloser.Close()
+}
+
+// testKubeletLineSize is the size of the lines of testKubeletLines.
+const testKubeletLineSize = 200
+
+// testKubeletLines returns count lines of the container, one per second from
+// testCollectorTime on and padded to testKubeletLineSize bytes, with a stack
+// trace line every ten lines.
+func testKubeletLines(container string, count int) []string {
+	lines := []string{}
+	for i := range count {
+		line := fmt.Sprintf("%s %s line %03d ", testCollectorTime.Add(time.Duration(i)*time.Second).Format(time.RFC3339Nano), container, i)
+		lines = append(lines, line+strings.Repeat("x", testKubeletLineSize-len(line)-1)+"\n")
+		if i%10 == 9 {
+			lines = append(lines, fmt.Sprintf("%s \tat %s.main()\n", testCollectorTime.Add(time.Duration(i)*time.Second).Format(time.RFC3339Nano), container))
+		}
+	}
+	return lines
+}
+
+// useFakeKubelet serves a pod web-0 with an app and a sidecar container.
+func useFakeKubelet(t *testing.T, app, sidecar int) *fakeKubelet {
+	t.Helper()
+	kubelet := &fakeKubelet{lines: map[string][]string{
+		"app":     testKubeletLines("app", app),
+		"sidecar": testKubeletLines("sidecar", sidecar),
+	}}
+	pod := testPod("web-0", "app", "sidecar")
+	pod.UID = "b8e6a1f2"
+	useFakeKubernetes(t, &fakeKubernetes{
+		objects:   map[string]any{"/api/v1/namespaces/default/pods/web-0": pod},
+		logs:      kubelet.logs,
+		transport: kubelet.transport,
+	})
+	return kubelet
+}
+
+func testPageMessages(logs *Logs) []string {
+	messages := []string{}
+	for _, entry := range logs.GetEntries() {
+		message, _, _ := strings.Cut(entry.GetMessage(), "\n")
+		messages = append(messages, strings.TrimRight(message, " x"))
+	}
+	return messages
+}
+
+func TestGetLogsPages(t *testing.T) {
+	kubelet := useFakeKubelet(t, 400, 25)
+	expected := []string{}
+	for i := range 400 {
+		expected = append(expected, fmt.Sprintf("app line %03d", i))
+	}
+	for i := range 25 {
+		expected = append(expected, fmt.Sprintf("sidecar line %03d", i))
+	}
+
+	req := &LogsRequest{Namespace: "default", Pod: "web-0", PageSize: 30, Multiline: true}
+	forward := []string{}
+	pages := []*Logs{}
+	for {
+		logs, err := (&server{}).GetLogs(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(pages) == 0 && len(logs.GetPreviousCursor()) > 0 {
+			t.Errorf("expected no previous cursor on the first page")
+		}
+		pages = append(pages, logs)
+		forward = append(forward, testPageMessages(logs)...)
+		if len(logs.GetNextCursor()) == 0 {
+			break
+		}
+		req.Cursor = logs.GetNextCursor()
+	}
+	if !slices.Equal(forward, expected) {
+		t.Fatalf("unexpected forward pages %v", forward)
+	}
+	if crossing := testPageMessages(pages[13]); crossing[0] != "app line 390" || crossing[len(crossing)-1] != "sidecar line 019" {
+		t.Errorf("expected the page to cross from app to sidecar, got %v", crossing)
+	}
+	// Every page is read from its window rather than from the start, give or
+	// take the buffers of the client.
+	const maxRead = 4*30*testKubeletLineSize + 16<<10
+	for i, read := range kubelet.read {
+		if read > maxRead {
+			t.Errorf("forward request %d read %d bytes", i, read)
+		}
+	}
+
+	kubelet.read = nil
+	backward := []string{}
+	req.Cursor = pages[len(pages)-1].GetPreviousCursor()
+	for len(req.GetCursor()) > 0 {
+		logs, err := (&server{}).GetLogs(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		backward = append(testPageMessages(logs), backward...)
+		req.Cursor = logs.GetPreviousCursor()
+	}
+	if !slices.Equal(backward, expected[:len(expected)-len(pages[len(pages)-1].GetEntries())]) {
+		t.Fatalf("unexpected backward pages %v", backward)
+	}
+	// Crossing back into app reads it whole, its length being unknown, but
+	// the pages before are read from their window again.
+	whole := 0
+	for _, read := range kubelet.read {
+		if read > maxRead {
+			whole++
+		}
+	}
+	if whole > 1 {
+		t.Errorf("expected a single backward request to read a whole container, got %d in %v", whole, kubelet.read)
+	}
+}
+
+func TestGetLogsPageCursors(t *testing.T) {
+	useFakeKubelet(t, 40, 5)
+
+	first, err := (&server{}).GetLogs(context.Background(), &LogsRequest{Namespace: "default", Pod: "web-0", PageSize: 20})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	next, err := (&server{}).GetLogs(context.Background(), &LogsRequest{Namespace: "default", Pod: "web-0", PageSize: 20, Cursor: first.GetNextCursor()})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	previous, err := (&server{}).GetLogs(context.Background(), &LogsRequest{Namespace: "default", Pod: "web-0", PageSize: 20, Cursor: next.GetPreviousCursor()})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !slices.Equal(testPageMessages(previous), testPageMessages(first)) {
+		t.Errorf("expected the previous page to be the first one, got %v", testPageMessages(previous))
+	}
+	if len(previous.GetPreviousCursor()) > 0 {
+		t.Errorf("expected no previous cursor before the first entry")
+	}
+
+	last := next
+	for len(last.GetNextCursor()) > 0 {
+		if last, err = (&server{}).GetLogs(context.Background(), &LogsRequest{Namespace: "default", Pod: "web-0", PageSize: 20, Cursor: last.GetNextCursor()}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if len(last.GetEntries()) >= 20 || len(last.GetPreviousCursor()) == 0 {
+		t.Errorf("expected a last partial page with a previous cursor, got %d entries", len(last.GetEntries()))
+	}
+
+	encode := func(cursor *logCursor) string {
+		return cursor.encode()
+	}
+	pod := testPod("web-0", "app", "sidecar")
+	pod.UID = "b8e6a1f2"
+	other := testPod("web-0", "app", "sidecar")
+	other.UID = "0c4d7e93"
+
+	tests := []struct {
+		name   string
+		cursor string
+		err    string
+	}{
+		{name: "not base64", cursor: "not a cursor!", err: "invalid cursor"},
+		{name: "not json", cursor: "bm90IGpzb24", err: "invalid cursor"},
+		{name: "invalid time", cursor: encode(&logCursor{Pod: logCursorPod(pod), Container: "app", SinceTime: "yesterday"}), err: "invalid cursor"},
+		{name: "negative offset", cursor: encode(&logCursor{Pod: logCursorPod(pod), Container: "app", Offset: -1}), err: "negative offset"},
+		{name: "negative window", cursor: encode(&logCursor{Pod: logCursorPod(pod), Container: "app", Offset: 10, WindowTime: "2026-10-17T12:00:00Z", WindowOffset: -1}), err: "negative offset"},
+		{name: "window past the offset", cursor: encode(&logCursor{Pod: logCursorPod(pod), Container: "app", Offset: 10, WindowTime: "2026-10-17T12:00:00Z", WindowOffset: 20}), err: "window past the offset"},
+		{name: "unknown container", cursor: encode(&logCursor{Pod: logCursorPod(pod), Container: "db"}), err: "cursor container db not found"},
+		{name: "other pod", cursor: encode(&logCursor{Pod: logCursorPod(other), Container: "app"}), err: "cursor does not belong to pod web-0"},
+		{name: "no pod", cursor: encode(&logCursor{Container: "app"}), err: "cursor does not belong to pod web-0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := (&server{}).GetLogs(context.Background(), &LogsRequest{Namespace: "default", Pod: "web-0", PageSize: 20, Cursor: test.cursor})
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
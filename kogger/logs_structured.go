@@ -0,0 +1,149 @@
+package kogger
+
+import (
+	"encoding/json"
+	"strings"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Keys holding the severity and the message of a structured log line, in order
+// of preference.
+var (
+	structuredLevelKeys   = []string{"level", "lvl", "severity", "log.level", "loglevel"}
+	structuredMessageKeys = []string{"msg", "message", "@message", "log"}
+)
+
+// numericLogLevels maps the numeric levels written by pino and bunyan.
+var numericLogLevels = map[float64]LogLevel{
+	10: LogLevel_LOG_LEVEL_TRACE,
+	20: LogLevel_LOG_LEVEL_DEBUG,
+	30: LogLevel_LOG_LEVEL_INFO,
+	40: LogLevel_LOG_LEVEL_WARN,
+	50: LogLevel_LOG_LEVEL_ERROR,
+	60: LogLevel_LOG_LEVEL_FATAL,
+}
+
+// structuredLog is a JSON or logfmt log line parsed into its fields.
+type structuredLog struct {
+	fields  *structpb.Struct
+	level   LogLevel
+	message string
+}
+
+// parseStructuredLog parses a JSON object or logfmt log line and extracts its
+// normalized level and message. It returns nil for unstructured lines.
+func parseStructuredLog(line string) *structuredLog {
+	var values map[string]any
+
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}") {
+		if err := json.Unmarshal([]byte(trimmed), &values); err != nil {
+			return nil
+		}
+	} else {
+		values = parseLogfmt(trimmed)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	fields, err := structpb.NewStruct(values)
+	if err != nil {
+		return nil
+	}
+
+	structured := &structuredLog{
+		fields: fields,
+		level:  LogLevel_LOG_LEVEL_UNKNOWN,
+	}
+	for _, key := range structuredLevelKeys {
+		if value, ok := values[key]; ok {
+			structured.level = normalizeLogLevel(value)
+			break
+		}
+	}
+	for _, key := range structuredMessageKeys {
+		if value, ok := values[key].(string); ok {
+			structured.message = value
+			break
+		}
+	}
+
+	return structured
+}
+
+// normalizeLogLevel converts the level value of a structured line.
+func normalizeLogLevel(value any) LogLevel {
+	switch level := value.(type) {
+	case string:
+		if normalized, ok := logLevels[strings.ToLower(level)]; ok {
+			return normalized
+		}
+		return detectLogLevel(level)
+	case float64:
+		return numericLogLevels[level]
+	}
+	return LogLevel_LOG_LEVEL_UNKNOWN
+}
+
+// parseLogfmt parses a logfmt line such as `level=info msg="user logged in"`.
+// It returns nil unless the whole line is made of at least two key=value pairs.
+func parseLogfmt(line string) map[string]any {
+	values := make(map[string]any)
+
+	for i := 0; i < len(line); {
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+		if i == len(line) {
+			break
+		}
+
+		start := i
+		for i < len(line) && isLogfmtKeyChar(line[i]) {
+			i++
+		}
+		if i == start || i == len(line) || line[i] != '=' {
+			return nil
+		}
+		key := line[start:i]
+		i++
+
+		var value string
+		if i < len(line) && line[i] == '"' {
+			var builder strings.Builder
+			i++
+			for i < len(line) && line[i] != '"' {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+				}
+				builder.WriteByte(line[i])
+				i++
+			}
+			if i == len(line) {
+				return nil
+			}
+			i++
+			value = builder.String()
+		} else {
+			start = i
+			for i < len(line) && line[i] != ' ' {
+				i++
+			}
+			value = line[start:i]
+		}
+
+		values[key] = value
+	}
+
+	if len(values) < 2 {
+		return nil
+	}
+	return values
+}
+
+func isLogfmtKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.' || c == '@'
+}
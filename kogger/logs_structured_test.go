@@ -0,0 +1,164 @@
+package kogger
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+)
+
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		values map[string]any
+	}{
+		{
+			name:   "pairs",
+			line:   `level=info msg="user logged in" user=alice`,
+			values: map[string]any{"level": "info", "msg": "user logged in", "user": "alice"},
+		},
+		{
+			name:   "escaped quotes",
+			line:   `level=warn msg="unknown option \"verbose\""`,
+			values: map[string]any{"level": "warn", "msg": `unknown option "verbose"`},
+		},
+		{
+			name:   "escaped backslash",
+			line:   `path="C:\\logs" level=info`,
+			values: map[string]any{"path": `C:\logs`, "level": "info"},
+		},
+		{
+			name:   "empty values",
+			line:   `msg= error=""`,
+			values: map[string]any{"msg": "", "error": ""},
+		},
+		{
+			name:   "dotted and dashed keys",
+			line:   `log.level=error trace-id=abc @timestamp=2026-10-17T12:00:00Z`,
+			values: map[string]any{"log.level": "error", "trace-id": "abc", "@timestamp": "2026-10-17T12:00:00Z"},
+		},
+		{
+			name:   "extra spaces",
+			line:   `  level=debug   msg=ready  `,
+			values: map[string]any{"level": "debug", "msg": "ready"},
+		},
+		{
+			name: "unterminated quotes",
+			line: `level=error msg="connection lost`,
+		},
+		{
+			name: "unterminated quotes after an escape",
+			line: `level=error msg="connection lost\"`,
+		},
+		{
+			name: "single pair",
+			line: `level=info`,
+		},
+		{
+			name: "text after the pairs",
+			line: `level=info msg=started on :8080`,
+		},
+		{
+			name: "plain text",
+			line: `GET /api 200`,
+		},
+		{
+			name: "missing key",
+			line: `level=info ="orphan"`,
+		},
+		{
+			name: "empty",
+			line: ``,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values := parseLogfmt(test.line)
+			if test.values == nil {
+				if values != nil {
+					t.Errorf("expected nil, got %v", values)
+				}
+				return
+			}
+			if fmt.Sprint(values) != fmt.Sprint(test.values) {
+				t.Errorf("expected %v, got %v", test.values, values)
+			}
+		})
+	}
+}
+
+func TestNormalizeLogLevel(t *testing.T) {
+	tests := []struct {
+		value any
+		level LogLevel
+	}{
+		{"info", LogLevel_LOG_LEVEL_INFO},
+		{"WARNING", LogLevel_LOG_LEVEL_WARN},
+		{"Err", LogLevel_LOG_LEVEL_ERROR},
+		{"critical", LogLevel_LOG_LEVEL_FATAL},
+		{"[ERROR]", LogLevel_LOG_LEVEL_ERROR},
+		{"verbose", LogLevel_LOG_LEVEL_UNKNOWN},
+		{"", LogLevel_LOG_LEVEL_UNKNOWN},
+		{10.0, LogLevel_LOG_LEVEL_TRACE},
+		{20.0, LogLevel_LOG_LEVEL_DEBUG},
+		{30.0, LogLevel_LOG_LEVEL_INFO},
+		{40.0, LogLevel_LOG_LEVEL_WARN},
+		{50.0, LogLevel_LOG_LEVEL_ERROR},
+		{60.0, LogLevel_LOG_LEVEL_FATAL},
+		{35.0, LogLevel_LOG_LEVEL_UNKNOWN},
+		{0.0, LogLevel_LOG_LEVEL_UNKNOWN},
+		{true, LogLevel_LOG_LEVEL_UNKNOWN},
+		{nil, LogLevel_LOG_LEVEL_UNKNOWN},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.value), func(t *testing.T) {
+			if level := normalizeLogLevel(test.value); level != test.level {
+				t.Errorf("expected level %s, got %s", test.level, level)
+			}
+		})
+	}
+}
+
+func TestStructuredLogMinLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		// level is the level of the entry, or unset when it is filtered out.
+		level LogLevel
+	}{
+		{"json string level", `{"level":"error","msg":"disk full"}`, LogLevel_LOG_LEVEL_ERROR},
+		{"json numeric level", `{"level":40,"msg":"disk almost full"}`, LogLevel_LOG_LEVEL_WARN},
+		{"json numeric level below", `{"level":30,"msg":"started"}`, LogLevel_LOG_LEVEL_UNKNOWN},
+		{"logfmt level", `lvl=fatal msg="out of memory"`, LogLevel_LOG_LEVEL_FATAL},
+		{"logfmt level below", `level=debug msg="cache miss"`, LogLevel_LOG_LEVEL_UNKNOWN},
+		{"level from the message", `{"msg":"ERROR: disk full"}`, LogLevel_LOG_LEVEL_ERROR},
+		{"unknown level", `{"level":"verbose","msg":"disk full"}`, LogLevel_LOG_LEVEL_UNKNOWN},
+		{"unknown numeric level", `{"level":45,"msg":"disk full"}`, LogLevel_LOG_LEVEL_UNKNOWN},
+		{"no level", `{"msg":"disk full"}`, LogLevel_LOG_LEVEL_UNKNOWN},
+	}
+
+	query, err := newLogQuery(&LogsRequest{MinLevel: LogLevel_LOG_LEVEL_WARN})
+	if err != nil {
+		t.Fatalf("failed to create query: %s", err)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry := query.newParser(logContainer{name: "app"}).build(&logRecord{message: test.message})
+			if test.level == LogLevel_LOG_LEVEL_UNKNOWN {
+				if entry != nil {
+					t.Errorf("expected the entry to be filtered out, got level %s", entry.GetLevel())
+				}
+				return
+			}
+			if entry == nil {
+				t.Fatalf("expected an entry of level %s", test.level)
+			}
+			if entry.GetLevel() != test.level || entry.GetFields() == nil {
+				t.Errorf("expected a structured entry of level %s, got %s", test.level, entry.GetLevel())
+			}
+		})
+	}
+}
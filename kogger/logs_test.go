@@ -0,0 +1,232 @@
+package kogger
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSplitLogLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		timestamp string
+		time      time.Time
+		message   string
+	}{
+		{
+			name:      "nanoseconds",
+			line:      "2026-10-17T12:00:00.123456789Z listening on :8080",
+			timestamp: "2026-10-17T12:00:00.123456789Z",
+			time:      time.Date(2026, 10, 17, 12, 0, 0, 123456789, time.UTC),
+			message:   "listening on :8080",
+		},
+		{
+			name:      "seconds",
+			line:      "2026-10-17T12:00:00Z started",
+			timestamp: "2026-10-17T12:00:00Z",
+			time:      time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+			message:   "started",
+		},
+		{
+			name:      "offset",
+			line:      "2026-10-17T14:00:00.5+02:00 started",
+			timestamp: "2026-10-17T14:00:00.5+02:00",
+			time:      time.Date(2026, 10, 17, 12, 0, 0, 500000000, time.UTC),
+			message:   "started",
+		},
+		{
+			name:      "empty message",
+			line:      "2026-10-17T12:00:00Z ",
+			timestamp: "2026-10-17T12:00:00Z",
+			time:      time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "message starting with spaces",
+			line:      "2026-10-17T12:00:00Z \tat main.main()",
+			timestamp: "2026-10-17T12:00:00Z",
+			time:      time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+			message:   "\tat main.main()",
+		},
+		{
+			name:    "no timestamp",
+			line:    "listening on :8080",
+			message: "listening on :8080",
+		},
+		{
+			name:    "other date format",
+			line:    "2026-10-17 12:00:00 started",
+			message: "2026-10-17 12:00:00 started",
+		},
+		{
+			name:    "invalid timestamp",
+			line:    "2026-13-17T12:00:00Z started",
+			message: "2026-13-17T12:00:00Z started",
+		},
+		{
+			name:    "timestamp alone",
+			line:    "2026-10-17T12:00:00Z",
+			message: "2026-10-17T12:00:00Z",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			record := splitLogLine(test.line)
+			if record.timestamp != test.timestamp || !record.time.Equal(test.time) || record.message != test.message {
+				t.Errorf("expected %q, %s, %q, got %q, %s, %q", test.timestamp, test.time, test.message, record.timestamp, record.time, record.message)
+			}
+		})
+	}
+}
+
+func TestLogParserMissingTimestamp(t *testing.T) {
+	query, err := newLogQuery(&LogsRequest{})
+	if err != nil {
+		t.Fatalf("failed to create query: %s", err)
+	}
+	parser := query.newParser(logContainer{pod: "web-0", name: "app"})
+
+	entries := []*LogEntry{}
+	for _, line := range []string{
+		"2026-10-17T12:00:00Z started\n",
+		"no timestamp\n",
+		"\n",
+		"2026-10-17T12:00:01Z ready\r\n",
+		"2026-10-17 12:00:02 almost a timestamp\n",
+	} {
+		if entry := parser.add(line); entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	expected := []struct {
+		message string
+		missing bool
+	}{
+		{"started", false},
+		{"no timestamp", true},
+		{"ready", false},
+		{"2026-10-17 12:00:02 almost a timestamp", true},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, entry := range entries {
+		if entry.GetMessage() != expected[i].message || entry.GetMissingTimestamp() != expected[i].missing {
+			t.Errorf("expected %q with missing timestamp %t, got %q with %t", expected[i].message, expected[i].missing, entry.GetMessage(), entry.GetMissingTimestamp())
+		}
+		if (entry.GetTime() == nil) != expected[i].missing || (len(entry.GetTimestamp()) == 0) != expected[i].missing {
+			t.Errorf("unexpected time %v and timestamp %q of %q", entry.GetTime(), entry.GetTimestamp(), entry.GetMessage())
+		}
+	}
+	if missing := countMissingTimestamps(entries); missing != 2 {
+		t.Errorf("expected 2 missing timestamps, got %d", missing)
+	}
+}
+
+func TestSortLogEntries(t *testing.T) {
+	entry := func(pod, container, message string, second int) *LogEntry {
+		entry := &LogEntry{Pod: pod, Container: container, Message: message}
+		if second >= 0 {
+			entry.Time = timestamppb.New(testCollectorTime.Add(time.Duration(second) * time.Second))
+		} else {
+			entry.MissingTimestamp = true
+		}
+		return entry
+	}
+
+	tests := []struct {
+		name     string
+		entries  []*LogEntry
+		messages string
+	}{
+		{
+			name: "interleaved containers",
+			entries: []*LogEntry{
+				entry("web-0", "app", "a1", 1), entry("web-0", "app", "a3", 3),
+				entry("web-0", "sidecar", "s0", 0), entry("web-0", "sidecar", "s2", 2),
+			},
+			messages: "[s0 a1 s2 a3]",
+		},
+		{
+			name: "simultaneous entries keep their order",
+			entries: []*LogEntry{
+				entry("web-0", "app", "a1", 1), entry("web-0", "app", "a1'", 1),
+				entry("web-1", "app", "b1", 1), entry("web-1", "app", "b0", 0),
+			},
+			messages: "[b0 a1 a1' b1]",
+		},
+		{
+			name: "missing timestamps follow the previous entry of their stream",
+			entries: []*LogEntry{
+				entry("web-0", "app", "a1", 1), entry("web-0", "app", "a1+", -1), entry("web-0", "app", "a3", 3),
+				entry("web-0", "sidecar", "s2", 2), entry("web-0", "sidecar", "s2+", -1),
+			},
+			messages: "[a1 a1+ s2 s2+ a3]",
+		},
+		{
+			name: "same container of another pod",
+			entries: []*LogEntry{
+				entry("web-0", "app", "a2", 2), entry("web-0", "app", "a2+", -1),
+				entry("web-1", "app", "b1", 1), entry("web-1", "app", "b1+", -1),
+			},
+			messages: "[b1 b1+ a2 a2+]",
+		},
+		{
+			name: "missing timestamps before any time",
+			entries: []*LogEntry{
+				entry("web-0", "app", "a1", 1),
+				entry("web-0", "sidecar", "banner", -1), entry("web-0", "sidecar", "s0", 0),
+			},
+			messages: "[banner s0 a1]",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sortLogEntries(test.entries)
+			messages := []string{}
+			for _, entry := range test.entries {
+				messages = append(messages, entry.GetMessage())
+			}
+			if fmt.Sprint(messages) != test.messages {
+				t.Errorf("expected %s, got %v", test.messages, messages)
+			}
+		})
+	}
+}
+
+func TestGetLogsMissingTimestamps(t *testing.T) {
+	useFakeKubernetes(t, &fakeKubernetes{
+		objects: map[string]any{"/api/v1/namespaces/default/pods/web-0": testPod("web-0", "app", "sidecar")},
+		logs: func(pod string, query url.Values) (int, string) {
+			if query.Get("container") == "app" {
+				return http.StatusOK, "2026-10-17T12:00:01Z app started\nstack line\n2026-10-17T12:00:03Z app ready\n"
+			}
+			return http.StatusOK, "sidecar banner\n2026-10-17T12:00:02Z sidecar started\n"
+		},
+	})
+
+	logs, err := (&server{}).GetLogs(context.Background(), &LogsRequest{Namespace: "default", Pod: "web-0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if logs.GetMissingTimestamps() != 2 {
+		t.Errorf("expected 2 missing timestamps, got %d", logs.GetMissingTimestamps())
+	}
+
+	messages := []string{}
+	for _, entry := range logs.GetEntries() {
+		messages = append(messages, entry.GetMessage())
+	}
+	if fmt.Sprint(messages) != "[sidecar banner app started stack line sidecar started app ready]" {
+		t.Errorf("unexpected order %v", messages)
+	}
+}
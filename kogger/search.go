@@ -0,0 +1,213 @@
+package kogger
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// defaultSearchLimit and maxSearchLimit bound the hits of a search.
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+	// snippetLength is the length in bytes of the snippets around the hits.
+	snippetLength = 200
+	// bm25K1 and bm25B are the usual BM25 term saturation and length
+	// normalisation parameters.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// token is a word of a message along with its position in the message.
+type token struct {
+	text       string
+	start, end int
+}
+
+// tokenize splits text into lower case words made of letters, digits and
+// underscores. Other characters separate the words, so a request ID such as
+// 3f2a-77b1 gives two tokens.
+func tokenize(text string) []token {
+	tokens := []token{}
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+		if isWord && start < 0 {
+			start = i
+		} else if !isWord && start >= 0 {
+			tokens = append(tokens, token{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return tokens
+}
+
+func tokenTexts(text string) []string {
+	texts := []string{}
+	for _, t := range tokenize(text) {
+		texts = append(texts, t.text)
+	}
+	return texts
+}
+
+// entryTokens returns the tokens of the searchable texts of an entry.
+func entryTokens(entry *LogEntry) []string {
+	tokens := []string{}
+	for _, text := range entryTexts(entry) {
+		tokens = append(tokens, tokenTexts(text)...)
+	}
+	return tokens
+}
+
+// entryTexts returns the searchable texts of an entry: its message, then the
+// string values of its structured fields, in the order of their keys. The
+// field the message was taken from is not repeated.
+func entryTexts(entry *LogEntry) []string {
+	texts := []string{entry.GetMessage()}
+	var walk func(value *structpb.Value)
+	walk = func(value *structpb.Value) {
+		switch kind := value.GetKind().(type) {
+		case *structpb.Value_StringValue:
+			if kind.StringValue != entry.GetMessage() {
+				texts = append(texts, kind.StringValue)
+			}
+		case *structpb.Value_ListValue:
+			for _, item := range kind.ListValue.GetValues() {
+				walk(item)
+			}
+		case *structpb.Value_StructValue:
+			fields := kind.StructValue.GetFields()
+			for _, key := range slices.Sorted(maps.Keys(fields)) {
+				walk(fields[key])
+			}
+		}
+	}
+	walk(structpb.NewStructValue(entry.GetFields()))
+	return texts
+}
+
+// parseSearchQuery splits a query into phrases, each being a list of tokens
+// which must appear next to each other. Quoted text is a phrase, and so is
+// every other word, so that searching for 3f2a-77b1 matches the whole ID.
+func parseSearchQuery(query string) ([][]string, error) {
+	phrases := [][]string{}
+	for i, part := range strings.Split(query, `"`) {
+		words := []string{part}
+		if i%2 == 0 {
+			words = strings.Fields(part)
+		}
+		for _, word := range words {
+			if phrase := tokenTexts(word); len(phrase) > 0 {
+				phrases = append(phrases, phrase)
+			}
+		}
+	}
+	if len(phrases) == 0 {
+		return nil, fmt.Errorf("empty search query")
+	}
+	return phrases, nil
+}
+
+// containsPhrase tells whether the phrase appears in the tokens.
+func containsPhrase(tokens []string, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		match := true
+		for j := range phrase {
+			if tokens[i+j] != phrase[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// bm25 scores the tokens of an entry against the query tokens. documents and
+// averageLength describe the collection, frequencies gives the number of
+// documents holding each query token.
+func bm25(tokens []string, queryTokens []string, documents int, averageLength float64, frequencies map[string]int) float64 {
+	counts := map[string]int{}
+	for _, t := range tokens {
+		counts[t]++
+	}
+
+	score := 0.0
+	for _, t := range queryTokens {
+		tf := float64(counts[t])
+		if tf == 0 {
+			continue
+		}
+		df := float64(frequencies[t])
+		idf := math.Log(1 + (float64(documents)-df+0.5)/(df+0.5))
+		norm := 1 - bm25B
+		if averageLength > 0 {
+			norm += bm25B * float64(len(tokens)) / averageLength
+		}
+		score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+	}
+	return score
+}
+
+// highlightSnippet returns the part of the message around the first query
+// token, along with the byte ranges of the query tokens in the snippet.
+func highlightSnippet(message string, queryTokens map[string]bool) (string, []*TextRange) {
+	tokens := tokenize(message)
+
+	start, end := 0, len(message)
+	if len(message) > snippetLength {
+		first := 0
+		for _, t := range tokens {
+			if queryTokens[t.text] {
+				first = t.start
+				break
+			}
+		}
+		start = max(0, first-snippetLength/4)
+		end = min(len(message), start+snippetLength)
+		// Cut on spaces when possible, and always on rune boundaries.
+		if space := strings.IndexByte(message[start:first], ' '); start > 0 && space >= 0 {
+			start += space + 1
+		}
+		if space := strings.LastIndexByte(message[first:end], ' '); end < len(message) && space > 0 {
+			end = first + space
+		}
+		for start > 0 && !utf8.RuneStart(message[start]) {
+			start++
+		}
+		for end < len(message) && !utf8.RuneStart(message[end]) {
+			end--
+		}
+	}
+
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "…"
+	}
+	if end < len(message) {
+		suffix = "…"
+	}
+
+	highlights := []*TextRange{}
+	for _, t := range tokens {
+		if queryTokens[t.text] && t.start >= start && t.end <= end {
+			highlights = append(highlights, &TextRange{
+				Start: int32(len(prefix) + t.start - start),
+				End:   int32(len(prefix) + t.end - start),
+			})
+		}
+	}
+	return prefix + message[start:end] + suffix, highlights
+}
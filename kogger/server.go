@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	. "github.com/k-ogger/kogger-service/koggerservicerpc"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
@@ -62,6 +63,29 @@ func Run() {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
+	store, err := newLogStore()
+	if err != nil {
+		log.Fatalf("failed to configure log storage: %v", err)
+	}
+	logStore = store
+
+	compactorCtx, stopCompactor := context.WithCancel(context.Background())
+	defer stopCompactor()
+	if store != nil {
+		policy, err := newRetentionPolicy()
+		if err != nil {
+			log.Fatalf("failed to configure log retention: %v", err)
+		}
+		interval, err := parseDurationEnv("KOGGER_STORAGE_COMPACTION_INTERVAL")
+		if err != nil {
+			log.Fatalf("failed to configure log retention: %v", err)
+		}
+		if interval == 0 {
+			interval = 10 * time.Minute
+		}
+		go runCompactor(compactorCtx, store, policy, interval)
+	}
+
 	provider := noop.NewTracerProvider()
 	s := grpc.NewServer(grpc.MaxRecvMsgSize(16*1024*1024), grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithTracerProvider(provider))))
 	RegisterKoggerServiceServer(s, &server{})
@@ -74,7 +98,7 @@ func Run() {
 		log.Fatal(http.ListenAndServe(":8081", nil))
 	}()
 
-	termChan := make(chan os.Signal)
+	termChan := make(chan os.Signal, 1)
 	signal.Notify(termChan, syscall.SIGTERM) // Received after the preStop hook
 
 	go func() {
@@ -87,6 +111,7 @@ func Run() {
 	select {
 	case c := <-termChan:
 		log.Printf("Received signal %v, stopping gracefully", c)
+		stopCompactor()
 		s.GracefulStop()
 		log.Printf("Server stopped, exiting. ")
 	}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"strings"
+	"sync"
 
 	grpctoken "github.com/ZolaraProject/library/grpctoken"
 	logger "github.com/ZolaraProject/library/logger"
 	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/structpb"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -518,6 +520,14 @@ func (*server) GetResource(ctx context.Context, req *ResourceRequest) (*Resource
 		}
 
 		resourceInfo = analyseService(resource)
+	case ResourceType_RESOURCE_TYPE_STATEFULSET:
+		resource, err := Clientset.AppsV1().StatefulSets(req.GetNamespace()).Get(ctx, req.GetName(), metav1.GetOptions{})
+		if err != nil {
+			logger.Err(grpcToken, "Failed to get statefulset %s in namespace %s: %s", req.GetName(), req.GetNamespace(), err)
+			return nil, err
+		}
+
+		resourceInfo = analyseStatefulSet(resource)
 	default:
 		logger.Err(grpcToken, "Unsupported resource type: %s", resourceType)
 		return nil, fmt.Errorf("unsupported resource type: %s", resourceType)
@@ -529,9 +539,46 @@ func (*server) GetResource(ctx context.Context, req *ResourceRequest) (*Resource
 func (*server) GetLogs(ctx context.Context, req *LogsRequest) (*Logs, error) {
 	grpcToken := grpctoken.GetToken(ctx)
 
-	if len(req.GetNamespace()) == 0 || len(req.GetPod()) == 0 {
-		logger.Err(grpcToken, "Namespace or pod not specified")
-		return nil, fmt.Errorf("namespace or pod not specified")
+	hasSelector := len(req.GetLabelSelector()) > 0 || len(req.GetFieldSelector()) > 0
+	if len(req.GetNamespace()) == 0 || (len(req.GetPod()) == 0 && !hasSelector) {
+		logger.Err(grpcToken, "Namespace, pod or selector not specified")
+		return nil, fmt.Errorf("namespace, pod or selector not specified")
+	}
+	if len(req.GetPod()) > 0 && hasSelector {
+		logger.Err(grpcToken, "Both pod and selector specified")
+		return nil, fmt.Errorf("pod and selector are mutually exclusive")
+	}
+
+	query, err := newLogQuery(req)
+	if err != nil {
+		logger.Err(grpcToken, "Invalid log options: %s", err)
+		return nil, err
+	}
+
+	paginated := req.GetPageSize() > 0 || len(req.GetCursor()) > 0
+	if paginated && hasSelector {
+		logger.Err(grpcToken, "Pagination requested for a selector query")
+		return nil, fmt.Errorf("pagination is only supported for a single pod")
+	}
+
+	if hasSelector {
+		logger.Debug(grpcToken, "Fetching logs for pods matching labels %q and fields %q in namespace %s", req.GetLabelSelector(), req.GetFieldSelector(), req.GetNamespace())
+
+		pods, err := selectorPods(ctx, req)
+		if err != nil {
+			logger.Err(grpcToken, "Failed to list pods matching selector in namespace %s: %s", req.GetNamespace(), err)
+			return nil, err
+		}
+
+		logs := collectPodsLogs(ctx, grpcToken, pods, query)
+		sortLogEntries(logs)
+
+		logger.Debug(grpcToken, "Returning %d log entries from %d pods in namespace %s", len(logs), len(pods), req.GetNamespace())
+		return &Logs{
+			Namespace:         req.GetNamespace(),
+			Entries:           logs,
+			MissingTimestamps: countMissingTimestamps(logs),
+		}, nil
 	}
 
 	logger.Debug(grpcToken, "Fetching logs for pod %s in namespace %s", req.GetPod(), req.GetNamespace())
@@ -542,59 +589,236 @@ func (*server) GetLogs(ctx context.Context, req *LogsRequest) (*Logs, error) {
 		return nil, err
 	}
 
-	logs := []*LogEntry{}
-
-	for _, container := range pod.Spec.Containers {
-		logReq := Clientset.CoreV1().Pods(req.GetNamespace()).GetLogs(pod.Name, &v1.PodLogOptions{
-			Container:  container.Name,
-			Timestamps: true,
-		})
-		podLogs, err := logReq.Stream(ctx)
+	if paginated {
+		logs, err := getPodLogsPage(ctx, grpcToken, pod, query)
 		if err != nil {
-			logger.Err(grpcToken, "Failed to get logs for pod %s in namespace %s, container %s: %s", req.GetPod(), req.GetNamespace(), container.Name, err)
-			continue
+			return nil, err
 		}
+		logs.PodLabels = pod.Labels
+		return logs, nil
+	}
+
+	logs, err := getPodLogs(ctx, grpcToken, pod, query)
+	if err != nil {
+		return nil, err
+	}
+	sortLogEntries(logs)
+
+	return &Logs{
+		Pod:               req.GetPod(),
+		Namespace:         req.GetNamespace(),
+		Entries:           logs,
+		MissingTimestamps: countMissingTimestamps(logs),
+		PodLabels:         pod.Labels,
+	}, nil
+}
+
+func (*server) GetWorkloadLogs(ctx context.Context, req *ResourceRequest) (*Logs, error) {
+	grpcToken := grpctoken.GetToken(ctx)
 
-		buf := new(strings.Builder)
-		if _, err := io.Copy(buf, podLogs); err != nil {
-			logger.Err(grpcToken, "Failed to read logs for pod %s in namespace %s, container %s: %s", req.GetPod(), req.GetNamespace(), container.Name, err)
-			podLogs.Close()
+	if len(req.GetNamespace()) == 0 || len(req.GetName()) == 0 || req.GetResourceType() == 0 {
+		logger.Err(grpcToken, "Namespace, name or resource type not specified")
+		return nil, fmt.Errorf("namespace, name or resource type not specified")
+	}
+
+	logger.Debug(grpcToken, "Fetching logs for %s %s in namespace %s", ResourceTypeToString(req.GetResourceType()), req.GetName(), req.GetNamespace())
+
+	pods, err := workloadPods(ctx, grpcToken, req.GetNamespace(), req.GetResourceType(), req.GetName())
+	if err != nil {
+		logger.Err(grpcToken, "Failed to find pods of %s %s in namespace %s: %s", ResourceTypeToString(req.GetResourceType()), req.GetName(), req.GetNamespace(), err)
+		return nil, err
+	}
+
+	if len(pods) > maxSelectorPods {
+		logger.Err(grpcToken, "%s %s in namespace %s has %d pods, more than %d", ResourceTypeToString(req.GetResourceType()), req.GetName(), req.GetNamespace(), len(pods), maxSelectorPods)
+		return nil, status.Errorf(codes.FailedPrecondition, "%s %s has more than %d pods, fetch the logs of its pods instead", ResourceTypeToString(req.GetResourceType()), req.GetName(), maxSelectorPods)
+	}
+
+	// Without any bound, only the last lines of every pod are fetched.
+	tailLines := req.GetTailLines()
+	if tailLines == 0 && req.GetSinceSeconds() == 0 && req.GetSinceTime() == nil {
+		tailLines = defaultWorkloadTailLines
+	}
+	query, err := newLogQuery(&LogsRequest{
+		Namespace:    req.GetNamespace(),
+		TailLines:    tailLines,
+		SinceSeconds: req.GetSinceSeconds(),
+		SinceTime:    req.GetSinceTime(),
+	})
+	if err != nil {
+		logger.Err(grpcToken, "Invalid log options: %s", err)
+		return nil, err
+	}
+
+	logs := collectPodsLogs(ctx, grpcToken, pods, query)
+	sortLogEntries(logs)
+
+	logger.Debug(grpcToken, "Returning %d log entries from %d pods of %s %s in namespace %s", len(logs), len(pods), ResourceTypeToString(req.GetResourceType()), req.GetName(), req.GetNamespace())
+	return &Logs{
+		Namespace:         req.GetNamespace(),
+		Entries:           logs,
+		MissingTimestamps: countMissingTimestamps(logs),
+	}, nil
+}
+
+func (*server) FollowLogs(req *LogsRequest, stream KoggerService_FollowLogsServer) error {
+	ctx := stream.Context()
+	grpcToken := grpctoken.GetToken(ctx)
+
+	if len(req.GetNamespace()) == 0 || len(req.GetPod()) == 0 {
+		logger.Err(grpcToken, "Namespace or pod not specified")
+		return fmt.Errorf("namespace or pod not specified")
+	}
+
+	query, err := newLogQuery(req)
+	if err != nil {
+		logger.Err(grpcToken, "Invalid log options: %s", err)
+		return err
+	}
+
+	logger.Debug(grpcToken, "Following logs for pod %s in namespace %s", req.GetPod(), req.GetNamespace())
+
+	pod, err := Clientset.CoreV1().Pods(req.GetNamespace()).Get(ctx, req.GetPod(), metav1.GetOptions{})
+	if err != nil {
+		logger.Err(grpcToken, "Failed to get pod %s in namespace %s: %s", req.GetPod(), req.GetNamespace(), err)
+		return err
+	}
+
+	containers, err := logContainers(pod, req)
+	if err != nil {
+		logger.Err(grpcToken, "Failed to select containers for pod %s in namespace %s: %s", req.GetPod(), req.GetNamespace(), err)
+		return err
+	}
+
+	entries := make(chan *LogEntry)
+	var wg sync.WaitGroup
+
+	opened := 0
+	for _, container := range containers {
+		logReq := Clientset.CoreV1().Pods(req.GetNamespace()).GetLogs(pod.Name, podLogOptions(req, container.name, true))
+		podLogs, err := logReq.Stream(ctx)
+		if err != nil {
+			logger.Err(grpcToken, "Failed to follow logs for pod %s in namespace %s, container %s: %s", req.GetPod(), req.GetNamespace(), container.name, err)
 			continue
 		}
-		if err := podLogs.Close(); err != nil {
-			logger.Err(grpcToken, "Failed to close log stream for pod %s in namespace %s, container %s: %s", req.GetPod(), req.GetNamespace(), container.Name, err)
-		}
+		opened++
 
-		logLines := strings.Split(strings.TrimSpace(buf.String()), "\n")
-		for _, line := range logLines {
-			if strings.TrimSpace(line) == "" {
-				continue
-			}
+		wg.Add(1)
+		go func(container logContainer, podLogs io.ReadCloser) {
+			defer wg.Done()
+			defer podLogs.Close()
 
-			timestamp := ""
-			message := line
+			send := func(entry *LogEntry) bool {
+				if entry == nil {
+					return true
+				}
 
-			if len(line) > 30 && line[4] == '-' && line[7] == '-' && line[10] == 'T' {
-				parts := strings.SplitN(line, " ", 2)
-				if len(parts) == 2 {
-					timestamp = parts[0]
-					message = parts[1]
+				select {
+				case entries <- entry:
+					return true
+				case <-ctx.Done():
+					return false
 				}
 			}
 
-			logs = append(logs, &LogEntry{
-				Container: container.Name,
-				Timestamp: timestamp,
-				Message:   message,
-			})
+			parser := query.newParser(container)
+			err := followLogLines(podLogs, parser, send)
+			if err != nil && ctx.Err() == nil {
+				logger.Err(grpcToken, "Failed to read logs for pod %s in namespace %s, container %s: %s", req.GetPod(), req.GetNamespace(), container.name, err)
+			}
+			send(parser.flush())
+		}(container, podLogs)
+	}
+	if opened == 0 {
+		logger.Err(grpcToken, "No log stream could be opened for pod %s in namespace %s", req.GetPod(), req.GetNamespace())
+		return status.Errorf(codes.NotFound, "no log stream could be opened for pod %s", req.GetPod())
+	}
+
+	go func() {
+		wg.Wait()
+		close(entries)
+	}()
+
+	for entry := range entries {
+		if err := stream.Send(entry); err != nil {
+			logger.Err(grpcToken, "Failed to send log entry for pod %s in namespace %s: %s", req.GetPod(), req.GetNamespace(), err)
+			return err
 		}
 	}
 
-	return &Logs{
-		Pod:       req.GetPod(),
-		Namespace: req.GetNamespace(),
-		Entries:   logs,
-	}, nil
+	if ctx.Err() != nil {
+		logger.Debug(grpcToken, "Client stopped following logs for pod %s in namespace %s", req.GetPod(), req.GetNamespace())
+	} else {
+		logger.Debug(grpcToken, "All log streams ended for pod %s in namespace %s", req.GetPod(), req.GetNamespace())
+	}
+	return nil
+}
+
+func (*server) DownloadLogs(req *DownloadLogsRequest, stream KoggerService_DownloadLogsServer) error {
+	ctx := stream.Context()
+	grpcToken := grpctoken.GetToken(ctx)
+
+	if len(req.GetNamespace()) == 0 {
+		logger.Err(grpcToken, "Namespace not specified")
+		return fmt.Errorf("namespace not specified")
+	}
+
+	var pods []v1.Pod
+	switch {
+	case len(req.GetPod()) > 0:
+		logger.Debug(grpcToken, "Downloading logs for pod %s in namespace %s", req.GetPod(), req.GetNamespace())
+		pod, err := Clientset.CoreV1().Pods(req.GetNamespace()).Get(ctx, req.GetPod(), metav1.GetOptions{})
+		if err != nil {
+			logger.Err(grpcToken, "Failed to get pod %s in namespace %s: %s", req.GetPod(), req.GetNamespace(), err)
+			return err
+		}
+		pods = []v1.Pod{*pod}
+	case len(req.GetName()) > 0 && req.GetResourceType() != ResourceType_RESOURCE_TYPE_UNKNOWN:
+		logger.Debug(grpcToken, "Downloading logs for %s %s in namespace %s", ResourceTypeToString(req.GetResourceType()), req.GetName(), req.GetNamespace())
+		var err error
+		pods, err = workloadPods(ctx, grpcToken, req.GetNamespace(), req.GetResourceType(), req.GetName())
+		if err != nil {
+			logger.Err(grpcToken, "Failed to find pods of %s %s in namespace %s: %s", ResourceTypeToString(req.GetResourceType()), req.GetName(), req.GetNamespace(), err)
+			return err
+		}
+	default:
+		logger.Debug(grpcToken, "Downloading logs for namespace %s", req.GetNamespace())
+		podList, err := Clientset.CoreV1().Pods(req.GetNamespace()).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			logger.Err(grpcToken, "Failed to list pods in namespace %s: %s", req.GetNamespace(), err)
+			return err
+		}
+		pods = podList.Items
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeLogsArchive(ctx, grpcToken, writer, pods, req.GetCompression()))
+	}()
+	defer reader.Close()
+
+	sent := 0
+	buf := make([]byte, downloadChunkSize)
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if n > 0 {
+			if err := stream.Send(&LogChunk{Data: buf[:n]}); err != nil {
+				logger.Err(grpcToken, "Failed to send log archive chunk for namespace %s: %s", req.GetNamespace(), err)
+				return err
+			}
+			sent += n
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			logger.Err(grpcToken, "Failed to build log archive for namespace %s: %s", req.GetNamespace(), err)
+			return err
+		}
+	}
+
+	logger.Debug(grpcToken, "Sent log archive of %d bytes for %d pods in namespace %s", sent, len(pods), req.GetNamespace())
+	return nil
 }
 
 func analyseDeployment(deployment *appsv1.Deployment) *Resource {
@@ -674,3 +898,278 @@ func analyseService(service *v1.Service) *Resource {
 		Fields:    serviceFields,
 	}
 }
+
+func analyseStatefulSet(statefulSet *appsv1.StatefulSet) *Resource {
+	statefulSetFields := &AdjustableFields{
+		Fields: make(map[string]*structpb.Value),
+	}
+
+	containerList := []*structpb.Value{}
+	imageList := []*structpb.Value{}
+	for _, container := range statefulSet.Spec.Template.Spec.Containers {
+		containerList = append(containerList, structpb.NewStringValue(container.Name))
+		imageList = append(imageList, structpb.NewStringValue(container.Image))
+	}
+
+	labelsList := make(map[string]*structpb.Value)
+	for key, value := range statefulSet.Spec.Template.ObjectMeta.Labels {
+		labelsList[key] = structpb.NewStringValue(value)
+	}
+
+	claimList := []*structpb.Value{}
+	for _, claim := range statefulSet.Spec.VolumeClaimTemplates {
+		accessModes := []*structpb.Value{}
+		for _, mode := range claim.Spec.AccessModes {
+			accessModes = append(accessModes, structpb.NewStringValue(string(mode)))
+		}
+
+		storageClass := ""
+		if claim.Spec.StorageClassName != nil {
+			storageClass = *claim.Spec.StorageClassName
+		}
+		size := ""
+		if request, ok := claim.Spec.Resources.Requests[v1.ResourceStorage]; ok {
+			size = request.String()
+		}
+
+		claimList = append(claimList, structpb.NewStructValue(&structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				"Name":         structpb.NewStringValue(claim.Name),
+				"StorageClass": structpb.NewStringValue(storageClass),
+				"Size":         structpb.NewStringValue(size),
+				"AccessModes":  structpb.NewListValue(&structpb.ListValue{Values: accessModes}),
+			},
+		}))
+	}
+
+	updateStrategy := map[string]*structpb.Value{
+		"Type": structpb.NewStringValue(string(statefulSet.Spec.UpdateStrategy.Type)),
+	}
+	if rollingUpdate := statefulSet.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil {
+		if rollingUpdate.Partition != nil {
+			updateStrategy["Partition"] = structpb.NewStringValue(fmt.Sprintf("%d", *rollingUpdate.Partition))
+		}
+		if rollingUpdate.MaxUnavailable != nil {
+			updateStrategy["MaxUnavailable"] = structpb.NewStringValue(rollingUpdate.MaxUnavailable.String())
+		}
+	}
+
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+
+	statefulSetFields.Fields["Containers"] = structpb.NewListValue(&structpb.ListValue{Values: containerList})
+	statefulSetFields.Fields["Images"] = structpb.NewListValue(&structpb.ListValue{Values: imageList})
+	statefulSetFields.Fields["Replicas"] = structpb.NewStringValue(fmt.Sprintf("%d", replicas))
+	statefulSetFields.Fields["ReadyReplicas"] = structpb.NewStringValue(fmt.Sprintf("%d", statefulSet.Status.ReadyReplicas))
+	statefulSetFields.Fields["CurrentReplicas"] = structpb.NewStringValue(fmt.Sprintf("%d", statefulSet.Status.CurrentReplicas))
+	statefulSetFields.Fields["UpdatedReplicas"] = structpb.NewStringValue(fmt.Sprintf("%d", statefulSet.Status.UpdatedReplicas))
+	statefulSetFields.Fields["ServiceName"] = structpb.NewStringValue(statefulSet.Spec.ServiceName)
+	statefulSetFields.Fields["VolumeClaimTemplates"] = structpb.NewListValue(&structpb.ListValue{Values: claimList})
+	statefulSetFields.Fields["UpdateStrategy"] = structpb.NewStructValue(&structpb.Struct{
+		Fields: updateStrategy,
+	})
+	statefulSetFields.Fields["CurrentRevision"] = structpb.NewStringValue(statefulSet.Status.CurrentRevision)
+	statefulSetFields.Fields["UpdateRevision"] = structpb.NewStringValue(statefulSet.Status.UpdateRevision)
+	statefulSetFields.Fields["Selector"] = structpb.NewStructValue(&structpb.Struct{
+		Fields: labelsList,
+	})
+
+	// StatefulSets report no conditions, the status is derived from the
+	// rollout and the ready replicas. A partitioned rolling update only
+	// updates the pods from the partition ordinal on, and is done once they
+	// are, though the current revision stays behind.
+	updating := replicas
+	if rollingUpdate := statefulSet.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil {
+		updating = max(replicas-*rollingUpdate.Partition, 0)
+	}
+	status := "Ready"
+	switch {
+	case statefulSet.Status.ObservedGeneration < statefulSet.Generation:
+		status = "Progressing"
+	case len(statefulSet.Status.UpdateRevision) > 0 && statefulSet.Status.CurrentRevision != statefulSet.Status.UpdateRevision && statefulSet.Status.UpdatedReplicas < updating:
+		status = "Updating"
+	case statefulSet.Status.ReadyReplicas < replicas:
+		status = "NotReady"
+	}
+
+	return &Resource{
+		Namespace: statefulSet.Namespace,
+		Name:      statefulSet.Name,
+		Status:    status,
+		Fields:    statefulSetFields,
+	}
+}
+
+// StoreLogs appends logs to the store. It is meant to be called by the store
+// sink of the collector only: the service does not authenticate its callers,
+// so its port should only be reachable from the collector, for instance
+// through a NetworkPolicy. Every entry must belong to a pod and have a time,
+// unless flagged with a missing timestamp, in which case it is stored at the
+// time of the call. A request with an invalid entry is rejected as a whole.
+func (*server) StoreLogs(ctx context.Context, req *Logs) (*Void, error) {
+	grpcToken := grpctoken.GetToken(ctx)
+
+	if logStore == nil {
+		logger.Err(grpcToken, "Log storage is not enabled")
+		return nil, fmt.Errorf("log storage is not enabled")
+	}
+	if len(req.GetNamespace()) == 0 {
+		logger.Err(grpcToken, "Namespace not specified")
+		return nil, fmt.Errorf("namespace not specified")
+	}
+	for i, entry := range req.GetEntries() {
+		if len(entry.GetPod()) == 0 && len(req.GetPod()) == 0 {
+			logger.Err(grpcToken, "Entry %d of namespace %s has no pod", i, req.GetNamespace())
+			return nil, fmt.Errorf("entry %d has no pod", i)
+		}
+		if len(entry.GetNamespace()) > 0 && entry.GetNamespace() != req.GetNamespace() {
+			logger.Err(grpcToken, "Entry %d of namespace %s belongs to namespace %s", i, req.GetNamespace(), entry.GetNamespace())
+			return nil, fmt.Errorf("entry %d belongs to namespace %s, not %s", i, entry.GetNamespace(), req.GetNamespace())
+		}
+		if entry.GetTime() == nil && !entry.GetMissingTimestamp() {
+			logger.Err(grpcToken, "Entry %d of namespace %s has no time", i, req.GetNamespace())
+			return nil, fmt.Errorf("entry %d has no time", i)
+		}
+		if entry.GetTime() != nil {
+			if err := entry.GetTime().CheckValid(); err != nil {
+				logger.Err(grpcToken, "Entry %d of namespace %s has an invalid time: %s", i, req.GetNamespace(), err)
+				return nil, fmt.Errorf("entry %d has an invalid time: %s", i, err)
+			}
+		}
+	}
+
+	if err := logStore.Append(ctx, req); err != nil {
+		logger.Err(grpcToken, "Failed to store logs of namespace %s: %s", req.GetNamespace(), err)
+		return nil, err
+	}
+
+	logger.Debug(grpcToken, "Stored %d log entries in namespace %s", len(req.GetEntries()), req.GetNamespace())
+	return &Void{}, nil
+}
+
+func (*server) QueryStoredLogs(ctx context.Context, req *QueryStoredLogsRequest) (*Logs, error) {
+	grpcToken := grpctoken.GetToken(ctx)
+
+	if logStore == nil {
+		logger.Err(grpcToken, "Log storage is not enabled")
+		return nil, fmt.Errorf("log storage is not enabled")
+	}
+	if len(req.GetNamespace()) == 0 && len(req.GetPod()) > 0 {
+		logger.Err(grpcToken, "Pod specified without namespace")
+		return nil, fmt.Errorf("namespace not specified")
+	}
+	if req.GetLimit() < 0 || req.GetLimit() > maxLogPageSize {
+		logger.Err(grpcToken, "Invalid limit %d", req.GetLimit())
+		return nil, fmt.Errorf("limit must be between 0 and %d", maxLogPageSize)
+	}
+
+	query := &LogStoreQuery{
+		Namespace: req.GetNamespace(),
+		Pod:       req.GetPod(),
+		Container: req.GetContainer(),
+		Limit:     int(req.GetLimit()),
+	}
+	if query.Limit == 0 {
+		query.Limit = maxLogPageSize
+	}
+	if req.GetSince() != nil {
+		query.Since = req.GetSince().AsTime()
+	}
+	if req.GetUntil() != nil {
+		query.Until = req.GetUntil().AsTime()
+	}
+	if !query.Since.IsZero() && !query.Until.IsZero() && query.Until.Before(query.Since) {
+		logger.Err(grpcToken, "Until %s is before since %s", query.Until, query.Since)
+		return nil, fmt.Errorf("until must not be before since")
+	}
+
+	logger.Debug(grpcToken, "Querying stored logs for pod %q in namespace %q", req.GetPod(), req.GetNamespace())
+
+	entries, err := logStore.Query(ctx, query)
+	if err != nil {
+		logger.Err(grpcToken, "Failed to query stored logs: %s", err)
+		return nil, err
+	}
+
+	logger.Debug(grpcToken, "Returning %d stored log entries", len(entries))
+	return &Logs{
+		Pod:               req.GetPod(),
+		Namespace:         req.GetNamespace(),
+		Entries:           entries,
+		MissingTimestamps: countMissingTimestamps(entries),
+	}, nil
+}
+
+func (*server) SearchLogs(ctx context.Context, req *SearchLogsRequest) (*SearchLogsResponse, error) {
+	grpcToken := grpctoken.GetToken(ctx)
+
+	if logStore == nil {
+		logger.Err(grpcToken, "Log storage is not enabled")
+		return nil, fmt.Errorf("log storage is not enabled")
+	}
+	if req.GetLimit() < 0 || req.GetLimit() > maxSearchLimit {
+		logger.Err(grpcToken, "Invalid limit %d", req.GetLimit())
+		return nil, fmt.Errorf("limit must be between 0 and %d", maxSearchLimit)
+	}
+
+	phrases, err := parseSearchQuery(req.GetQuery())
+	if err != nil {
+		logger.Err(grpcToken, "Invalid search query %q: %s", req.GetQuery(), err)
+		return nil, err
+	}
+
+	query := &LogSearchQuery{
+		Phrases:    phrases,
+		Namespaces: req.GetNamespaces(),
+		Limit:      int(req.GetLimit()),
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultSearchLimit
+	}
+	if req.GetSince() != nil {
+		query.Since = req.GetSince().AsTime()
+	}
+	if req.GetUntil() != nil {
+		query.Until = req.GetUntil().AsTime()
+	}
+	if !query.Since.IsZero() && !query.Until.IsZero() && query.Until.Before(query.Since) {
+		logger.Err(grpcToken, "Until %s is before since %s", query.Until, query.Since)
+		return nil, fmt.Errorf("until must not be before since")
+	}
+
+	logger.Debug(grpcToken, "Searching stored logs for %q in namespaces %v", req.GetQuery(), req.GetNamespaces())
+
+	result, err := logStore.Search(ctx, query)
+	if err != nil {
+		logger.Err(grpcToken, "Failed to search stored logs: %s", err)
+		return nil, err
+	}
+
+	queryTokens := map[string]bool{}
+	for _, phrase := range phrases {
+		for _, t := range phrase {
+			queryTokens[t] = true
+		}
+	}
+
+	response := &SearchLogsResponse{
+		Hits:            []*SearchHit{},
+		ScannedSegments: int64(result.ScannedSegments),
+		SkippedSegments: int64(result.SkippedSegments),
+	}
+	for _, hit := range result.Hits {
+		snippet, highlights := highlightSnippet(hit.Entry.GetMessage(), queryTokens)
+		response.Hits = append(response.Hits, &SearchHit{
+			Namespace:  hit.Namespace,
+			Entry:      hit.Entry,
+			Score:      hit.Score,
+			Snippet:    snippet,
+			Highlights: highlights,
+		})
+	}
+
+	logger.Debug(grpcToken, "Returning %d search hits, %d segments scanned and %d skipped", len(response.Hits), response.ScannedSegments, response.SkippedSegments)
+	return response, nil
+}
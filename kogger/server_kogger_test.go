@@ -0,0 +1,377 @@
+package kogger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// fakeKubernetes is a stand-in for the Kubernetes API, serving objects as
+// JSON by path and the container logs through logs.
+type fakeKubernetes struct {
+	// objects are keyed by path, e.g. /api/v1/namespaces/default/pods/web-0.
+	objects map[string]any
+	// logs returns the status and the body of a log request of the pod.
+	logs func(pod string, query url.Values) (int, string)
+	// transport wraps the transport of the clientset when set.
+	transport func(rt http.RoundTripper) http.RoundTripper
+}
+
+func (k *fakeKubernetes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if pod, ok := strings.CutSuffix(r.URL.Path, "/log"); ok && k.logs != nil {
+		code, body := k.logs(path.Base(pod), r.URL.Query())
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(code)
+		w.Write([]byte(body))
+		return
+	}
+
+	object, ok := k.objects[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&metav1.Status{
+			TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+			Status:   metav1.StatusFailure,
+			Reason:   metav1.StatusReasonNotFound,
+			Code:     http.StatusNotFound,
+			Message:  fmt.Sprintf("%s not found", r.URL.Path),
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(object)
+}
+
+// useFakeKubernetes points Clientset to the stand-in until the test ends.
+func useFakeKubernetes(t *testing.T, k *fakeKubernetes) {
+	t.Helper()
+	srv := httptest.NewServer(k)
+	t.Cleanup(srv.Close)
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: srv.URL, QPS: -1, WrapTransport: k.transport})
+	if err != nil {
+		t.Fatalf("failed to create clientset: %s", err)
+	}
+	previous := Clientset
+	Clientset = clientset
+	t.Cleanup(func() { Clientset = previous })
+}
+
+// testPod returns a running pod of the default namespace with the containers.
+func testPod(name string, containers ...string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	for _, container := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: container})
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, v1.ContainerStatus{Name: container, ContainerID: "containerd://" + container})
+	}
+	return pod
+}
+
+// fakeFollowStream records the entries sent by FollowLogs.
+type fakeFollowStream struct {
+	grpc.ServerStream
+
+	ctx     context.Context
+	entries []*LogEntry
+}
+
+func (s *fakeFollowStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeFollowStream) Send(entry *LogEntry) error {
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func TestFollowLogsStreams(t *testing.T) {
+	tests := []struct {
+		name     string
+		failing  []string
+		messages []string
+		code     codes.Code
+	}{
+		{
+			name:     "every stream",
+			messages: []string{"app started", "sidecar started"},
+		},
+		{
+			name:     "some streams",
+			failing:  []string{"sidecar"},
+			messages: []string{"app started"},
+		},
+		{
+			name:    "no stream",
+			failing: []string{"app", "sidecar"},
+			code:    codes.NotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useFakeKubernetes(t, &fakeKubernetes{
+				objects: map[string]any{"/api/v1/namespaces/default/pods/web-0": testPod("web-0", "app", "sidecar")},
+				logs: func(pod string, query url.Values) (int, string) {
+					container := query.Get("container")
+					for _, failing := range test.failing {
+						if container == failing {
+							return http.StatusBadRequest, "container is waiting to start"
+						}
+					}
+					return http.StatusOK, "2026-10-17T12:00:00Z " + container + " started\n"
+				},
+			})
+
+			stream := &fakeFollowStream{ctx: context.Background()}
+			err := (&server{}).FollowLogs(&LogsRequest{Namespace: "default", Pod: "web-0"}, stream)
+			if status.Code(err) != test.code {
+				t.Fatalf("expected code %s, got %v", test.code, err)
+			}
+
+			messages := map[string]bool{}
+			for _, entry := range stream.entries {
+				messages[entry.GetMessage()] = true
+			}
+			if len(messages) != len(test.messages) {
+				t.Errorf("expected messages %v, got %v", test.messages, messages)
+			}
+			for _, message := range test.messages {
+				if !messages[message] {
+					t.Errorf("expected message %q, got %v", message, messages)
+				}
+			}
+		})
+	}
+}
+
+func TestGetWorkloadLogs(t *testing.T) {
+	// testStatefulSetPods returns count pods of the web StatefulSet.
+	testStatefulSetPods := func(count int) *v1.PodList {
+		pods := &v1.PodList{}
+		for i := range count {
+			pod := testPod(fmt.Sprintf("web-%d", i), "app")
+			pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "web", UID: "web-uid", Controller: &[]bool{true}[0]}}
+			pods.Items = append(pods.Items, *pod)
+		}
+		return pods
+	}
+	since := time.Date(2026, 10, 17, 11, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		req   *ResourceRequest
+		pods  int
+		query string
+		code  codes.Code
+	}{
+		{
+			name:  "default tail",
+			req:   &ResourceRequest{},
+			pods:  2,
+			query: "tailLines=1000",
+		},
+		{
+			name:  "tail",
+			req:   &ResourceRequest{TailLines: 10},
+			pods:  2,
+			query: "tailLines=10",
+		},
+		{
+			name:  "since seconds",
+			req:   &ResourceRequest{SinceSeconds: 60},
+			pods:  2,
+			query: "sinceSeconds=60",
+		},
+		{
+			name:  "since time and tail",
+			req:   &ResourceRequest{TailLines: 10, SinceTime: timestamppb.New(since)},
+			pods:  2,
+			query: "sinceTime=2026-10-17T11%3A00%3A00Z&tailLines=10",
+		},
+		{
+			name: "as many pods as a selector",
+			req:  &ResourceRequest{},
+			pods: maxSelectorPods,
+		},
+		{
+			name: "too many pods",
+			req:  &ResourceRequest{},
+			pods: maxSelectorPods + 1,
+			code: codes.FailedPrecondition,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mu sync.Mutex
+			queries := map[string]bool{}
+			useFakeKubernetes(t, &fakeKubernetes{
+				objects: map[string]any{
+					"/apis/apps/v1/namespaces/default/statefulsets/web": &appsv1.StatefulSet{
+						ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+						Spec:       appsv1.StatefulSetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+					},
+					"/api/v1/namespaces/default/pods": testStatefulSetPods(test.pods),
+				},
+				logs: func(pod string, query url.Values) (int, string) {
+					mu.Lock()
+					defer mu.Unlock()
+					query.Del("container")
+					query.Del("timestamps")
+					queries[query.Encode()] = true
+					return http.StatusOK, "2026-10-17T12:00:00Z " + pod + " started\n"
+				},
+			})
+
+			req := test.req
+			req.Namespace, req.ResourceType, req.Name = "default", ResourceType_RESOURCE_TYPE_STATEFULSET, "web"
+			logs, err := (&server{}).GetWorkloadLogs(context.Background(), req)
+			if status.Code(err) != test.code {
+				t.Fatalf("expected code %s, got %v", test.code, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if len(logs.GetEntries()) != test.pods {
+				t.Errorf("expected %d entries, got %d", test.pods, len(logs.GetEntries()))
+			}
+			if len(test.query) > 0 && (len(queries) != 1 || !queries[test.query]) {
+				t.Errorf("expected log queries %q, got %v", test.query, queries)
+			}
+		})
+	}
+}
+
+func TestAnalyseStatefulSet(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	statefulSet := func(replicas *int32, partition *int32, status appsv1.StatefulSetStatus) *appsv1.StatefulSet {
+		statefulSet := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: 2},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:       replicas,
+				UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+			},
+			Status: status,
+		}
+		if partition != nil {
+			statefulSet.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{Partition: partition}
+		}
+		if statefulSet.Status.ObservedGeneration == 0 {
+			statefulSet.Status.ObservedGeneration = 2
+		}
+		return statefulSet
+	}
+
+	tests := []struct {
+		name        string
+		statefulSet *appsv1.StatefulSet
+		status      string
+		// replicas lists the replicas, ready, current and updated replicas.
+		replicas string
+	}{
+		{
+			name:        "ready",
+			statefulSet: statefulSet(int32Ptr(3), nil, appsv1.StatefulSetStatus{ReadyReplicas: 3, CurrentReplicas: 3, UpdatedReplicas: 3, CurrentRevision: "web-1", UpdateRevision: "web-1"}),
+			status:      "Ready",
+			replicas:    "3 3 3 3",
+		},
+		{
+			name:        "nil replicas",
+			statefulSet: statefulSet(nil, nil, appsv1.StatefulSetStatus{ReadyReplicas: 1, CurrentReplicas: 1, UpdatedReplicas: 1, CurrentRevision: "web-1", UpdateRevision: "web-1"}),
+			status:      "Ready",
+			replicas:    "1 1 1 1",
+		},
+		{
+			name:        "nil replicas not ready",
+			statefulSet: statefulSet(nil, nil, appsv1.StatefulSetStatus{CurrentReplicas: 1, UpdatedReplicas: 1, CurrentRevision: "web-1", UpdateRevision: "web-1"}),
+			status:      "NotReady",
+			replicas:    "1 0 1 1",
+		},
+		{
+			name:        "fewer ready replicas",
+			statefulSet: statefulSet(int32Ptr(3), nil, appsv1.StatefulSetStatus{ReadyReplicas: 2, CurrentReplicas: 3, UpdatedReplicas: 3, CurrentRevision: "web-1", UpdateRevision: "web-1"}),
+			status:      "NotReady",
+			replicas:    "3 2 3 3",
+		},
+		{
+			name:        "generation not observed",
+			statefulSet: statefulSet(int32Ptr(3), nil, appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3, CurrentReplicas: 3, UpdatedReplicas: 3, CurrentRevision: "web-1", UpdateRevision: "web-1"}),
+			status:      "Progressing",
+			replicas:    "3 3 3 3",
+		},
+		{
+			name:        "rolling update",
+			statefulSet: statefulSet(int32Ptr(3), nil, appsv1.StatefulSetStatus{ReadyReplicas: 3, CurrentReplicas: 2, UpdatedReplicas: 1, CurrentRevision: "web-1", UpdateRevision: "web-2"}),
+			status:      "Updating",
+			replicas:    "3 3 2 1",
+		},
+		{
+			name:        "rolling update done before the current revision moves",
+			statefulSet: statefulSet(int32Ptr(3), nil, appsv1.StatefulSetStatus{ReadyReplicas: 2, UpdatedReplicas: 3, CurrentRevision: "web-1", UpdateRevision: "web-2"}),
+			status:      "NotReady",
+			replicas:    "3 2 0 3",
+		},
+		{
+			name:        "partitioned rolling update in progress",
+			statefulSet: statefulSet(int32Ptr(3), int32Ptr(1), appsv1.StatefulSetStatus{ReadyReplicas: 3, CurrentReplicas: 2, UpdatedReplicas: 1, CurrentRevision: "web-1", UpdateRevision: "web-2"}),
+			status:      "Updating",
+			replicas:    "3 3 2 1",
+		},
+		{
+			name:        "partitioned rolling update done",
+			statefulSet: statefulSet(int32Ptr(3), int32Ptr(2), appsv1.StatefulSetStatus{ReadyReplicas: 3, CurrentReplicas: 2, UpdatedReplicas: 1, CurrentRevision: "web-1", UpdateRevision: "web-2"}),
+			status:      "Ready",
+			replicas:    "3 3 2 1",
+		},
+		{
+			name:        "partition past the replicas",
+			statefulSet: statefulSet(int32Ptr(3), int32Ptr(5), appsv1.StatefulSetStatus{ReadyReplicas: 3, CurrentReplicas: 3, CurrentRevision: "web-1", UpdateRevision: "web-2"}),
+			status:      "Ready",
+			replicas:    "3 3 3 0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resource := analyseStatefulSet(test.statefulSet)
+			if resource.GetStatus() != test.status {
+				t.Errorf("expected status %s, got %s", test.status, resource.GetStatus())
+			}
+
+			fields := resource.GetFields().GetFields()
+			replicas := fmt.Sprintf("%s %s %s %s", fields["Replicas"].GetStringValue(), fields["ReadyReplicas"].GetStringValue(), fields["CurrentReplicas"].GetStringValue(), fields["UpdatedReplicas"].GetStringValue())
+			if replicas != test.replicas {
+				t.Errorf("expected replicas %s, got %s", test.replicas, replicas)
+			}
+			if partition := test.statefulSet.Spec.UpdateStrategy.RollingUpdate; partition != nil {
+				if got := fields["UpdateStrategy"].GetStructValue().GetFields()["Partition"].GetStringValue(); got != fmt.Sprint(*partition.Partition) {
+					t.Errorf("expected partition %d, got %s", *partition.Partition, got)
+				}
+			}
+		})
+	}
+}
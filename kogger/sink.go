@@ -0,0 +1,145 @@
+package kogger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// LogSink receives the logs gathered by the collector, one pod at a time.
+type LogSink interface {
+	Write(ctx context.Context, logs *Logs) error
+	Close() error
+}
+
+// sinkFactories maps the sink names accepted in COLLECTOR_SINKS to their
+// constructors. Each sink reads its own settings from the environment, and
+// logs with the token of the collector run.
+var sinkFactories = map[string]func(grpcToken string) (LogSink, error){
+	"stdout":        newStdoutSink,
+	"store":         newStoreSink,
+	"loki":          newLokiSink,
+	"elasticsearch": newElasticsearchSink,
+	"opensearch":    newElasticsearchSink,
+	"otlp":          newOtlpSink,
+	"syslog":        newSyslogSink,
+	"kafka":         newKafkaSink,
+	"s3":            newS3Sink,
+	"file":          newFileSink,
+}
+
+// newLogSinks builds the sinks listed, comma separated, in names.
+func newLogSinks(grpcToken string, names string) ([]LogSink, error) {
+	sinks := []LogSink{}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+
+		factory, ok := sinkFactories[name]
+		if !ok {
+			closeLogSinks(sinks)
+			return nil, fmt.Errorf("unknown sink %q, available sinks are %s", name, strings.Join(availableSinks(), ", "))
+		}
+		sink, err := factory(grpcToken)
+		if err != nil {
+			closeLogSinks(sinks)
+			return nil, fmt.Errorf("failed to create sink %s: %s", name, err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("no sink configured")
+	}
+	return sinks, nil
+}
+
+func closeLogSinks(sinks []LogSink) error {
+	var firstErr error
+	for _, sink := range sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func availableSinks() []string {
+	names := []string{}
+	for name := range sinkFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newRunID returns the identifier of a collector run, used by the sinks
+// writing files so that runs do not overwrite each other: the UTC start time,
+// sorting runs in order, and a random suffix.
+func newRunID() (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix), nil
+}
+
+// stdoutSink prints the collected logs on the standard output.
+type stdoutSink struct{}
+
+func newStdoutSink(grpcToken string) (LogSink, error) {
+	return &stdoutSink{}, nil
+}
+
+func (*stdoutSink) Write(ctx context.Context, logs *Logs) error {
+	for _, entry := range logs.GetEntries() {
+		if _, err := fmt.Fprintf(os.Stdout, "%s/%s/%s %s %s\n", logs.GetNamespace(), entry.GetPod(), entry.GetContainer(), entry.GetTimestamp(), entry.GetMessage()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (*stdoutSink) Close() error {
+	return nil
+}
+
+// storeSink sends the collected logs to the store of the kogger service.
+type storeSink struct {
+	conn   *grpc.ClientConn
+	client KoggerServiceClient
+}
+
+func newStoreSink(grpcToken string) (LogSink, error) {
+	conn, err := grpc.NewClient(fmt.Sprintf("%v:%v", KoggerHost, KoggerPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(collectorMaxMsgSize)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &storeSink{
+		conn:   conn,
+		client: NewKoggerServiceClient(conn),
+	}, nil
+}
+
+func (s *storeSink) Write(ctx context.Context, logs *Logs) error {
+	_, err := s.client.StoreLogs(ctx, logs)
+	return err
+}
+
+func (s *storeSink) Close() error {
+	return s.conn.Close()
+}
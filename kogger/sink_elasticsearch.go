@@ -0,0 +1,396 @@
+package kogger
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	logger "github.com/ZolaraProject/library/logger"
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+)
+
+// elasticsearchFields lists the fields of the documents, with their default
+// name and mapping. ES_FIELDS renames them, or drops them with a "-" name.
+var elasticsearchFields = []struct {
+	source  string
+	target  string
+	mapping map[string]any
+}{
+	{"timestamp", "@timestamp", map[string]any{"type": "date_nanos"}},
+	{"message", "message", map[string]any{"type": "text"}},
+	{"level", "log.level", map[string]any{"type": "keyword"}},
+	{"namespace", "kubernetes.namespace", map[string]any{"type": "keyword"}},
+	{"pod", "kubernetes.pod", map[string]any{"type": "keyword"}},
+	{"container", "kubernetes.container", map[string]any{"type": "keyword"}},
+	{"containerId", "kubernetes.container_id", map[string]any{"type": "keyword"}},
+	{"restartCount", "kubernetes.restart_count", map[string]any{"type": "integer"}},
+	// Label names have their dots replaced, so that app and app.kubernetes.io/name
+	// do not map app both as a keyword and an object.
+	{"labels", "kubernetes.labels", map[string]any{"type": "object"}},
+	// Structured fields differ from one application to the other, indexing
+	// them would lead to mapping conflicts.
+	{"fields", "fields", map[string]any{"type": "object", "enabled": false}},
+}
+
+// elasticsearchSink writes the collected logs to an Elasticsearch or
+// OpenSearch _bulk endpoint, in daily indices named after ES_INDEX. Documents
+// are created with an ID derived from their content, so entries sent again
+// after a failed run are not duplicated, while the collector checkpoints
+// avoid sending them in the first place. Items rejected with a retryable
+// status are sent again with backoff, those rejected for good are logged and
+// dropped.
+type elasticsearchSink struct {
+	grpcToken  string
+	url        string
+	index      string
+	dateFormat string
+	fields     map[string]string
+	username   string
+	password   string
+	apiKey     string
+
+	client       *http.Client
+	retry        retryPolicy
+	batchEntries int
+	batchBytes   int64
+
+	pending      []bulkItem
+	pendingBytes int64
+	// rejected counts the documents rejected for good, which are dropped.
+	rejected int
+	// failed is the first batch which could not be written, reported again
+	// on Close so that the collector does not move its checkpoints.
+	failed error
+}
+
+// bulkItem is a document of a bulk request.
+type bulkItem struct {
+	index    string
+	id       string
+	document []byte
+}
+
+func newElasticsearchSink(grpcToken string) (LogSink, error) {
+	baseURL := strings.TrimSuffix(getEnv("ES_URL", ""), "/")
+	if len(baseURL) == 0 {
+		return nil, fmt.Errorf("ES_URL environment variable is not set")
+	}
+	if parsed, err := url.Parse(baseURL); err != nil || len(parsed.Host) == 0 {
+		return nil, fmt.Errorf("invalid ES_URL %q", baseURL)
+	}
+
+	sink := &elasticsearchSink{
+		grpcToken:  grpcToken,
+		url:        baseURL,
+		index:      getEnv("ES_INDEX", "kogger-logs-{date}"),
+		dateFormat: getEnv("ES_INDEX_DATE_FORMAT", "2006.01.02"),
+		fields:     map[string]string{},
+		username:   getEnv("ES_USERNAME", ""),
+		password:   getEnv("ES_PASSWORD", ""),
+		apiKey:     getEnv("ES_API_KEY", ""),
+	}
+	if sink.index != strings.ToLower(sink.index) {
+		return nil, fmt.Errorf("invalid ES_INDEX %q, index names must be lower case", sink.index)
+	}
+
+	for _, field := range elasticsearchFields {
+		sink.fields[field.source] = field.target
+	}
+	for _, rename := range strings.Split(getEnv("ES_FIELDS", ""), ",") {
+		if rename = strings.TrimSpace(rename); len(rename) == 0 {
+			continue
+		}
+		source, target, ok := strings.Cut(rename, "=")
+		if _, known := sink.fields[source]; !ok || !known || len(target) == 0 {
+			return nil, fmt.Errorf("invalid ES_FIELDS entry %q, expected <field>=<name> with a field among %s", rename, strings.Join(elasticsearchSources(), ", "))
+		}
+		sink.fields[source] = target
+	}
+
+	var err error
+	if sink.retry, err = newRetryPolicy("ES"); err != nil {
+		return nil, err
+	}
+	if sink.batchEntries, err = parseIntEnv("ES_BATCH_SIZE", 500); err != nil {
+		return nil, err
+	}
+	if sink.batchBytes, err = parseBytesEnv("ES_BATCH_BYTES"); err != nil {
+		return nil, err
+	}
+	if sink.batchBytes == 0 {
+		sink.batchBytes = 5 * 1024 * 1024
+	}
+	timeout, err := parseDurationEnv("ES_TIMEOUT")
+	if err != nil {
+		return nil, err
+	}
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	sink.client = &http.Client{Timeout: timeout}
+
+	if template := getEnv("ES_TEMPLATE", "kogger-logs"); len(template) > 0 {
+		if err := sink.putIndexTemplate(context.Background(), template); err != nil {
+			return nil, fmt.Errorf("failed to install index template %s: %s", template, err)
+		}
+	}
+
+	return sink, nil
+}
+
+func elasticsearchSources() []string {
+	sources := []string{}
+	for _, field := range elasticsearchFields {
+		sources = append(sources, field.source)
+	}
+	return sources
+}
+
+// putIndexTemplate installs the template applied to the daily indices. Its
+// body is read from ES_TEMPLATE_FILE when set, and otherwise maps the fields
+// of the documents.
+func (s *elasticsearchSink) putIndexTemplate(ctx context.Context, name string) error {
+	var body []byte
+	if path := getEnv("ES_TEMPLATE_FILE", ""); len(path) > 0 {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		body = data
+	} else {
+		properties := map[string]any{}
+		for _, field := range elasticsearchFields {
+			if target := s.fields[field.source]; target != "-" {
+				properties[target] = field.mapping
+			}
+		}
+
+		pattern := strings.NewReplacer("{date}", "*", "{namespace}", "*").Replace(s.index)
+		data, err := json.Marshal(map[string]any{
+			"index_patterns": []string{pattern},
+			"template": map[string]any{
+				"mappings": map[string]any{
+					"properties": properties,
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		body = data
+	}
+
+	_, err := doWithRetry(ctx, s.client, s.retry, func() (*http.Request, error) {
+		return s.newRequest(http.MethodPut, "/_index_template/"+url.PathEscape(name), "application/json", body)
+	})
+	return err
+}
+
+func (s *elasticsearchSink) newRequest(method, path, contentType string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequest(method, s.url+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if len(s.apiKey) > 0 {
+		req.Header.Set("Authorization", "ApiKey "+s.apiKey)
+	} else if len(s.username) > 0 {
+		req.SetBasicAuth(s.username, s.password)
+	}
+	return req, nil
+}
+
+func (s *elasticsearchSink) Write(ctx context.Context, logs *Logs) error {
+	now := time.Now()
+	for _, entry := range logs.GetEntries() {
+		pod := entry.GetPod()
+		if len(pod) == 0 {
+			pod = logs.GetPod()
+		}
+		at := now
+		if entry.GetTime() != nil {
+			at = entry.GetTime().AsTime()
+		}
+
+		values := map[string]any{
+			"timestamp":    at.UTC().Format(time.RFC3339Nano),
+			"message":      entry.GetMessage(),
+			"namespace":    logs.GetNamespace(),
+			"pod":          pod,
+			"container":    entry.GetContainer(),
+			"containerId":  entry.GetContainerId(),
+			"restartCount": entry.GetRestartCount(),
+		}
+		if entry.GetLevel() != LogLevel_LOG_LEVEL_UNKNOWN {
+			values["level"] = strings.ToLower(strings.TrimPrefix(entry.GetLevel().String(), "LOG_LEVEL_"))
+		}
+		if len(logs.GetPodLabels()) > 0 {
+			values["labels"] = elasticsearchLabels(logs.GetPodLabels())
+		}
+		if entry.GetFields() != nil {
+			values["fields"] = entry.GetFields().AsMap()
+		}
+
+		document := map[string]any{}
+		for source, value := range values {
+			if target := s.fields[source]; target != "-" {
+				document[target] = value
+			}
+		}
+		data, err := json.Marshal(document)
+		if err != nil {
+			return err
+		}
+
+		index := strings.NewReplacer("{date}", at.UTC().Format(s.dateFormat), "{namespace}", logs.GetNamespace()).Replace(s.index)
+		id := sha256.Sum256([]byte(strings.Join([]string{logs.GetNamespace(), pod, entry.GetContainer(), entry.GetContainerId(), at.Format(time.RFC3339Nano), entry.GetMessage()}, "\x00")))
+		s.pending = append(s.pending, bulkItem{
+			index:    index,
+			id:       hex.EncodeToString(id[:16]),
+			document: data,
+		})
+		s.pendingBytes += int64(len(data))
+
+		if len(s.pending) >= s.batchEntries || s.pendingBytes >= s.batchBytes {
+			if err := s.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// elasticsearchLabels replaces the dots of the label names, which
+// Elasticsearch would otherwise expand into nested objects.
+func elasticsearchLabels(labels map[string]string) map[string]string {
+	replaced := make(map[string]string, len(labels))
+	for name, value := range labels {
+		replaced[strings.ReplaceAll(name, ".", "_")] = value
+	}
+	return replaced
+}
+
+func (s *elasticsearchSink) Close() error {
+	if err := s.flush(context.Background()); err != nil {
+		return err
+	}
+	if s.rejected > 0 {
+		logger.Warn(s.grpcToken, "Elasticsearch rejected %d documents", s.rejected)
+	}
+	return s.failed
+}
+
+// bulkResponse is the part of a _bulk response needed to find failed items.
+type bulkResponse struct {
+	Errors bool                          `json:"errors"`
+	Items  []map[string]bulkItemResponse `json:"items"`
+}
+
+type bulkItemResponse struct {
+	Status int `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// flush sends the pending documents. Items failing with a retryable status
+// are sent again, along with the whole request on network errors, throttling
+// and server errors, until the retries are exhausted. Items rejected for good,
+// such as mapping errors, are logged and dropped without failing the run:
+// they would be rejected again by every later run, which would then never
+// move its checkpoints.
+func (s *elasticsearchSink) flush(ctx context.Context) error {
+	items := s.pending
+	s.pending, s.pendingBytes = nil, 0
+
+	var lastErr error
+	for retry := 0; len(items) > 0; retry++ {
+		if retry > 0 {
+			if retry > s.retry.maxRetries {
+				return s.fail(fmt.Errorf("failed to write %d documents to elasticsearch after %d attempts: %s", len(items), retry, lastErr))
+			}
+			retryAfter := time.Duration(0)
+			if statusErr, ok := lastErr.(*httpStatusError); ok {
+				retryAfter = statusErr.retryAfter
+			}
+			if err := s.retry.wait(ctx, retry, retryAfter); err != nil {
+				return s.fail(err)
+			}
+		}
+
+		var body bytes.Buffer
+		for _, item := range items {
+			action, err := json.Marshal(map[string]any{
+				"create": map[string]string{"_index": item.index, "_id": item.id},
+			})
+			if err != nil {
+				return err
+			}
+			body.Write(action)
+			body.WriteByte('\n')
+			body.Write(item.document)
+			body.WriteByte('\n')
+		}
+
+		data, err := doRequest(ctx, s.client, func() (*http.Request, error) {
+			return s.newRequest(http.MethodPost, "/_bulk", "application/x-ndjson", body.Bytes())
+		})
+		if err != nil {
+			if statusErr, ok := err.(*httpStatusError); (ok && !statusErr.retryable()) || ctx.Err() != nil {
+				return s.fail(fmt.Errorf("failed to write %d documents to elasticsearch: %s", len(items), err))
+			}
+			lastErr = err
+			continue
+		}
+
+		response := &bulkResponse{}
+		if err := json.Unmarshal(data, response); err != nil {
+			return s.fail(fmt.Errorf("invalid bulk response: %s", err))
+		}
+		if !response.Errors {
+			return nil
+		}
+		if len(response.Items) != len(items) {
+			return s.fail(fmt.Errorf("invalid bulk response: %d items for %d documents", len(response.Items), len(items)))
+		}
+
+		failed := []bulkItem{}
+		for i, result := range response.Items {
+			for _, item := range result {
+				switch {
+				case item.Status < 300 || item.Status == http.StatusConflict:
+					// Created, or already created by a previous run.
+				case item.Status == http.StatusTooManyRequests || item.Status >= 500:
+					failed = append(failed, items[i])
+					lastErr = fmt.Errorf("document %s failed with status %d", items[i].id, item.Status)
+				default:
+					s.rejected++
+					reason := ""
+					if item.Error != nil {
+						reason = item.Error.Type + ": " + item.Error.Reason
+					}
+					logger.Warn(s.grpcToken, "Elasticsearch rejected document %s in index %s with status %d: %s", items[i].id, items[i].index, item.Status, reason)
+				}
+			}
+		}
+		items = failed
+	}
+	return nil
+}
+
+// fail records the first error of the sink and returns err.
+func (s *elasticsearchSink) fail(err error) error {
+	if s.failed == nil {
+		s.failed = err
+	}
+	return err
+}
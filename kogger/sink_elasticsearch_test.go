@@ -0,0 +1,396 @@
+package kogger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+)
+
+// fakeElasticsearch answers the template and _bulk requests, with the status
+// of the bulk requests and items taken in order from statuses.
+type fakeElasticsearch struct {
+	t *testing.T
+
+	lock      sync.Mutex
+	templates map[string]map[string]any
+	bulks     [][]map[string]any
+	// statuses are the answers to the successive bulk requests: the request
+	// status, followed by the status of each item when the request succeeds.
+	statuses [][]int
+}
+
+func newFakeElasticsearch(t *testing.T, statuses ...[]int) (*fakeElasticsearch, *httptest.Server) {
+	fake := &fakeElasticsearch{t: t, templates: map[string]map[string]any{}, statuses: statuses}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeElasticsearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.t.Errorf("failed to read request: %s", err)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/_index_template/"):
+		template := map[string]any{}
+		if err := json.Unmarshal(body, &template); err != nil {
+			f.t.Errorf("invalid template: %s", err)
+		}
+		f.templates[strings.TrimPrefix(r.URL.Path, "/_index_template/")] = template
+		w.Write([]byte(`{"acknowledged":true}`))
+
+	case r.Method == http.MethodPost && r.URL.Path == "/_bulk":
+		if r.Header.Get("Content-Type") != "application/x-ndjson" {
+			f.t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		lines := []map[string]any{}
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			line := map[string]any{}
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				f.t.Errorf("invalid bulk line %q: %s", scanner.Text(), err)
+			}
+			lines = append(lines, line)
+		}
+		f.bulks = append(f.bulks, lines)
+
+		statuses := []int{http.StatusOK}
+		if len(f.statuses) > 0 {
+			statuses, f.statuses = f.statuses[0], f.statuses[1:]
+		}
+		if statuses[0] != http.StatusOK {
+			w.WriteHeader(statuses[0])
+			return
+		}
+
+		items := []any{}
+		hasErrors := false
+		for i := 0; i < len(lines)/2; i++ {
+			status := http.StatusCreated
+			if i+1 < len(statuses) {
+				status = statuses[i+1]
+			}
+			item := map[string]any{"status": status}
+			if status >= 300 {
+				hasErrors = true
+				item["error"] = map[string]any{"type": "test_exception", "reason": fmt.Sprintf("status %d", status)}
+			}
+			items = append(items, map[string]any{"create": item})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"errors": hasErrors, "items": items})
+
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// documents returns the messages of the documents of each bulk request.
+func (f *fakeElasticsearch) documents() [][]string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	requests := [][]string{}
+	for _, lines := range f.bulks {
+		messages := []string{}
+		for i := 1; i < len(lines); i += 2 {
+			messages = append(messages, fmt.Sprint(lines[i]["message"]))
+		}
+		requests = append(requests, messages)
+	}
+	return requests
+}
+
+func setElasticsearchEnv(t *testing.T, url string) {
+	setSinkEnv(t, "ES", map[string]string{"URL": url})
+}
+
+func testElasticsearchLogs(messages ...string) *Logs {
+	logs := testSinkLogs(messages...)
+	logs.PodLabels = map[string]string{"app": "web", "app.kubernetes.io/name": "web"}
+	for _, entry := range logs.Entries {
+		entry.Level = LogLevel_LOG_LEVEL_INFO
+	}
+	return logs
+}
+
+func TestElasticsearchSinkTemplate(t *testing.T) {
+	fake, server := newFakeElasticsearch(t)
+	setElasticsearchEnv(t, server.URL)
+	t.Setenv("ES_FIELDS", "message=log.message,containerId=-")
+
+	sink, err := newElasticsearchSink("")
+	if err != nil {
+		t.Fatalf("failed to create sink: %s", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unexpected error on close: %s", err)
+	}
+
+	template, ok := fake.templates["kogger-logs"]
+	if !ok {
+		t.Fatalf("template kogger-logs not installed, got %v", fake.templates)
+	}
+	if patterns := fmt.Sprint(template["index_patterns"]); patterns != "[kogger-logs-*]" {
+		t.Errorf("unexpected index patterns %s", patterns)
+	}
+	properties := template["template"].(map[string]any)["mappings"].(map[string]any)["properties"].(map[string]any)
+	for field, mapping := range map[string]string{
+		"@timestamp":        "date_nanos",
+		"log.message":       "text",
+		"kubernetes.pod":    "keyword",
+		"kubernetes.labels": "object",
+	} {
+		property, ok := properties[field].(map[string]any)
+		if !ok || property["type"] != mapping {
+			t.Errorf("expected field %s mapped as %s, got %v", field, mapping, properties[field])
+		}
+	}
+	for _, field := range []string{"message", "kubernetes.container_id"} {
+		if _, ok := properties[field]; ok {
+			t.Errorf("unexpected field %s in the mapping", field)
+		}
+	}
+}
+
+func TestElasticsearchSinkBulk(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses [][]int
+		// requests are the messages expected in each bulk request.
+		requests [][]string
+		failure  string
+		rejected int
+	}{
+		{
+			name:     "created",
+			requests: [][]string{{"a", "b", "c"}},
+		},
+		{
+			name:     "already created",
+			statuses: [][]int{{http.StatusOK, http.StatusCreated, http.StatusConflict, http.StatusCreated}},
+			requests: [][]string{{"a", "b", "c"}},
+		},
+		{
+			name: "retry throttled and failed items",
+			statuses: [][]int{
+				{http.StatusOK, http.StatusCreated, http.StatusTooManyRequests, http.StatusServiceUnavailable},
+				{http.StatusOK, http.StatusCreated, http.StatusTooManyRequests},
+			},
+			requests: [][]string{{"a", "b", "c"}, {"b", "c"}, {"c"}},
+		},
+		{
+			name:     "retry request",
+			statuses: [][]int{{http.StatusServiceUnavailable}, {http.StatusTooManyRequests}},
+			requests: [][]string{{"a", "b", "c"}, {"a", "b", "c"}, {"a", "b", "c"}},
+		},
+		{
+			name:     "retries exhausted",
+			statuses: [][]int{{http.StatusBadGateway}, {http.StatusBadGateway}, {http.StatusBadGateway}},
+			requests: [][]string{{"a", "b", "c"}, {"a", "b", "c"}, {"a", "b", "c"}},
+			failure:  "after 3 attempts",
+		},
+		{
+			name: "items retries exhausted",
+			statuses: [][]int{
+				{http.StatusOK, http.StatusCreated, http.StatusCreated, http.StatusInternalServerError},
+				{http.StatusOK, http.StatusInternalServerError},
+				{http.StatusOK, http.StatusInternalServerError},
+			},
+			requests: [][]string{{"a", "b", "c"}, {"c"}, {"c"}},
+			failure:  "failed to write 1 documents",
+		},
+		{
+			name:     "rejected request",
+			statuses: [][]int{{http.StatusBadRequest}},
+			requests: [][]string{{"a", "b", "c"}},
+			failure:  "unexpected status 400",
+		},
+		{
+			name:     "rejected item",
+			statuses: [][]int{{http.StatusOK, http.StatusCreated, http.StatusBadRequest, http.StatusCreated}},
+			requests: [][]string{{"a", "b", "c"}},
+			rejected: 1,
+		},
+		{
+			name: "rejected and retried items",
+			statuses: [][]int{
+				{http.StatusOK, http.StatusTooManyRequests, http.StatusBadRequest, http.StatusCreated},
+			},
+			requests: [][]string{{"a", "b", "c"}, {"a"}},
+			rejected: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, server := newFakeElasticsearch(t, test.statuses...)
+			setElasticsearchEnv(t, server.URL)
+			t.Setenv("ES_TEMPLATE", "")
+
+			sink, err := newElasticsearchSink("")
+			if err != nil {
+				t.Fatalf("failed to create sink: %s", err)
+			}
+			if err := sink.Write(context.Background(), testElasticsearchLogs("a", "b", "c")); err != nil {
+				t.Fatalf("unexpected error on write: %s", err)
+			}
+
+			err = sink.Close()
+			switch {
+			case len(test.failure) == 0 && err != nil:
+				t.Errorf("unexpected error on close: %s", err)
+			case len(test.failure) > 0 && err == nil:
+				t.Errorf("expected an error on close")
+			case len(test.failure) > 0 && !strings.Contains(err.Error(), test.failure):
+				t.Errorf("expected an error containing %q on close, got %q", test.failure, err)
+			}
+
+			if requests := fake.documents(); fmt.Sprint(requests) != fmt.Sprint(test.requests) {
+				t.Errorf("expected bulk requests %v, got %v", test.requests, requests)
+			}
+			if rejected := sink.(*elasticsearchSink).rejected; rejected != test.rejected {
+				t.Errorf("expected %d rejected documents, got %d", test.rejected, rejected)
+			}
+		})
+	}
+}
+
+func TestElasticsearchSinkCollectorCheckpoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses [][]int
+		saved    bool
+	}{
+		{
+			name:     "rejected document",
+			statuses: [][]int{{http.StatusOK, http.StatusCreated, http.StatusBadRequest}},
+			saved:    true,
+		},
+		{
+			name:     "rejected request",
+			statuses: [][]int{{http.StatusBadRequest}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, server := newFakeElasticsearch(t, test.statuses...)
+			setElasticsearchEnv(t, server.URL)
+			t.Setenv("ES_TEMPLATE", "")
+			useFakeKoggerServer(t, &fakeLogsClient{
+				containers: []string{"app"},
+				current:    map[string][]*LogEntry{"app": testCollectorEntries("app", "app-1", 0, 1000)},
+			})
+
+			path := filepath.Join(t.TempDir(), "checkpoints.json")
+			t.Setenv("COLLECTOR_NAMESPACES", "default")
+			t.Setenv("COLLECTOR_SINKS", "elasticsearch")
+			t.Setenv("COLLECTOR_CHECKPOINT", "file")
+			t.Setenv("COLLECTOR_CHECKPOINT_PATH", path)
+
+			err := RunCollector(context.Background(), "")
+			if test.saved && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !test.saved && err == nil {
+				t.Fatalf("expected the run to fail")
+			}
+			if requests := fmt.Sprint(fake.documents()); requests != "[[app@0 app@1000]]" {
+				t.Errorf("unexpected bulk requests %s", requests)
+			}
+
+			cps, err := (&fileCheckpointStore{path: path}).Load(context.Background())
+			if err != nil {
+				t.Fatalf("failed to load checkpoints: %s", err)
+			}
+			expected := checkpoints{}
+			if test.saved {
+				expected["default/web-0/app"] = checkpoint{ContainerID: "app-1", LastTime: testCollectorTime.Add(time.Second)}
+			}
+			if fmt.Sprint(cps) != fmt.Sprint(expected) {
+				t.Errorf("expected checkpoints %v, got %v", expected, cps)
+			}
+		})
+	}
+}
+
+func TestElasticsearchSinkBatch(t *testing.T) {
+	fake, server := newFakeElasticsearch(t)
+	setElasticsearchEnv(t, server.URL)
+	t.Setenv("ES_TEMPLATE", "")
+	t.Setenv("ES_BATCH_SIZE", "2")
+
+	sink, err := newElasticsearchSink("")
+	if err != nil {
+		t.Fatalf("failed to create sink: %s", err)
+	}
+	if err := sink.Write(context.Background(), testElasticsearchLogs("a", "b", "c", "d", "e")); err != nil {
+		t.Fatalf("unexpected error on write: %s", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unexpected error on close: %s", err)
+	}
+
+	if requests := fmt.Sprint(fake.documents()); requests != "[[a b] [c d] [e]]" {
+		t.Errorf("unexpected bulk requests %s", requests)
+	}
+}
+
+func TestElasticsearchSinkDocument(t *testing.T) {
+	fake, server := newFakeElasticsearch(t)
+	setElasticsearchEnv(t, server.URL)
+	t.Setenv("ES_TEMPLATE", "")
+
+	sink, err := newElasticsearchSink("")
+	if err != nil {
+		t.Fatalf("failed to create sink: %s", err)
+	}
+	if err := sink.Write(context.Background(), testElasticsearchLogs("a")); err != nil {
+		t.Fatalf("unexpected error on write: %s", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unexpected error on close: %s", err)
+	}
+
+	if len(fake.bulks) != 1 || len(fake.bulks[0]) != 2 {
+		t.Fatalf("expected a single document, got %v", fake.bulks)
+	}
+	action := fake.bulks[0][0]["create"].(map[string]any)
+	if action["_index"] != "kogger-logs-2026.10.17" || len(fmt.Sprint(action["_id"])) != 32 {
+		t.Errorf("unexpected action %v", action)
+	}
+
+	document := fake.bulks[0][1]
+	for field, value := range map[string]string{
+		"@timestamp":           "2026-10-17T12:00:00Z",
+		"message":              "a",
+		"log.level":            "info",
+		"kubernetes.namespace": "default",
+		"kubernetes.pod":       "web-0",
+		"kubernetes.container": "app",
+		"kubernetes.labels":    "map[app:web app_kubernetes_io/name:web]",
+	} {
+		if got := fmt.Sprint(document[field]); got != value {
+			t.Errorf("expected %s to be %q, got %q", field, value, got)
+		}
+	}
+}
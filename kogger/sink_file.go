@@ -0,0 +1,411 @@
+package kogger
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"github.com/xitongsys/parquet-go/writer"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	fileFormatNDJSON  = "ndjson"
+	fileFormatParquet = "parquet"
+
+	// fileIndexName is the name of the index of a run, written last.
+	fileIndexName = "index.json"
+	// fileTempExtension marks the files being written.
+	fileTempExtension = ".tmp"
+)
+
+// fileSink writes the collected logs to a local directory, typically a
+// mounted volume of an air-gapped cluster, one directory per run:
+//
+//	<FILE_PATH>/<run>/00000.ndjson
+//	<FILE_PATH>/<run>/00001.ndjson
+//	<FILE_PATH>/<run>/index.json
+//
+// Files hold newline delimited JSON or Parquet rows, and are rotated once
+// larger than FILE_MAX_SIZE or older than FILE_MAX_AGE. They carry a .tmp
+// extension until complete, and the index listing what the run collected is
+// written when the sink is closed, so that offline tooling only picks up runs
+// with an index.
+type fileSink struct {
+	dir     string
+	format  string
+	maxSize int64
+	maxAge  time.Duration
+
+	index   fileIndex
+	current *logFile
+}
+
+// fileIndex is the content of the index of a run.
+type fileIndex struct {
+	Run        string           `json:"run"`
+	Format     string           `json:"format"`
+	StartedAt  time.Time        `json:"startedAt"`
+	FinishedAt time.Time        `json:"finishedAt"`
+	Entries    int              `json:"entries"`
+	Files      []*fileIndexFile `json:"files"`
+}
+
+type fileIndexFile struct {
+	Name       string                `json:"name"`
+	Entries    int                   `json:"entries"`
+	Size       int64                 `json:"size"`
+	Sha256     string                `json:"sha256"`
+	FirstTime  time.Time             `json:"firstTime"`
+	LastTime   time.Time             `json:"lastTime"`
+	Containers []*fileIndexContainer `json:"containers"`
+}
+
+type fileIndexContainer struct {
+	Namespace string    `json:"namespace"`
+	Pod       string    `json:"pod"`
+	Container string    `json:"container"`
+	Entries   int       `json:"entries"`
+	FirstTime time.Time `json:"firstTime"`
+	LastTime  time.Time `json:"lastTime"`
+}
+
+// logFile is the file being written.
+type logFile struct {
+	path       string
+	file       *os.File
+	counter    *countingWriter
+	sum        hash.Hash
+	encoder    logFileEncoder
+	opened     time.Time
+	entry      *fileIndexFile
+	containers map[string]*fileIndexContainer
+}
+
+// logFileEncoder writes the entries in the format of the file.
+type logFileEncoder interface {
+	write(namespace string, entry *LogEntry) error
+	// buffered is the size of the entries written but not flushed yet.
+	buffered() int64
+	close() error
+}
+
+func newFileSink(grpcToken string) (LogSink, error) {
+	dir := getEnv("FILE_PATH", "")
+	if len(dir) == 0 {
+		return nil, fmt.Errorf("FILE_PATH environment variable is not set")
+	}
+
+	sink := &fileSink{
+		format: strings.ToLower(getEnv("FILE_FORMAT", fileFormatNDJSON)),
+	}
+	if sink.format != fileFormatNDJSON && sink.format != fileFormatParquet {
+		return nil, fmt.Errorf("invalid FILE_FORMAT %q, expected ndjson or parquet", sink.format)
+	}
+
+	var err error
+	if sink.maxSize, err = parseBytesEnv("FILE_MAX_SIZE"); err != nil {
+		return nil, err
+	}
+	if sink.maxSize == 0 {
+		sink.maxSize = 100 * 1024 * 1024
+	}
+	if sink.maxAge, err = parseDurationEnv("FILE_MAX_AGE"); err != nil {
+		return nil, err
+	}
+	if sink.maxAge == 0 {
+		sink.maxAge = time.Hour
+	}
+
+	run, err := newRunID()
+	if err != nil {
+		return nil, err
+	}
+	sink.dir = filepath.Join(dir, run)
+	if err := os.MkdirAll(sink.dir, 0o755); err != nil {
+		return nil, err
+	}
+	sink.index = fileIndex{
+		Run:       run,
+		Format:    sink.format,
+		StartedAt: time.Now().UTC(),
+		Files:     []*fileIndexFile{},
+	}
+
+	return sink, nil
+}
+
+func (s *fileSink) Write(ctx context.Context, logs *Logs) error {
+	now := time.Now()
+	for _, entry := range logs.GetEntries() {
+		if s.current != nil && (s.current.size() >= s.maxSize || now.Sub(s.current.opened) >= s.maxAge) {
+			if err := s.rotate(); err != nil {
+				return err
+			}
+		}
+		if s.current == nil {
+			if err := s.open(); err != nil {
+				return err
+			}
+		}
+
+		if len(entry.GetPod()) == 0 {
+			entry = proto.Clone(entry).(*LogEntry)
+			entry.Pod = logs.GetPod()
+		}
+		if err := s.current.encoder.write(logs.GetNamespace(), entry); err != nil {
+			return err
+		}
+
+		at := now
+		if entry.GetTime() != nil {
+			at = entry.GetTime().AsTime()
+		}
+		key := checkpointKey(logs.GetNamespace(), entry.GetPod(), entry.GetContainer())
+		container, ok := s.current.containers[key]
+		if !ok {
+			container = &fileIndexContainer{
+				Namespace: logs.GetNamespace(),
+				Pod:       entry.GetPod(),
+				Container: entry.GetContainer(),
+			}
+			s.current.containers[key] = container
+		}
+		updateFileTimes(&container.FirstTime, &container.LastTime, container.Entries, at)
+		updateFileTimes(&s.current.entry.FirstTime, &s.current.entry.LastTime, s.current.entry.Entries, at)
+		container.Entries++
+		s.current.entry.Entries++
+		s.index.Entries++
+	}
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	if err := s.rotate(); err != nil {
+		return err
+	}
+
+	s.index.FinishedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(s.index, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, fileIndexName)
+	if err := os.WriteFile(path+fileTempExtension, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+fileTempExtension, path)
+}
+
+// open starts the next file of the run.
+func (s *fileSink) open() error {
+	name := fmt.Sprintf("%05d.%s", len(s.index.Files), s.format)
+	path := filepath.Join(s.dir, name)
+	file, err := os.Create(path + fileTempExtension)
+	if err != nil {
+		return err
+	}
+
+	current := &logFile{
+		path:       path,
+		file:       file,
+		sum:        sha256.New(),
+		opened:     time.Now(),
+		entry:      &fileIndexFile{Name: name},
+		containers: map[string]*fileIndexContainer{},
+	}
+	current.counter = &countingWriter{writer: io.MultiWriter(file, current.sum)}
+
+	if s.format == fileFormatParquet {
+		current.encoder, err = newParquetEncoder(current.counter)
+	} else {
+		current.encoder = newNDJSONEncoder(current.counter)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+
+	s.current = current
+	return nil
+}
+
+// rotate completes the current file and adds it to the index.
+func (s *fileSink) rotate() error {
+	current := s.current
+	if current == nil {
+		return nil
+	}
+	s.current = nil
+
+	if err := current.encoder.close(); err != nil {
+		current.file.Close()
+		return err
+	}
+	if err := current.file.Sync(); err != nil {
+		current.file.Close()
+		return err
+	}
+	if err := current.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(current.path+fileTempExtension, current.path); err != nil {
+		return err
+	}
+
+	current.entry.Size = current.counter.count
+	current.entry.Sha256 = hex.EncodeToString(current.sum.Sum(nil))
+	current.entry.Containers = make([]*fileIndexContainer, 0, len(current.containers))
+	for _, container := range current.containers {
+		current.entry.Containers = append(current.entry.Containers, container)
+	}
+	sort.Slice(current.entry.Containers, func(i, j int) bool {
+		a, b := current.entry.Containers[i], current.entry.Containers[j]
+		return checkpointKey(a.Namespace, a.Pod, a.Container) < checkpointKey(b.Namespace, b.Pod, b.Container)
+	})
+	s.index.Files = append(s.index.Files, current.entry)
+	return nil
+}
+
+// size is the size of the file once the buffered entries are written.
+func (f *logFile) size() int64 {
+	return f.counter.count + f.encoder.buffered()
+}
+
+func updateFileTimes(first, last *time.Time, entries int, at time.Time) {
+	if entries == 0 || at.Before(*first) {
+		*first = at
+	}
+	if entries == 0 || at.After(*last) {
+		*last = at
+	}
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	return n, err
+}
+
+// ndjsonEncoder writes one LogEntry per line, as JSON with the namespace
+// added.
+type ndjsonEncoder struct {
+	writer *bufio.Writer
+}
+
+func newNDJSONEncoder(w io.Writer) *ndjsonEncoder {
+	return &ndjsonEncoder{writer: bufio.NewWriter(w)}
+}
+
+func (e *ndjsonEncoder) write(namespace string, entry *LogEntry) error {
+	data, err := protojson.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	ns, err := json.Marshal(namespace)
+	if err != nil {
+		return err
+	}
+
+	e.writer.WriteString(`{"namespace":`)
+	e.writer.Write(ns)
+	if fields := strings.TrimSpace(string(data[1:])); fields != "}" {
+		e.writer.WriteByte(',')
+	}
+	e.writer.Write(data[1:])
+	return e.writer.WriteByte('\n')
+}
+
+func (e *ndjsonEncoder) buffered() int64 {
+	return int64(e.writer.Buffered())
+}
+
+func (e *ndjsonEncoder) close() error {
+	return e.writer.Flush()
+}
+
+// parquetLogEntry is the row of the Parquet files.
+type parquetLogEntry struct {
+	Time         *int64 `parquet:"name=time, type=INT64, convertedtype=TIMESTAMP_MICROS, repetitiontype=OPTIONAL"`
+	Namespace    string `parquet:"name=namespace, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Pod          string `parquet:"name=pod, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Container    string `parquet:"name=container, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	ContainerID  string `parquet:"name=containerId, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	RestartCount int32  `parquet:"name=restartCount, type=INT32"`
+	Level        string `parquet:"name=level, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Message      string `parquet:"name=message, type=BYTE_ARRAY, convertedtype=UTF8"`
+	// Fields holds the structured fields as JSON, as they differ from one
+	// application to the other.
+	Fields *string `parquet:"name=fields, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+}
+
+// parquetEncoder writes snappy compressed Parquet rows, in row groups of at
+// most parquetRowGroupSize bytes.
+type parquetEncoder struct {
+	writer *writer.ParquetWriter
+}
+
+const parquetRowGroupSize = 16 * 1024 * 1024
+
+func newParquetEncoder(w io.Writer) (*parquetEncoder, error) {
+	pw, err := writer.NewParquetWriterFromWriter(w, new(parquetLogEntry), 1)
+	if err != nil {
+		return nil, err
+	}
+	pw.RowGroupSize = parquetRowGroupSize
+	return &parquetEncoder{writer: pw}, nil
+}
+
+func (e *parquetEncoder) write(namespace string, entry *LogEntry) error {
+	row := &parquetLogEntry{
+		Namespace:    namespace,
+		Pod:          entry.GetPod(),
+		Container:    entry.GetContainer(),
+		ContainerID:  entry.GetContainerId(),
+		RestartCount: entry.GetRestartCount(),
+		Message:      entry.GetMessage(),
+	}
+	if entry.GetTime() != nil {
+		at := entry.GetTime().AsTime().UnixMicro()
+		row.Time = &at
+	}
+	if entry.GetLevel() != LogLevel_LOG_LEVEL_UNKNOWN {
+		row.Level = strings.ToLower(strings.TrimPrefix(entry.GetLevel().String(), "LOG_LEVEL_"))
+	}
+	if entry.GetFields() != nil {
+		data, err := protojson.Marshal(entry.GetFields())
+		if err != nil {
+			return err
+		}
+		fields := string(data)
+		row.Fields = &fields
+	}
+	return e.writer.Write(row)
+}
+
+func (e *parquetEncoder) buffered() int64 {
+	return e.writer.Size + e.writer.ObjsSize
+}
+
+func (e *parquetEncoder) close() error {
+	return e.writer.WriteStop()
+}
@@ -0,0 +1,229 @@
+package kogger
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// testFileSink creates a file sink writing NDJSON to a temporary directory,
+// and returns the directory of its run.
+func testFileSink(t *testing.T, maxSize, maxAge string) (*fileSink, string) {
+	t.Setenv("FILE_PATH", t.TempDir())
+	t.Setenv("FILE_FORMAT", "ndjson")
+	t.Setenv("FILE_MAX_SIZE", maxSize)
+	t.Setenv("FILE_MAX_AGE", maxAge)
+
+	sink, err := newFileSink("")
+	if err != nil {
+		t.Fatalf("failed to create sink: %s", err)
+	}
+	return sink.(*fileSink), sink.(*fileSink).dir
+}
+
+func testFileLogs(pod string, seconds ...int) *Logs {
+	logs := &Logs{Namespace: "default", Pod: pod}
+	for _, second := range seconds {
+		logs.Entries = append(logs.Entries, &LogEntry{
+			Container: "app",
+			Message:   "message",
+			Time:      timestamppb.New(testCollectorTime.Add(time.Duration(second) * time.Second)),
+		})
+	}
+	return logs
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxSize string
+		maxAge  string
+		// writes are the seconds of the entries of each write, a write
+		// waiting for the previous file to expire when maxAge is short.
+		writes [][]int
+		// files are the number of entries of each file.
+		files []int
+	}{
+		{
+			name:   "single file",
+			maxAge: "1h",
+			writes: [][]int{{0, 1}, {2}},
+			files:  []int{3},
+		},
+		{
+			name:    "size",
+			maxSize: "1",
+			maxAge:  "1h",
+			writes:  [][]int{{0, 1}, {2}},
+			files:   []int{1, 1, 1},
+		},
+		{
+			name:   "age",
+			maxAge: "10ms",
+			writes: [][]int{{0, 1}, {2}, {3, 4}},
+			files:  []int{2, 1, 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sink, dir := testFileSink(t, test.maxSize, test.maxAge)
+			for i, seconds := range test.writes {
+				if i > 0 && sink.maxAge < time.Second {
+					time.Sleep(2 * sink.maxAge)
+				}
+				if err := sink.Write(context.Background(), testFileLogs("web-0", seconds...)); err != nil {
+					t.Fatalf("failed to write: %s", err)
+				}
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatalf("failed to close: %s", err)
+			}
+
+			index := readFileIndex(t, dir)
+			if len(index.Files) != len(test.files) {
+				t.Fatalf("expected %d files, got %d", len(test.files), len(index.Files))
+			}
+			for i, file := range index.Files {
+				if file.Entries != test.files[i] {
+					t.Errorf("expected %d entries in %s, got %d", test.files[i], file.Name, file.Entries)
+				}
+				if lines := countFileLines(t, filepath.Join(dir, file.Name)); lines != file.Entries {
+					t.Errorf("expected %d lines in %s, got %d", file.Entries, file.Name, lines)
+				}
+			}
+
+			temp, err := filepath.Glob(filepath.Join(dir, "*"+fileTempExtension))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(temp) > 0 {
+				t.Errorf("unexpected temporary files %v", temp)
+			}
+		})
+	}
+}
+
+func TestFileSinkTemporaryFiles(t *testing.T) {
+	sink, dir := testFileSink(t, "1", "1h")
+
+	if err := sink.Write(context.Background(), testFileLogs("web-0", 0)); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "00000.ndjson"+fileTempExtension)); err != nil {
+		t.Errorf("expected the open file to be temporary: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, fileIndexName)); !os.IsNotExist(err) {
+		t.Errorf("expected no index before close, got %v", err)
+	}
+
+	if err := sink.Write(context.Background(), testFileLogs("web-0", 1)); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "00000.ndjson")); err != nil {
+		t.Errorf("expected the rotated file to be renamed: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "00001.ndjson"+fileTempExtension)); err != nil {
+		t.Errorf("expected the next file to be temporary: %s", err)
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "00001.ndjson")); err != nil {
+		t.Errorf("expected the last file to be renamed on close: %s", err)
+	}
+}
+
+func TestFileSinkIndex(t *testing.T) {
+	sink, dir := testFileSink(t, "", "1h")
+
+	for _, logs := range []*Logs{testFileLogs("web-1", 5, 3), testFileLogs("web-0", 2, 4, 1)} {
+		if err := sink.Write(context.Background(), logs); err != nil {
+			t.Fatalf("failed to write: %s", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close: %s", err)
+	}
+
+	index := readFileIndex(t, dir)
+	if index.Run != filepath.Base(dir) || index.Format != fileFormatNDJSON || index.Entries != 5 {
+		t.Errorf("unexpected index run %q, format %q, entries %d", index.Run, index.Format, index.Entries)
+	}
+	if index.StartedAt.IsZero() || index.FinishedAt.Before(index.StartedAt) {
+		t.Errorf("unexpected index times %s to %s", index.StartedAt, index.FinishedAt)
+	}
+	if len(index.Files) != 1 {
+		t.Fatalf("expected a single file, got %d", len(index.Files))
+	}
+
+	file := index.Files[0]
+	data, err := os.ReadFile(filepath.Join(dir, file.Name))
+	if err != nil {
+		t.Fatalf("failed to read %s: %s", file.Name, err)
+	}
+	sum := sha256.Sum256(data)
+	if file.Name != "00000.ndjson" || file.Entries != 5 || file.Size != int64(len(data)) || file.Sha256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected file %+v", file)
+	}
+	if !file.FirstTime.Equal(testCollectorTime.Add(time.Second)) || !file.LastTime.Equal(testCollectorTime.Add(5*time.Second)) {
+		t.Errorf("unexpected file times %s to %s", file.FirstTime, file.LastTime)
+	}
+
+	expected := []fileIndexContainer{
+		{Namespace: "default", Pod: "web-0", Container: "app", Entries: 3, FirstTime: testCollectorTime.Add(time.Second), LastTime: testCollectorTime.Add(4 * time.Second)},
+		{Namespace: "default", Pod: "web-1", Container: "app", Entries: 2, FirstTime: testCollectorTime.Add(3 * time.Second), LastTime: testCollectorTime.Add(5 * time.Second)},
+	}
+	if len(file.Containers) != len(expected) {
+		t.Fatalf("expected %d containers, got %d", len(expected), len(file.Containers))
+	}
+	for i, container := range file.Containers {
+		if container.Namespace != expected[i].Namespace || container.Pod != expected[i].Pod || container.Container != expected[i].Container ||
+			container.Entries != expected[i].Entries || !container.FirstTime.Equal(expected[i].FirstTime) || !container.LastTime.Equal(expected[i].LastTime) {
+			t.Errorf("expected container %+v, got %+v", expected[i], *container)
+		}
+	}
+}
+
+func readFileIndex(t *testing.T, dir string) *fileIndex {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, fileIndexName))
+	if err != nil {
+		t.Fatalf("failed to read the index: %s", err)
+	}
+	index := &fileIndex{}
+	if err := json.Unmarshal(data, index); err != nil {
+		t.Fatalf("invalid index: %s", err)
+	}
+	return index
+}
+
+func countFileLines(t *testing.T, path string) int {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %s", path, err)
+	}
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := map[string]any{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Errorf("invalid line in %s: %s", path, err)
+		}
+		lines++
+	}
+	return lines
+}
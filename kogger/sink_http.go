@@ -0,0 +1,151 @@
+package kogger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxErrorBodyLength caps the part of an error response quoted in errors.
+const maxErrorBodyLength = 512
+
+// retryPolicy is the exponential backoff of the sinks pushing over HTTP.
+type retryPolicy struct {
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// newRetryPolicy reads the <prefix>_MAX_RETRIES, <prefix>_MIN_BACKOFF and
+// <prefix>_MAX_BACKOFF variables.
+func newRetryPolicy(prefix string) (retryPolicy, error) {
+	policy := retryPolicy{
+		maxRetries: 5,
+		minBackoff: 500 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+	}
+
+	var err error
+	if policy.maxRetries, err = parseIntEnv(prefix+"_MAX_RETRIES", policy.maxRetries); err != nil {
+		return policy, err
+	}
+	if backoff, err := parseDurationEnv(prefix + "_MIN_BACKOFF"); err != nil {
+		return policy, err
+	} else if backoff > 0 {
+		policy.minBackoff = backoff
+	}
+	if backoff, err := parseDurationEnv(prefix + "_MAX_BACKOFF"); err != nil {
+		return policy, err
+	} else if backoff > 0 {
+		policy.maxBackoff = backoff
+	}
+	return policy, nil
+}
+
+// backoff returns the delay before the given retry, starting at 1, with full
+// jitter.
+func (p retryPolicy) backoff(retry int) time.Duration {
+	delay := p.minBackoff << min(retry-1, 30)
+	if delay <= 0 || delay > p.maxBackoff {
+		delay = p.maxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(delay)) + 1)
+}
+
+// wait sleeps for the delay of the given retry, or the delay requested by the
+// server when shorter than the maximum backoff.
+func (p retryPolicy) wait(ctx context.Context, retry int, retryAfter time.Duration) error {
+	delay := p.backoff(retry)
+	if retryAfter > 0 {
+		delay = min(retryAfter, p.maxBackoff)
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// httpStatusError is a response with an unexpected status.
+type httpStatusError struct {
+	status     int
+	body       string
+	retryAfter time.Duration
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.status, e.body)
+}
+
+// retryable tells whether sending the same request again may succeed.
+func (e *httpStatusError) retryable() bool {
+	return e.status == http.StatusTooManyRequests || e.status >= 500
+}
+
+// doWithRetry sends the request built by newRequest, retrying on network
+// errors, throttling and server errors. It returns the body of the first
+// successful response.
+func doWithRetry(ctx context.Context, client *http.Client, policy retryPolicy, newRequest func() (*http.Request, error)) ([]byte, error) {
+	var lastErr error
+	for retry := 0; ; retry++ {
+		if retry > 0 {
+			retryAfter := time.Duration(0)
+			if statusErr, ok := lastErr.(*httpStatusError); ok {
+				retryAfter = statusErr.retryAfter
+			}
+			if err := policy.wait(ctx, retry, retryAfter); err != nil {
+				return nil, err
+			}
+		}
+
+		body, err := doRequest(ctx, client, newRequest)
+		if err == nil {
+			return body, nil
+		}
+		if statusErr, ok := err.(*httpStatusError); ok && !statusErr.retryable() {
+			return nil, err
+		}
+		if ctx.Err() != nil || retry >= policy.maxRetries {
+			return nil, fmt.Errorf("giving up after %d attempts: %s", retry+1, err)
+		}
+		lastErr = err
+	}
+}
+
+func doRequest(ctx context.Context, client *http.Client, newRequest func() (*http.Request, error)) ([]byte, error) {
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return body, nil
+	}
+
+	statusErr := &httpStatusError{
+		status: resp.StatusCode,
+		body:   string(body[:min(len(body), maxErrorBodyLength)]),
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		statusErr.retryAfter = time.Duration(seconds) * time.Second
+	}
+	return nil, statusErr
+}
@@ -0,0 +1,264 @@
+package kogger
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	kafkaEncodingJSON     = "json"
+	kafkaEncodingProtobuf = "protobuf"
+)
+
+// kafkaCompressions maps the codecs accepted in KAFKA_COMPRESSION.
+var kafkaCompressions = map[string]kgo.CompressionCodec{
+	"none":   kgo.NoCompression(),
+	"gzip":   kgo.GzipCompression(),
+	"snappy": kgo.SnappyCompression(),
+	"lz4":    kgo.Lz4Compression(),
+	"zstd":   kgo.ZstdCompression(),
+}
+
+// kafkaProducer is the part of the kafka client used by the sink, so that a
+// fake producer can stand in for the brokers.
+type kafkaProducer interface {
+	Produce(ctx context.Context, record *kgo.Record, promise func(*kgo.Record, error))
+	Flush(ctx context.Context) error
+	Close()
+}
+
+// kafkaSink publishes the collected entries to KAFKA_TOPIC, one record per
+// entry keyed by <namespace>/<pod> so that the entries of a pod stay ordered
+// in a single partition. Values are LogEntry messages encoded as JSON or
+// protobuf, the namespace, pod and container are also set as headers.
+// Records are batched by the client and produced idempotently, with acks from
+// all the in-sync replicas.
+type kafkaSink struct {
+	producer    kafkaProducer
+	topic       string
+	encoding    string
+	contentType string
+
+	lock sync.Mutex
+	// failed is the first record which could not be produced, reported on
+	// Write and Close so that the collector does not move its checkpoints.
+	failed error
+}
+
+func newKafkaSink(grpcToken string) (LogSink, error) {
+	brokers := []string{}
+	for _, broker := range strings.Split(getEnv("KAFKA_BROKERS", ""), ",") {
+		if broker = strings.TrimSpace(broker); len(broker) > 0 {
+			brokers = append(brokers, broker)
+		}
+	}
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("KAFKA_BROKERS environment variable is not set")
+	}
+	topic := getEnv("KAFKA_TOPIC", "")
+	if len(topic) == 0 {
+		return nil, fmt.Errorf("KAFKA_TOPIC environment variable is not set")
+	}
+
+	compression, ok := kafkaCompressions[strings.ToLower(getEnv("KAFKA_COMPRESSION", "snappy"))]
+	if !ok {
+		return nil, fmt.Errorf("invalid KAFKA_COMPRESSION %q, expected none, gzip, snappy, lz4 or zstd", getEnv("KAFKA_COMPRESSION", ""))
+	}
+
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(brokers...),
+		kgo.DefaultProduceTopic(topic),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+		kgo.ProducerBatchCompression(compression),
+	}
+
+	batchBytes, err := parseBytesEnv("KAFKA_BATCH_BYTES")
+	if err != nil {
+		return nil, err
+	}
+	if batchBytes > 0 {
+		opts = append(opts, kgo.ProducerBatchMaxBytes(int32(batchBytes)))
+	}
+	linger, err := parseDurationEnv("KAFKA_LINGER")
+	if err != nil {
+		return nil, err
+	}
+	if linger > 0 {
+		opts = append(opts, kgo.ProducerLinger(linger))
+	}
+	timeout, err := parseDurationEnv("KAFKA_TIMEOUT")
+	if err != nil {
+		return nil, err
+	}
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	opts = append(opts, kgo.RecordDeliveryTimeout(timeout))
+
+	if tlsConfig, err := kafkaTLSConfig(); err != nil {
+		return nil, err
+	} else if tlsConfig != nil {
+		opts = append(opts, kgo.DialTLSConfig(tlsConfig))
+	}
+	if mechanism, err := kafkaSASLMechanism(); err != nil {
+		return nil, err
+	} else if mechanism != nil {
+		opts = append(opts, kgo.SASL(mechanism))
+	}
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sink, err := newKafkaSinkWithProducer(client, topic, strings.ToLower(getEnv("KAFKA_ENCODING", kafkaEncodingJSON)))
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return sink, nil
+}
+
+// newKafkaSinkWithProducer creates a sink publishing through producer.
+func newKafkaSinkWithProducer(producer kafkaProducer, topic, encoding string) (*kafkaSink, error) {
+	sink := &kafkaSink{
+		producer: producer,
+		topic:    topic,
+		encoding: encoding,
+	}
+	switch encoding {
+	case kafkaEncodingJSON:
+		sink.contentType = "application/json"
+	case kafkaEncodingProtobuf:
+		sink.contentType = "application/x-protobuf"
+	default:
+		return nil, fmt.Errorf("invalid KAFKA_ENCODING %q, expected json or protobuf", encoding)
+	}
+	return sink, nil
+}
+
+// kafkaTLSConfig returns the TLS configuration when KAFKA_TLS is set, with the
+// CA of KAFKA_TLS_CA_FILE when set.
+func kafkaTLSConfig() (*tls.Config, error) {
+	enabled, err := strconv.ParseBool(getEnv("KAFKA_TLS", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid KAFKA_TLS: %s", err)
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	config := &tls.Config{}
+	if path := getEnv("KAFKA_TLS_CA_FILE", ""); len(path) > 0 {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate found in %s", path)
+		}
+	}
+	return config, nil
+}
+
+// kafkaSASLMechanism returns the KAFKA_SASL_MECHANISM authentication with
+// KAFKA_USERNAME and KAFKA_PASSWORD, nil when unset.
+func kafkaSASLMechanism() (sasl.Mechanism, error) {
+	username, password := getEnv("KAFKA_USERNAME", ""), getEnv("KAFKA_PASSWORD", "")
+	switch mechanism := strings.ToLower(getEnv("KAFKA_SASL_MECHANISM", "")); mechanism {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Auth{User: username, Pass: password}.AsMechanism(), nil
+	case "scram-sha-256":
+		return scram.Auth{User: username, Pass: password}.AsSha256Mechanism(), nil
+	case "scram-sha-512":
+		return scram.Auth{User: username, Pass: password}.AsSha512Mechanism(), nil
+	default:
+		return nil, fmt.Errorf("invalid KAFKA_SASL_MECHANISM %q, expected plain, scram-sha-256 or scram-sha-512", mechanism)
+	}
+}
+
+func (s *kafkaSink) Write(ctx context.Context, logs *Logs) error {
+	if err := s.err(); err != nil {
+		return err
+	}
+
+	for _, entry := range logs.GetEntries() {
+		if len(entry.GetPod()) == 0 {
+			entry = proto.Clone(entry).(*LogEntry)
+			entry.Pod = logs.GetPod()
+		}
+
+		var value []byte
+		var err error
+		if s.encoding == kafkaEncodingProtobuf {
+			value, err = proto.Marshal(entry)
+		} else {
+			value, err = protojson.Marshal(entry)
+		}
+		if err != nil {
+			return err
+		}
+
+		record := &kgo.Record{
+			Topic: s.topic,
+			Key:   []byte(logs.GetNamespace() + "/" + entry.GetPod()),
+			Value: value,
+			Headers: []kgo.RecordHeader{
+				{Key: "content-type", Value: []byte(s.contentType)},
+				{Key: "namespace", Value: []byte(logs.GetNamespace())},
+				{Key: "pod", Value: []byte(entry.GetPod())},
+				{Key: "container", Value: []byte(entry.GetContainer())},
+			},
+		}
+		if entry.GetTime() != nil {
+			record.Timestamp = entry.GetTime().AsTime()
+		}
+
+		s.producer.Produce(ctx, record, func(record *kgo.Record, err error) {
+			if err != nil {
+				s.fail(fmt.Errorf("failed to produce to kafka topic %s: %s", record.Topic, err))
+			}
+		})
+	}
+	return s.err()
+}
+
+func (s *kafkaSink) Close() error {
+	defer s.producer.Close()
+	if err := s.producer.Flush(context.Background()); err != nil {
+		return err
+	}
+	return s.err()
+}
+
+func (s *kafkaSink) fail(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.failed == nil {
+		s.failed = err
+	}
+}
+
+func (s *kafkaSink) err() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.failed
+}
@@ -0,0 +1,200 @@
+package kogger
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeKafkaProducer records the produced records, failing those of the
+// containers in fail.
+type fakeKafkaProducer struct {
+	records  []*kgo.Record
+	fail     map[string]error
+	flushErr error
+	flushed  bool
+	closed   bool
+}
+
+func (p *fakeKafkaProducer) Produce(ctx context.Context, record *kgo.Record, promise func(*kgo.Record, error)) {
+	p.records = append(p.records, record)
+	for _, header := range record.Headers {
+		if header.Key == "container" {
+			promise(record, p.fail[string(header.Value)])
+			return
+		}
+	}
+	promise(record, nil)
+}
+
+func (p *fakeKafkaProducer) Flush(ctx context.Context) error {
+	p.flushed = true
+	return p.flushErr
+}
+
+func (p *fakeKafkaProducer) Close() {
+	p.closed = true
+}
+
+func testKafkaLogs() *Logs {
+	at := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	return &Logs{
+		Namespace: "default",
+		Pod:       "web-0",
+		Entries: []*LogEntry{
+			{Time: timestamppb.New(at), Container: "app", Message: "started", Level: LogLevel_LOG_LEVEL_INFO},
+			{Time: timestamppb.New(at.Add(time.Second)), Pod: "web-1", Container: "sidecar", Message: "ready"},
+		},
+	}
+}
+
+func TestKafkaSinkRecords(t *testing.T) {
+	tests := []struct {
+		encoding    string
+		contentType string
+		decode      func([]byte, proto.Message) error
+	}{
+		{kafkaEncodingJSON, "application/json", protojson.Unmarshal},
+		{kafkaEncodingProtobuf, "application/x-protobuf", proto.Unmarshal},
+	}
+
+	for _, test := range tests {
+		t.Run(test.encoding, func(t *testing.T) {
+			producer := &fakeKafkaProducer{}
+			sink, err := newKafkaSinkWithProducer(producer, "logs", test.encoding)
+			if err != nil {
+				t.Fatalf("failed to create sink: %s", err)
+			}
+
+			logs := testKafkaLogs()
+			if err := sink.Write(context.Background(), logs); err != nil {
+				t.Fatalf("unexpected error on write: %s", err)
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatalf("unexpected error on close: %s", err)
+			}
+			if !producer.flushed || !producer.closed {
+				t.Errorf("expected the producer to be flushed and closed")
+			}
+
+			if len(producer.records) != len(logs.Entries) {
+				t.Fatalf("expected %d records, got %d", len(logs.Entries), len(producer.records))
+			}
+			for i, expected := range []struct {
+				key       string
+				pod       string
+				container string
+			}{
+				{"default/web-0", "web-0", "app"},
+				{"default/web-1", "web-1", "sidecar"},
+			} {
+				record := producer.records[i]
+				if record.Topic != "logs" {
+					t.Errorf("record %d: expected topic logs, got %q", i, record.Topic)
+				}
+				if string(record.Key) != expected.key {
+					t.Errorf("record %d: expected key %q, got %q", i, expected.key, record.Key)
+				}
+				if !record.Timestamp.Equal(logs.Entries[i].GetTime().AsTime()) {
+					t.Errorf("record %d: expected timestamp %s, got %s", i, logs.Entries[i].GetTime().AsTime(), record.Timestamp)
+				}
+
+				headers := map[string]string{}
+				for _, header := range record.Headers {
+					headers[header.Key] = string(header.Value)
+				}
+				for key, value := range map[string]string{
+					"content-type": test.contentType,
+					"namespace":    "default",
+					"pod":          expected.pod,
+					"container":    expected.container,
+				} {
+					if headers[key] != value {
+						t.Errorf("record %d: expected header %s %q, got %q", i, key, value, headers[key])
+					}
+				}
+
+				entry := &LogEntry{}
+				if err := test.decode(record.Value, entry); err != nil {
+					t.Fatalf("record %d: invalid value: %s", i, err)
+				}
+				if entry.GetPod() != expected.pod || entry.GetMessage() != logs.Entries[i].GetMessage() || entry.GetLevel() != logs.Entries[i].GetLevel() {
+					t.Errorf("record %d: unexpected value %v", i, entry)
+				}
+			}
+
+			if len(logs.Entries[0].GetPod()) > 0 {
+				t.Errorf("expected the written entries to be left unchanged")
+			}
+		})
+	}
+}
+
+func TestKafkaSinkErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		fail     map[string]error
+		flushErr error
+		failure  string
+	}{
+		{
+			name: "success",
+		},
+		{
+			name:    "produce error",
+			fail:    map[string]error{"sidecar": errors.New("record too large")},
+			failure: "failed to produce to kafka topic logs: record too large",
+		},
+		{
+			name:     "flush error",
+			flushErr: context.DeadlineExceeded,
+			failure:  context.DeadlineExceeded.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			producer := &fakeKafkaProducer{fail: test.fail, flushErr: test.flushErr}
+			sink, err := newKafkaSinkWithProducer(producer, "logs", kafkaEncodingJSON)
+			if err != nil {
+				t.Fatalf("failed to create sink: %s", err)
+			}
+
+			writeErr := sink.Write(context.Background(), testKafkaLogs())
+			if test.fail == nil && writeErr != nil {
+				t.Errorf("unexpected error on write: %s", writeErr)
+			}
+
+			err = sink.Close()
+			switch {
+			case len(test.failure) == 0 && err != nil:
+				t.Errorf("unexpected error on close: %s", err)
+			case len(test.failure) > 0 && (err == nil || !strings.Contains(err.Error(), test.failure)):
+				t.Errorf("expected an error containing %q on close, got %v", test.failure, err)
+			}
+			if !producer.closed {
+				t.Errorf("expected the producer to be closed")
+			}
+
+			if test.fail != nil {
+				if err := sink.Write(context.Background(), testKafkaLogs()); err == nil {
+					t.Errorf("expected writes to fail after a produce error")
+				}
+			}
+		})
+	}
+}
+
+func TestKafkaSinkEncoding(t *testing.T) {
+	if _, err := newKafkaSinkWithProducer(&fakeKafkaProducer{}, "logs", "avro"); err == nil {
+		t.Errorf("expected an error for an unknown encoding")
+	}
+}
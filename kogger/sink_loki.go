@@ -0,0 +1,389 @@
+package kogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/ZolaraProject/library/logger"
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// lokiPushPath is the path of the push API, added to LOKI_URL when it
+	// has no path.
+	lokiPushPath = "/loki/api/v1/push"
+
+	lokiEncodingProtobuf = "protobuf"
+	lokiEncodingJSON     = "json"
+)
+
+// lokiSink pushes the collected logs to Loki. Entries are buffered until the
+// batch is full or the sink is closed, and each batch holds one stream per set
+// of labels: namespace, pod, container and the pod labels listed in
+// LOKI_POD_LABELS. The fields of structured entries are sent along as
+// structured metadata, unless LOKI_STRUCTURED_METADATA is false. Batches
+// rejected with a retryable status are pushed again with backoff, those
+// rejected for good, such as entries too old for Loki, are logged and dropped.
+type lokiSink struct {
+	grpcToken string
+	url       string
+	tenant    string
+	username  string
+	password  string
+	encoding  string
+	podLabels []string
+	metadata  bool
+
+	client       *http.Client
+	retry        retryPolicy
+	batchEntries int
+	batchBytes   int64
+
+	streams      map[string]*lokiStream
+	pending      int
+	pendingBytes int64
+	// rejected counts the entries of the batches rejected for good, which
+	// are dropped.
+	rejected int
+	// failed is the first failed push, reported again on Close so that the
+	// collector does not move its checkpoints past the dropped entries.
+	failed error
+}
+
+// lokiStream is the batch of entries of a stream.
+type lokiStream struct {
+	labels  string
+	values  map[string]string
+	entries []lokiEntry
+}
+
+type lokiEntry struct {
+	time     time.Time
+	line     string
+	metadata [][2]string
+}
+
+func newLokiSink(grpcToken string) (LogSink, error) {
+	pushURL := getEnv("LOKI_URL", "")
+	if len(pushURL) == 0 {
+		return nil, fmt.Errorf("LOKI_URL environment variable is not set")
+	}
+	parsed, err := url.Parse(pushURL)
+	if err != nil || len(parsed.Host) == 0 {
+		return nil, fmt.Errorf("invalid LOKI_URL %q", pushURL)
+	}
+	if parsed.Path == "" || parsed.Path == "/" {
+		parsed.Path = lokiPushPath
+	}
+
+	sink := &lokiSink{
+		grpcToken: grpcToken,
+		url:       parsed.String(),
+		tenant:    getEnv("LOKI_TENANT", ""),
+		username:  getEnv("LOKI_USERNAME", ""),
+		password:  getEnv("LOKI_PASSWORD", ""),
+		encoding:  strings.ToLower(getEnv("LOKI_ENCODING", lokiEncodingProtobuf)),
+		streams:   map[string]*lokiStream{},
+	}
+	if sink.encoding != lokiEncodingProtobuf && sink.encoding != lokiEncodingJSON {
+		return nil, fmt.Errorf("invalid LOKI_ENCODING %q, expected protobuf or json", sink.encoding)
+	}
+	for _, label := range strings.Split(getEnv("LOKI_POD_LABELS", ""), ",") {
+		if label = strings.TrimSpace(label); len(label) > 0 {
+			sink.podLabels = append(sink.podLabels, label)
+		}
+	}
+
+	if sink.metadata, err = strconv.ParseBool(getEnv("LOKI_STRUCTURED_METADATA", "true")); err != nil {
+		return nil, fmt.Errorf("invalid LOKI_STRUCTURED_METADATA: %s", err)
+	}
+
+	if sink.retry, err = newRetryPolicy("LOKI"); err != nil {
+		return nil, err
+	}
+	if sink.batchEntries, err = parseIntEnv("LOKI_BATCH_SIZE", 1000); err != nil {
+		return nil, err
+	}
+	if sink.batchBytes, err = parseBytesEnv("LOKI_BATCH_BYTES"); err != nil {
+		return nil, err
+	}
+	if sink.batchBytes == 0 {
+		sink.batchBytes = 1024 * 1024
+	}
+	timeout, err := parseDurationEnv("LOKI_TIMEOUT")
+	if err != nil {
+		return nil, err
+	}
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	sink.client = &http.Client{Timeout: timeout}
+
+	return sink, nil
+}
+
+func (s *lokiSink) Write(ctx context.Context, logs *Logs) error {
+	now := time.Now()
+	for _, entry := range logs.GetEntries() {
+		pod := entry.GetPod()
+		if len(pod) == 0 {
+			pod = logs.GetPod()
+		}
+
+		values := map[string]string{
+			"namespace": logs.GetNamespace(),
+			"pod":       pod,
+			"container": entry.GetContainer(),
+		}
+		for _, label := range s.podLabels {
+			if value, ok := logs.GetPodLabels()[label]; ok {
+				values[lokiLabelName(label)] = value
+			}
+		}
+		labels := formatLokiLabels(values)
+
+		stream, ok := s.streams[labels]
+		if !ok {
+			stream = &lokiStream{labels: labels, values: values}
+			s.streams[labels] = stream
+		}
+
+		at := now
+		if entry.GetTime() != nil {
+			at = entry.GetTime().AsTime()
+		}
+		item := lokiEntry{time: at, line: entry.GetMessage()}
+		if s.metadata {
+			item.metadata = lokiMetadata(entry)
+		}
+		stream.entries = append(stream.entries, item)
+		s.pending++
+		s.pendingBytes += int64(len(item.line))
+		for _, pair := range item.metadata {
+			s.pendingBytes += int64(len(pair[0]) + len(pair[1]))
+		}
+
+		if s.pending >= s.batchEntries || s.pendingBytes >= s.batchBytes {
+			if err := s.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *lokiSink) Close() error {
+	if err := s.flush(context.Background()); err != nil {
+		return err
+	}
+	if s.rejected > 0 {
+		logger.Warn(s.grpcToken, "Loki rejected %d entries", s.rejected)
+	}
+	return s.failed
+}
+
+// flush pushes the buffered entries. They are dropped even when the push
+// fails, so that a failing Loki does not make the buffer grow unbounded. A
+// batch rejected for good is not a failure: it would be rejected again by
+// every later run, which would then never move its checkpoints.
+func (s *lokiSink) flush(ctx context.Context) error {
+	if s.pending == 0 {
+		return nil
+	}
+
+	streams := make([]*lokiStream, 0, len(s.streams))
+	for _, stream := range s.streams {
+		sort.SliceStable(stream.entries, func(i, j int) bool {
+			return stream.entries[i].time.Before(stream.entries[j].time)
+		})
+		streams = append(streams, stream)
+	}
+	sort.Slice(streams, func(i, j int) bool {
+		return streams[i].labels < streams[j].labels
+	})
+	count := s.pending
+	s.streams = map[string]*lokiStream{}
+	s.pending, s.pendingBytes = 0, 0
+
+	var body []byte
+	contentType := "application/x-protobuf"
+	if s.encoding == lokiEncodingJSON {
+		data, err := encodeLokiJSON(streams)
+		if err != nil {
+			return err
+		}
+		body, contentType = data, "application/json"
+	} else {
+		body = snappy.Encode(nil, encodeLokiProtobuf(streams))
+	}
+
+	_, err := doWithRetry(ctx, s.client, s.retry, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", contentType)
+		if len(s.tenant) > 0 {
+			req.Header.Set("X-Scope-OrgID", s.tenant)
+		}
+		if len(s.username) > 0 {
+			req.SetBasicAuth(s.username, s.password)
+		}
+		return req, nil
+	})
+	if statusErr, ok := err.(*httpStatusError); ok && !statusErr.retryable() {
+		s.rejected += count
+		logger.Warn(s.grpcToken, "Loki rejected %d entries with status %d: %s", count, statusErr.status, statusErr.body)
+		return nil
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to push %d entries to loki: %s", count, err)
+		if s.failed == nil {
+			s.failed = err
+		}
+		return err
+	}
+	return nil
+}
+
+// lokiLabelName turns a pod label such as app.kubernetes.io/name into a valid
+// Loki label name, app_kubernetes_io_name.
+func lokiLabelName(label string) string {
+	name := []byte(label)
+	for i, c := range name {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || (i > 0 && c >= '0' && c <= '9')) {
+			name[i] = '_'
+		}
+	}
+	return string(name)
+}
+
+// lokiMetadata returns the fields of a structured entry as structured
+// metadata pairs sorted by name, leaving out the field the message was taken
+// from. Values other than strings are sent as JSON.
+func lokiMetadata(entry *LogEntry) [][2]string {
+	fields := entry.GetFields().GetFields()
+	pairs := make([][2]string, 0, len(fields))
+	for name, field := range fields {
+		if slices.Contains(structuredMessageKeys, name) && field.GetStringValue() == entry.GetMessage() {
+			continue
+		}
+		value, ok := field.AsInterface().(string)
+		if !ok {
+			data, err := json.Marshal(field.AsInterface())
+			if err != nil {
+				continue
+			}
+			value = string(data)
+		}
+		pairs = append(pairs, [2]string{lokiLabelName(name), value})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i][0] < pairs[j][0]
+	})
+	return pairs
+}
+
+// formatLokiLabels formats labels the way Loki expects them in the protobuf
+// push requests, {name="value", ...} sorted by name.
+func formatLokiLabels(values map[string]string) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+strconv.Quote(values[name]))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// encodeLokiJSON encodes the streams as a JSON push request.
+func encodeLokiJSON(streams []*lokiStream) ([]byte, error) {
+	type jsonStream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][]any           `json:"values"`
+	}
+
+	request := struct {
+		Streams []jsonStream `json:"streams"`
+	}{}
+	for _, stream := range streams {
+		values := make([][]any, 0, len(stream.entries))
+		for _, entry := range stream.entries {
+			value := []any{strconv.FormatInt(entry.time.UnixNano(), 10), entry.line}
+			if len(entry.metadata) > 0 {
+				metadata := make(map[string]string, len(entry.metadata))
+				for _, pair := range entry.metadata {
+					metadata[pair[0]] = pair[1]
+				}
+				value = append(value, metadata)
+			}
+			values = append(values, value)
+		}
+		request.Streams = append(request.Streams, jsonStream{Stream: stream.values, Values: values})
+	}
+	return json.Marshal(request)
+}
+
+// encodeLokiProtobuf encodes the streams as a logproto.PushRequest:
+//
+//	PushRequest { repeated Stream streams = 1; }
+//	Stream { string labels = 1; repeated Entry entries = 2; }
+//	Entry {
+//	  google.protobuf.Timestamp timestamp = 1;
+//	  string line = 2;
+//	  repeated LabelPair structuredMetadata = 3;
+//	}
+//	LabelPair { string name = 1; string value = 2; }
+func encodeLokiProtobuf(streams []*lokiStream) []byte {
+	var request []byte
+	for _, stream := range streams {
+		var encoded []byte
+		encoded = protowire.AppendTag(encoded, 1, protowire.BytesType)
+		encoded = protowire.AppendString(encoded, stream.labels)
+		for _, entry := range stream.entries {
+			var timestamp []byte
+			timestamp = protowire.AppendTag(timestamp, 1, protowire.VarintType)
+			timestamp = protowire.AppendVarint(timestamp, uint64(entry.time.Unix()))
+			timestamp = protowire.AppendTag(timestamp, 2, protowire.VarintType)
+			timestamp = protowire.AppendVarint(timestamp, uint64(entry.time.Nanosecond()))
+
+			var encodedEntry []byte
+			encodedEntry = protowire.AppendTag(encodedEntry, 1, protowire.BytesType)
+			encodedEntry = protowire.AppendBytes(encodedEntry, timestamp)
+			encodedEntry = protowire.AppendTag(encodedEntry, 2, protowire.BytesType)
+			encodedEntry = protowire.AppendString(encodedEntry, entry.line)
+			for _, pair := range entry.metadata {
+				var encodedPair []byte
+				encodedPair = protowire.AppendTag(encodedPair, 1, protowire.BytesType)
+				encodedPair = protowire.AppendString(encodedPair, pair[0])
+				encodedPair = protowire.AppendTag(encodedPair, 2, protowire.BytesType)
+				encodedPair = protowire.AppendString(encodedPair, pair[1])
+
+				encodedEntry = protowire.AppendTag(encodedEntry, 3, protowire.BytesType)
+				encodedEntry = protowire.AppendBytes(encodedEntry, encodedPair)
+			}
+
+			encoded = protowire.AppendTag(encoded, 2, protowire.BytesType)
+			encoded = protowire.AppendBytes(encoded, encodedEntry)
+		}
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, encoded)
+	}
+	return request
+}
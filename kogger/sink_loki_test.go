@@ -0,0 +1,446 @@
+package kogger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// lokiPush is a decoded push request, one line per entry formatted as
+// <labels> <unix nano> <line> [<metadata>].
+type lokiPush []string
+
+// fakeLoki decodes the push requests, answering them with the statuses in
+// order and 204 once they are used up.
+type fakeLoki struct {
+	t *testing.T
+
+	lock     sync.Mutex
+	pushes   []lokiPush
+	statuses []int
+}
+
+func newFakeLoki(t *testing.T, statuses ...int) (*fakeLoki, *httptest.Server) {
+	fake := &fakeLoki{t: t, statuses: statuses}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeLoki) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if r.Method != http.MethodPost || r.URL.Path != lokiPushPath {
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if tenant := r.Header.Get("X-Scope-OrgID"); tenant != "team" {
+		f.t.Errorf("expected tenant team, got %q", tenant)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.t.Errorf("failed to read request: %s", err)
+		return
+	}
+
+	var push lokiPush
+	switch contentType := r.Header.Get("Content-Type"); contentType {
+	case "application/json":
+		push, err = decodeLokiJSON(body)
+	case "application/x-protobuf":
+		var data []byte
+		if data, err = snappy.Decode(nil, body); err == nil {
+			push, err = decodeLokiProtobuf(data)
+		}
+	default:
+		err = fmt.Errorf("unexpected content type %q", contentType)
+	}
+	if err != nil {
+		f.t.Errorf("invalid push request: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f.pushes = append(f.pushes, push)
+
+	if len(f.statuses) > 0 {
+		status := f.statuses[0]
+		f.statuses = f.statuses[1:]
+		w.WriteHeader(status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeLokiJSON(data []byte) (lokiPush, error) {
+	request := struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][]any           `json:"values"`
+		} `json:"streams"`
+	}{}
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, err
+	}
+
+	push := lokiPush{}
+	for _, stream := range request.Streams {
+		labels := formatLokiLabels(stream.Stream)
+		for _, value := range stream.Values {
+			if len(value) < 2 || len(value) > 3 {
+				return nil, fmt.Errorf("invalid value %v", value)
+			}
+			line := fmt.Sprintf("%s %s %s", labels, value[0], value[1])
+			if len(value) == 3 {
+				pairs := []string{}
+				for name, metadata := range value[2].(map[string]any) {
+					pairs = append(pairs, name+"="+fmt.Sprint(metadata))
+				}
+				line += " " + strings.Join(sortedStrings(pairs), ",")
+			}
+			push = append(push, line)
+		}
+	}
+	return push, nil
+}
+
+func decodeLokiProtobuf(data []byte) (lokiPush, error) {
+	push := lokiPush{}
+	for _, stream := range protoMessages(data, 1) {
+		labels := ""
+		for _, field := range protoMessages(stream, 1) {
+			labels = string(field)
+		}
+		for _, entry := range protoMessages(stream, 2) {
+			var seconds, nanos uint64
+			for _, timestamp := range protoMessages(entry, 1) {
+				seconds, nanos = protoVarint(timestamp, 1), protoVarint(timestamp, 2)
+			}
+			line := ""
+			for _, field := range protoMessages(entry, 2) {
+				line = string(field)
+			}
+			formatted := fmt.Sprintf("%s %d %s", labels, seconds*uint64(time.Second)+nanos, line)
+
+			pairs := []string{}
+			for _, pair := range protoMessages(entry, 3) {
+				name, value := protoMessages(pair, 1), protoMessages(pair, 2)
+				if len(name) != 1 || len(value) != 1 {
+					return nil, fmt.Errorf("invalid structured metadata %x", pair)
+				}
+				pairs = append(pairs, string(name[0])+"="+string(value[0]))
+			}
+			if len(pairs) > 0 {
+				formatted += " " + strings.Join(sortedStrings(pairs), ",")
+			}
+			push = append(push, formatted)
+		}
+	}
+	return push, nil
+}
+
+// protoMessages returns the bytes fields of data with the given number.
+func protoMessages(data []byte, number protowire.Number) [][]byte {
+	fields := [][]byte{}
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil
+		}
+		data = data[n:]
+		if typ == protowire.BytesType && num == number {
+			value, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil
+			}
+			fields = append(fields, value)
+			data = data[n:]
+			continue
+		}
+		if n = protowire.ConsumeFieldValue(num, typ, data); n < 0 {
+			return nil
+		}
+		data = data[n:]
+	}
+	return fields
+}
+
+// protoVarint returns the varint field of data with the given number.
+func protoVarint(data []byte, number protowire.Number) uint64 {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return 0
+		}
+		data = data[n:]
+		if typ == protowire.VarintType && num == number {
+			value, _ := protowire.ConsumeVarint(data)
+			return value
+		}
+		if n = protowire.ConsumeFieldValue(num, typ, data); n < 0 {
+			return 0
+		}
+		data = data[n:]
+	}
+	return 0
+}
+
+func sortedStrings(values []string) []string {
+	sort.Strings(values)
+	return values
+}
+
+func (f *fakeLoki) requests() []lokiPush {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]lokiPush{}, f.pushes...)
+}
+
+func setLokiEnv(t *testing.T, url string) {
+	setSinkEnv(t, "LOKI", map[string]string{
+		"URL":        url,
+		"TENANT":     "team",
+		"POD_LABELS": "app.kubernetes.io/name",
+	})
+}
+
+// testLokiLogs returns out of order entries of two containers, one of them
+// structured.
+func testLokiLogs(t *testing.T) *Logs {
+	fields, err := structpb.NewStruct(map[string]any{"msg": "ready", "status": 200.0, "user": "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := testSinkLogs()
+	logs.PodLabels = map[string]string{"app.kubernetes.io/name": "web", "team": "core"}
+	logs.Entries = []*LogEntry{
+		testSinkEntry("app", "second", testCollectorTime.Add(time.Second)),
+		testSinkEntry("app", "first", testCollectorTime),
+		testSinkEntry("sidecar", "ready", testCollectorTime),
+	}
+	logs.Entries[2].Fields = fields
+	return logs
+}
+
+func TestLokiSinkEncoding(t *testing.T) {
+	at := strconv.FormatInt(testCollectorTime.UnixNano(), 10)
+	later := strconv.FormatInt(testCollectorTime.Add(time.Second).UnixNano(), 10)
+	app := `{app_kubernetes_io_name="web", container="app", namespace="default", pod="web-0"}`
+	sidecar := `{app_kubernetes_io_name="web", container="sidecar", namespace="default", pod="web-0"}`
+
+	tests := []struct {
+		encoding string
+		metadata string
+		expected lokiPush
+	}{
+		{
+			encoding: lokiEncodingProtobuf,
+			metadata: "true",
+			expected: lokiPush{app + " " + at + " first", app + " " + later + " second", sidecar + " " + at + " ready status=200,user=alice"},
+		},
+		{
+			encoding: lokiEncodingJSON,
+			metadata: "true",
+			expected: lokiPush{app + " " + at + " first", app + " " + later + " second", sidecar + " " + at + " ready status=200,user=alice"},
+		},
+		{
+			encoding: lokiEncodingProtobuf,
+			metadata: "false",
+			expected: lokiPush{app + " " + at + " first", app + " " + later + " second", sidecar + " " + at + " ready"},
+		},
+		{
+			encoding: lokiEncodingJSON,
+			metadata: "false",
+			expected: lokiPush{app + " " + at + " first", app + " " + later + " second", sidecar + " " + at + " ready"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.encoding+" metadata "+test.metadata, func(t *testing.T) {
+			fake, server := newFakeLoki(t)
+			setLokiEnv(t, server.URL)
+			t.Setenv("LOKI_ENCODING", test.encoding)
+			t.Setenv("LOKI_STRUCTURED_METADATA", test.metadata)
+
+			sink, err := newLokiSink("")
+			if err != nil {
+				t.Fatalf("failed to create sink: %s", err)
+			}
+			if err := sink.Write(context.Background(), testLokiLogs(t)); err != nil {
+				t.Fatalf("unexpected error on write: %s", err)
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatalf("unexpected error on close: %s", err)
+			}
+
+			pushes := fake.requests()
+			if len(pushes) != 1 {
+				t.Fatalf("expected a single push, got %d", len(pushes))
+			}
+			if strings.Join(pushes[0], "\n") != strings.Join(test.expected, "\n") {
+				t.Errorf("expected push\n%s\ngot\n%s", strings.Join(test.expected, "\n"), strings.Join(pushes[0], "\n"))
+			}
+		})
+	}
+}
+
+func TestLokiSinkBatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		batchSize  string
+		batchBytes string
+		// sizes are the number of entries of each push.
+		sizes []int
+	}{
+		{name: "single batch", sizes: []int{3}},
+		{name: "entries limit", batchSize: "2", sizes: []int{2, 1}},
+		{name: "single entry batches", batchSize: "1", sizes: []int{1, 1, 1}},
+		// Lines are 5 or 6 bytes long, the last one has 18 bytes of
+		// structured metadata.
+		{name: "bytes limit", batchBytes: "11", sizes: []int{2, 1}},
+		{name: "bytes limit per entry", batchBytes: "5", sizes: []int{1, 1, 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, server := newFakeLoki(t)
+			setLokiEnv(t, server.URL)
+			t.Setenv("LOKI_BATCH_SIZE", test.batchSize)
+			t.Setenv("LOKI_BATCH_BYTES", test.batchBytes)
+
+			sink, err := newLokiSink("")
+			if err != nil {
+				t.Fatalf("failed to create sink: %s", err)
+			}
+			if err := sink.Write(context.Background(), testLokiLogs(t)); err != nil {
+				t.Fatalf("unexpected error on write: %s", err)
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatalf("unexpected error on close: %s", err)
+			}
+
+			sizes := []int{}
+			for _, push := range fake.requests() {
+				sizes = append(sizes, len(push))
+			}
+			if fmt.Sprint(sizes) != fmt.Sprint(test.sizes) {
+				t.Errorf("expected pushes of %v entries, got %v", test.sizes, sizes)
+			}
+		})
+	}
+}
+
+func TestLokiSinkRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		pushes   int
+		failure  string
+	}{
+		{name: "success", pushes: 1},
+		{name: "throttled", statuses: []int{http.StatusTooManyRequests}, pushes: 2},
+		{name: "server errors", statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}, pushes: 3},
+		{
+			name:     "retries exhausted",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			pushes:   3,
+			failure:  "failed to push 3 entries to loki: giving up after 3 attempts",
+		},
+		{name: "rejected", statuses: []int{http.StatusBadRequest}, pushes: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, server := newFakeLoki(t, test.statuses...)
+			setLokiEnv(t, server.URL)
+
+			sink, err := newLokiSink("")
+			if err != nil {
+				t.Fatalf("failed to create sink: %s", err)
+			}
+			if err := sink.Write(context.Background(), testLokiLogs(t)); err != nil {
+				t.Fatalf("unexpected error on write: %s", err)
+			}
+
+			err = sink.Close()
+			switch {
+			case len(test.failure) == 0 && err != nil:
+				t.Errorf("unexpected error on close: %s", err)
+			case len(test.failure) > 0 && (err == nil || !strings.Contains(err.Error(), test.failure)):
+				t.Errorf("expected an error containing %q on close, got %v", test.failure, err)
+			}
+			if pushes := fake.requests(); len(pushes) != test.pushes {
+				t.Errorf("expected %d pushes, got %d", test.pushes, len(pushes))
+			}
+		})
+	}
+}
+
+// TestLokiSinkFailedBatch checks that a batch failing during Write is still
+// reported on Close, once the following batches succeeded.
+func TestLokiSinkFailedBatch(t *testing.T) {
+	fake, server := newFakeLoki(t, http.StatusServiceUnavailable)
+	setLokiEnv(t, server.URL)
+	t.Setenv("LOKI_BATCH_SIZE", "2")
+	t.Setenv("LOKI_MAX_RETRIES", "0")
+
+	sink, err := newLokiSink("")
+	if err != nil {
+		t.Fatalf("failed to create sink: %s", err)
+	}
+	if err := sink.Write(context.Background(), testLokiLogs(t)); err == nil {
+		t.Errorf("expected an error on write")
+	}
+	if err := sink.Write(context.Background(), testLokiLogs(t)); err != nil {
+		t.Errorf("unexpected error on write: %s", err)
+	}
+	if err := sink.Close(); err == nil || !strings.Contains(err.Error(), "failed to push 2 entries") {
+		t.Errorf("expected the failed push to be reported on close, got %v", err)
+	}
+	if pushes := fake.requests(); len(pushes) != 3 {
+		t.Errorf("expected 3 pushes, got %d", len(pushes))
+	}
+}
+
+// TestLokiSinkRejectedBatch checks that a batch rejected for good is dropped
+// without failing the following writes nor Close.
+func TestLokiSinkRejectedBatch(t *testing.T) {
+	fake, server := newFakeLoki(t, http.StatusBadRequest)
+	setLokiEnv(t, server.URL)
+	t.Setenv("LOKI_BATCH_SIZE", "2")
+
+	sink, err := newLokiSink("")
+	if err != nil {
+		t.Fatalf("failed to create sink: %s", err)
+	}
+	for range 2 {
+		if err := sink.Write(context.Background(), testLokiLogs(t)); err != nil {
+			t.Errorf("unexpected error on write: %s", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Errorf("unexpected error on close: %s", err)
+	}
+	if pushes := fake.requests(); len(pushes) != 3 {
+		t.Errorf("expected 3 pushes, got %d", len(pushes))
+	}
+	if sink.(*lokiSink).rejected != 2 {
+		t.Errorf("expected 2 rejected entries, got %d", sink.(*lokiSink).rejected)
+	}
+}
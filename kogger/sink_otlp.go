@@ -0,0 +1,389 @@
+package kogger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	logger "github.com/ZolaraProject/library/logger"
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcgzip "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	otlpProtocolGRPC = "grpc"
+	otlpProtocolHTTP = "http"
+	// otlpLogsPath is the path of the OTLP/HTTP logs endpoint, added to
+	// OTLP_ENDPOINT when it has no path.
+	otlpLogsPath = "/v1/logs"
+	// otlpScopeName names the instrumentation scope of the exported records.
+	otlpScopeName = "kogger-service"
+)
+
+// otlpSeverities maps the levels to the OTLP severity numbers.
+var otlpSeverities = map[LogLevel]logspb.SeverityNumber{
+	LogLevel_LOG_LEVEL_TRACE: logspb.SeverityNumber_SEVERITY_NUMBER_TRACE,
+	LogLevel_LOG_LEVEL_DEBUG: logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG,
+	LogLevel_LOG_LEVEL_INFO:  logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+	LogLevel_LOG_LEVEL_WARN:  logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+	LogLevel_LOG_LEVEL_ERROR: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+	LogLevel_LOG_LEVEL_FATAL: logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+}
+
+// otlpSink exports the collected logs as OTLP log records, over gRPC or
+// HTTP. Each container is a resource, described with the k8s.* attributes.
+type otlpSink struct {
+	grpcToken   string
+	protocol    string
+	endpoint    string
+	headers     map[string]string
+	compression bool
+	timeout     time.Duration
+	retry       retryPolicy
+	batchSize   int
+
+	conn       *grpc.ClientConn
+	grpcClient collogspb.LogsServiceClient
+	httpClient *http.Client
+
+	resources map[string]*logspb.ResourceLogs
+	pending   int
+	// failed is the first failed export, reported again on Close so that
+	// the collector does not move its checkpoints past the dropped records.
+	failed error
+}
+
+func newOtlpSink(grpcToken string) (LogSink, error) {
+	sink := &otlpSink{
+		grpcToken: grpcToken,
+		protocol:  strings.ToLower(getEnv("OTLP_PROTOCOL", otlpProtocolGRPC)),
+		headers:   map[string]string{},
+		resources: map[string]*logspb.ResourceLogs{},
+	}
+
+	for _, header := range strings.Split(getEnv("OTLP_HEADERS", ""), ",") {
+		if header = strings.TrimSpace(header); len(header) == 0 {
+			continue
+		}
+		key, value, ok := strings.Cut(header, "=")
+		if !ok || len(strings.TrimSpace(key)) == 0 {
+			return nil, fmt.Errorf("invalid OTLP_HEADERS entry %q, expected <key>=<value>", header)
+		}
+		sink.headers[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+
+	switch compression := getEnv("OTLP_COMPRESSION", "gzip"); compression {
+	case "gzip":
+		sink.compression = true
+	case "none", "":
+	default:
+		return nil, fmt.Errorf("invalid OTLP_COMPRESSION %q, expected gzip or none", compression)
+	}
+
+	var err error
+	if sink.retry, err = newRetryPolicy("OTLP"); err != nil {
+		return nil, err
+	}
+	if sink.batchSize, err = parseIntEnv("OTLP_BATCH_SIZE", 1000); err != nil {
+		return nil, err
+	}
+	if sink.timeout, err = parseDurationEnv("OTLP_TIMEOUT"); err != nil {
+		return nil, err
+	}
+	if sink.timeout == 0 {
+		sink.timeout = 10 * time.Second
+	}
+	plaintext := getEnv("OTLP_INSECURE", "false") == "true"
+
+	switch sink.protocol {
+	case otlpProtocolGRPC:
+		sink.endpoint = getEnv("OTLP_ENDPOINT", "localhost:4317")
+		creds := credentials.NewTLS(&tls.Config{})
+		if plaintext {
+			creds = insecure.NewCredentials()
+		}
+		if sink.conn, err = grpc.NewClient(sink.endpoint, grpc.WithTransportCredentials(creds)); err != nil {
+			return nil, err
+		}
+		sink.grpcClient = collogspb.NewLogsServiceClient(sink.conn)
+	case otlpProtocolHTTP:
+		endpoint := getEnv("OTLP_ENDPOINT", "http://localhost:4318")
+		parsed, err := url.Parse(endpoint)
+		if err != nil || len(parsed.Host) == 0 {
+			return nil, fmt.Errorf("invalid OTLP_ENDPOINT %q", endpoint)
+		}
+		if parsed.Path == "" || parsed.Path == "/" {
+			parsed.Path = otlpLogsPath
+		}
+		sink.endpoint = parsed.String()
+		sink.httpClient = &http.Client{Timeout: sink.timeout}
+	default:
+		return nil, fmt.Errorf("invalid OTLP_PROTOCOL %q, expected grpc or http", sink.protocol)
+	}
+
+	return sink, nil
+}
+
+func (s *otlpSink) Write(ctx context.Context, logs *Logs) error {
+	observed := uint64(time.Now().UnixNano())
+	for _, entry := range logs.GetEntries() {
+		pod := entry.GetPod()
+		if len(pod) == 0 {
+			pod = logs.GetPod()
+		}
+
+		key := strings.Join([]string{logs.GetNamespace(), pod, entry.GetContainer(), entry.GetContainerId()}, "/")
+		resource, ok := s.resources[key]
+		if !ok {
+			resource = &logspb.ResourceLogs{
+				Resource: otlpResource(logs, pod, entry),
+				ScopeLogs: []*logspb.ScopeLogs{{
+					Scope: &commonpb.InstrumentationScope{Name: otlpScopeName},
+				}},
+			}
+			s.resources[key] = resource
+		}
+
+		record := &logspb.LogRecord{
+			ObservedTimeUnixNano: observed,
+			Body:                 otlpString(entry.GetMessage()),
+		}
+		if entry.GetTime() != nil {
+			record.TimeUnixNano = uint64(entry.GetTime().AsTime().UnixNano())
+		}
+		if severity, ok := otlpSeverities[entry.GetLevel()]; ok {
+			record.SeverityNumber = severity
+			record.SeverityText = strings.TrimPrefix(entry.GetLevel().String(), "LOG_LEVEL_")
+		}
+		for name, value := range entry.GetFields().GetFields() {
+			record.Attributes = append(record.Attributes, &commonpb.KeyValue{Key: name, Value: otlpValue(value)})
+		}
+		sort.Slice(record.Attributes, func(i, j int) bool {
+			return record.Attributes[i].Key < record.Attributes[j].Key
+		})
+
+		resource.ScopeLogs[0].LogRecords = append(resource.ScopeLogs[0].LogRecords, record)
+		s.pending++
+		if s.pending >= s.batchSize {
+			if err := s.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *otlpSink) Close() error {
+	err := s.flush(context.Background())
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	if err != nil {
+		return err
+	}
+	return s.failed
+}
+
+// otlpResource describes a container with the k8s.* and container.id
+// resource attributes, along with the pod labels.
+func otlpResource(logs *Logs, pod string, entry *LogEntry) *resourcepb.Resource {
+	resource := &resourcepb.Resource{
+		Attributes: []*commonpb.KeyValue{
+			{Key: "k8s.namespace.name", Value: otlpString(logs.GetNamespace())},
+			{Key: "k8s.pod.name", Value: otlpString(pod)},
+			{Key: "k8s.container.name", Value: otlpString(entry.GetContainer())},
+			{Key: "k8s.container.restart_count", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(entry.GetRestartCount())}}},
+		},
+	}
+	if id := entry.GetContainerId(); len(id) > 0 {
+		// Drop the runtime scheme, such as containerd://.
+		if _, after, ok := strings.Cut(id, "://"); ok {
+			id = after
+		}
+		resource.Attributes = append(resource.Attributes, &commonpb.KeyValue{Key: "container.id", Value: otlpString(id)})
+	}
+
+	labels := []string{}
+	for label := range logs.GetPodLabels() {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		resource.Attributes = append(resource.Attributes, &commonpb.KeyValue{Key: "k8s.pod.label." + label, Value: otlpString(logs.GetPodLabels()[label])})
+	}
+	return resource
+}
+
+func otlpString(value string) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}
+}
+
+// otlpValue converts a structured field to an OTLP value.
+func otlpValue(value *structpb.Value) *commonpb.AnyValue {
+	switch kind := value.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return otlpString(kind.StringValue)
+	case *structpb.Value_BoolValue:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: kind.BoolValue}}
+	case *structpb.Value_NumberValue:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: kind.NumberValue}}
+	case *structpb.Value_ListValue:
+		values := []*commonpb.AnyValue{}
+		for _, item := range kind.ListValue.GetValues() {
+			values = append(values, otlpValue(item))
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}}
+	case *structpb.Value_StructValue:
+		values := []*commonpb.KeyValue{}
+		for name, item := range kind.StructValue.GetFields() {
+			values = append(values, &commonpb.KeyValue{Key: name, Value: otlpValue(item)})
+		}
+		sort.Slice(values, func(i, j int) bool {
+			return values[i].Key < values[j].Key
+		})
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: values}}}
+	default:
+		return &commonpb.AnyValue{}
+	}
+}
+
+// flush exports the buffered records, which are dropped even when the export
+// fails.
+func (s *otlpSink) flush(ctx context.Context) error {
+	if s.pending == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(s.resources))
+	for key := range s.resources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	request := &collogspb.ExportLogsServiceRequest{}
+	for _, key := range keys {
+		request.ResourceLogs = append(request.ResourceLogs, s.resources[key])
+	}
+	count := s.pending
+	s.resources = map[string]*logspb.ResourceLogs{}
+	s.pending = 0
+
+	var response *collogspb.ExportLogsServiceResponse
+	var err error
+	if s.protocol == otlpProtocolGRPC {
+		response, err = s.exportGRPC(ctx, request)
+	} else {
+		response, err = s.exportHTTP(ctx, request)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to export %d records over otlp: %s", count, err)
+		if s.failed == nil {
+			s.failed = err
+		}
+		return err
+	}
+
+	if partial := response.GetPartialSuccess(); partial.GetRejectedLogRecords() > 0 {
+		logger.Warn(s.grpcToken, "OTLP endpoint rejected %d of %d records: %s", partial.GetRejectedLogRecords(), count, partial.GetErrorMessage())
+	}
+	return nil
+}
+
+// exportGRPC sends the request, retrying on the status codes the OTLP
+// specification marks as retryable.
+func (s *otlpSink) exportGRPC(ctx context.Context, request *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	pairs := []string{}
+	for key, value := range s.headers {
+		pairs = append(pairs, key, value)
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+
+	opts := []grpc.CallOption{}
+	if s.compression {
+		opts = append(opts, grpc.UseCompressor(grpcgzip.Name))
+	}
+
+	for retry := 0; ; retry++ {
+		if retry > 0 {
+			if err := s.retry.wait(ctx, retry, 0); err != nil {
+				return nil, err
+			}
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		response, err := s.grpcClient.Export(callCtx, request, opts...)
+		cancel()
+		if err == nil {
+			return response, nil
+		}
+
+		switch status.Code(err) {
+		case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded, codes.OutOfRange, codes.DataLoss:
+		default:
+			return nil, err
+		}
+		if ctx.Err() != nil || retry >= s.retry.maxRetries {
+			return nil, fmt.Errorf("giving up after %d attempts: %s", retry+1, err)
+		}
+	}
+}
+
+func (s *otlpSink) exportHTTP(ctx context.Context, request *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	body, err := proto.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	if s.compression {
+		var compressed bytes.Buffer
+		compressor := gzip.NewWriter(&compressed)
+		if _, err := compressor.Write(body); err != nil {
+			return nil, err
+		}
+		if err := compressor.Close(); err != nil {
+			return nil, err
+		}
+		body = compressed.Bytes()
+	}
+
+	data, err := doWithRetry(ctx, s.httpClient, s.retry, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		if s.compression {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		for key, value := range s.headers {
+			req.Header.Set(key, value)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &collogspb.ExportLogsServiceResponse{}
+	if err := proto.Unmarshal(data, response); err != nil {
+		return nil, fmt.Errorf("invalid export response: %s", err)
+	}
+	return response, nil
+}
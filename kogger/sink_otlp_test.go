@@ -0,0 +1,431 @@
+package kogger
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeOtlp is an OTLP logs receiver, over HTTP or gRPC, answering the
+// successive exports with the statuses or codes given, and with success once
+// they are exhausted.
+type fakeOtlp struct {
+	collogspb.UnimplementedLogsServiceServer
+	t *testing.T
+
+	lock     sync.Mutex
+	requests []*collogspb.ExportLogsServiceRequest
+	headers  []string
+	statuses []int
+	codes    []codes.Code
+	// rejected is the number of records reported as rejected in the
+	// successful responses.
+	rejected int64
+}
+
+// newFakeOtlp starts the receiver and returns its endpoint.
+func newFakeOtlp(t *testing.T, protocol string) (*fakeOtlp, string) {
+	fake := &fakeOtlp{t: t}
+
+	if protocol == otlpProtocolHTTP {
+		server := httptest.NewServer(fake)
+		t.Cleanup(server.Close)
+		return fake, server.URL
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	server := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(server, fake)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return fake, listener.Addr().String()
+}
+
+func (f *fakeOtlp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if r.Method != http.MethodPost || r.URL.Path != otlpLogsPath || r.Header.Get("Content-Type") != "application/x-protobuf" {
+		f.t.Errorf("unexpected request %s %s of type %q", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+	}
+	var reader io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		decompressor, err := gzip.NewReader(r.Body)
+		if err != nil {
+			f.t.Errorf("invalid gzip body: %s", err)
+			return
+		}
+		reader = decompressor
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		f.t.Errorf("failed to read request: %s", err)
+		return
+	}
+	request := &collogspb.ExportLogsServiceRequest{}
+	if err := proto.Unmarshal(body, request); err != nil {
+		f.t.Errorf("invalid export request: %s", err)
+	}
+	f.requests = append(f.requests, request)
+	f.headers = append(f.headers, r.Header.Get("Authorization"))
+
+	if len(f.statuses) > 0 {
+		code := f.statuses[0]
+		f.statuses = f.statuses[1:]
+		if code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+	}
+	data, err := proto.Marshal(f.response())
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Write(data)
+}
+
+func (f *fakeOtlp) Export(ctx context.Context, request *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.requests = append(f.requests, request)
+	md, _ := metadata.FromIncomingContext(ctx)
+	f.headers = append(f.headers, strings.Join(md.Get("authorization"), ","))
+
+	if len(f.codes) > 0 {
+		code := f.codes[0]
+		f.codes = f.codes[1:]
+		if code != codes.OK {
+			return nil, status.Error(code, "test error")
+		}
+	}
+	return f.response(), nil
+}
+
+func (f *fakeOtlp) response() *collogspb.ExportLogsServiceResponse {
+	response := &collogspb.ExportLogsServiceResponse{}
+	if f.rejected > 0 {
+		response.PartialSuccess = &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: f.rejected, ErrorMessage: "too old"}
+	}
+	return response
+}
+
+// records returns the bodies of the records of each export.
+func (f *fakeOtlp) records() [][]string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	exports := [][]string{}
+	for _, request := range f.requests {
+		bodies := []string{}
+		for _, resource := range request.GetResourceLogs() {
+			for _, scope := range resource.GetScopeLogs() {
+				for _, record := range scope.GetLogRecords() {
+					bodies = append(bodies, record.GetBody().GetStringValue())
+				}
+			}
+		}
+		exports = append(exports, bodies)
+	}
+	return exports
+}
+
+func setOtlpEnv(t *testing.T, protocol, endpoint string) {
+	setSinkEnv(t, "OTLP", map[string]string{
+		"PROTOCOL": protocol,
+		"ENDPOINT": endpoint,
+		"INSECURE": "true",
+		"HEADERS":  "Authorization=Bearer secret",
+	})
+}
+
+// otlpAttributes returns the attributes as strings keyed by name.
+func otlpAttributes(attributes []*commonpb.KeyValue) map[string]string {
+	values := map[string]string{}
+	for _, attribute := range attributes {
+		switch value := attribute.GetValue().GetValue().(type) {
+		case *commonpb.AnyValue_StringValue:
+			values[attribute.GetKey()] = value.StringValue
+		case *commonpb.AnyValue_IntValue:
+			values[attribute.GetKey()] = fmt.Sprint(value.IntValue)
+		case *commonpb.AnyValue_DoubleValue:
+			values[attribute.GetKey()] = fmt.Sprint(value.DoubleValue)
+		case *commonpb.AnyValue_BoolValue:
+			values[attribute.GetKey()] = fmt.Sprint(value.BoolValue)
+		default:
+			values[attribute.GetKey()] = fmt.Sprintf("%T", value)
+		}
+	}
+	return values
+}
+
+func TestOtlpSinkRecords(t *testing.T) {
+	for _, protocol := range []string{otlpProtocolGRPC, otlpProtocolHTTP} {
+		t.Run(protocol, func(t *testing.T) {
+			fake, endpoint := newFakeOtlp(t, protocol)
+			setOtlpEnv(t, protocol, endpoint)
+
+			fields, err := structpb.NewStruct(map[string]any{"msg": "disk full", "free": 0.0, "retry": true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			logs := &Logs{
+				Namespace: "default",
+				Pod:       "web-0",
+				PodLabels: map[string]string{"app": "web"},
+				Entries: []*LogEntry{
+					{Time: timestamppb.New(testCollectorTime), Container: "app", ContainerId: "containerd://abc", RestartCount: 1, Message: "started", Level: LogLevel_LOG_LEVEL_INFO},
+					{Time: timestamppb.New(testCollectorTime.Add(time.Second)), Container: "app", ContainerId: "containerd://abc", RestartCount: 1, Message: "disk full", Level: LogLevel_LOG_LEVEL_ERROR, Fields: fields},
+					{Container: "sidecar", Message: "no level"},
+				},
+			}
+
+			sink, err := newOtlpSink("")
+			if err != nil {
+				t.Fatalf("failed to create sink: %s", err)
+			}
+			if err := sink.Write(context.Background(), logs); err != nil {
+				t.Fatalf("unexpected error on write: %s", err)
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatalf("unexpected error on close: %s", err)
+			}
+
+			if len(fake.requests) != 1 {
+				t.Fatalf("expected a single export, got %d", len(fake.requests))
+			}
+			if fake.headers[0] != "Bearer secret" {
+				t.Errorf("unexpected authorization %q", fake.headers[0])
+			}
+
+			resources := fake.requests[0].GetResourceLogs()
+			if len(resources) != 2 {
+				t.Fatalf("expected a resource per container, got %d", len(resources))
+			}
+			expected := []map[string]string{
+				{
+					"k8s.namespace.name":          "default",
+					"k8s.pod.name":                "web-0",
+					"k8s.container.name":          "app",
+					"k8s.container.restart_count": "1",
+					"container.id":                "abc",
+					"k8s.pod.label.app":           "web",
+				},
+				{
+					"k8s.namespace.name":          "default",
+					"k8s.pod.name":                "web-0",
+					"k8s.container.name":          "sidecar",
+					"k8s.container.restart_count": "0",
+					"k8s.pod.label.app":           "web",
+				},
+			}
+			for i, resource := range resources {
+				if attributes := otlpAttributes(resource.GetResource().GetAttributes()); fmt.Sprint(attributes) != fmt.Sprint(expected[i]) {
+					t.Errorf("expected resource attributes %v, got %v", expected[i], attributes)
+				}
+				if scope := resource.GetScopeLogs()[0].GetScope(); scope.GetName() != otlpScopeName {
+					t.Errorf("unexpected scope %v", scope)
+				}
+			}
+
+			records := append(resources[0].GetScopeLogs()[0].GetLogRecords(), resources[1].GetScopeLogs()[0].GetLogRecords()...)
+			expectedRecords := []struct {
+				body       string
+				time       time.Time
+				number     logspb.SeverityNumber
+				text       string
+				attributes string
+			}{
+				{"started", testCollectorTime, logspb.SeverityNumber_SEVERITY_NUMBER_INFO, "INFO", "map[]"},
+				{"disk full", testCollectorTime.Add(time.Second), logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, "ERROR", "map[free:0 msg:disk full retry:true]"},
+				{"no level", time.Unix(0, 0), logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, "", "map[]"},
+			}
+			if len(records) != len(expectedRecords) {
+				t.Fatalf("expected %d records, got %d", len(expectedRecords), len(records))
+			}
+			for i, record := range records {
+				want := expectedRecords[i]
+				if record.GetBody().GetStringValue() != want.body || record.GetSeverityNumber() != want.number || record.GetSeverityText() != want.text {
+					t.Errorf("expected record %q of severity %s %q, got %q of %s %q", want.body, want.number, want.text, record.GetBody().GetStringValue(), record.GetSeverityNumber(), record.GetSeverityText())
+				}
+				if at := time.Unix(0, int64(record.GetTimeUnixNano())); !at.Equal(want.time) || record.GetObservedTimeUnixNano() == 0 {
+					t.Errorf("unexpected times %s and %d of %q", at, record.GetObservedTimeUnixNano(), want.body)
+				}
+				if attributes := fmt.Sprint(otlpAttributes(record.GetAttributes())); attributes != want.attributes {
+					t.Errorf("expected attributes %s, got %s", want.attributes, attributes)
+				}
+			}
+		})
+	}
+}
+
+func TestOtlpSinkSeverities(t *testing.T) {
+	tests := []struct {
+		level  LogLevel
+		number logspb.SeverityNumber
+		text   string
+	}{
+		{LogLevel_LOG_LEVEL_UNKNOWN, logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, ""},
+		{LogLevel_LOG_LEVEL_TRACE, logspb.SeverityNumber_SEVERITY_NUMBER_TRACE, "TRACE"},
+		{LogLevel_LOG_LEVEL_DEBUG, logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG, "DEBUG"},
+		{LogLevel_LOG_LEVEL_INFO, logspb.SeverityNumber_SEVERITY_NUMBER_INFO, "INFO"},
+		{LogLevel_LOG_LEVEL_WARN, logspb.SeverityNumber_SEVERITY_NUMBER_WARN, "WARN"},
+		{LogLevel_LOG_LEVEL_ERROR, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, "ERROR"},
+		{LogLevel_LOG_LEVEL_FATAL, logspb.SeverityNumber_SEVERITY_NUMBER_FATAL, "FATAL"},
+	}
+
+	fake, endpoint := newFakeOtlp(t, otlpProtocolHTTP)
+	setOtlpEnv(t, otlpProtocolHTTP, endpoint)
+	sink, err := newOtlpSink("")
+	if err != nil {
+		t.Fatalf("failed to create sink: %s", err)
+	}
+	logs := &Logs{Namespace: "default", Pod: "web-0"}
+	for _, test := range tests {
+		logs.Entries = append(logs.Entries, &LogEntry{Container: "app", Message: test.level.String(), Level: test.level})
+	}
+	if err := sink.Write(context.Background(), logs); err != nil {
+		t.Fatalf("unexpected error on write: %s", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unexpected error on close: %s", err)
+	}
+
+	records := fake.requests[0].GetResourceLogs()[0].GetScopeLogs()[0].GetLogRecords()
+	if len(records) != len(tests) {
+		t.Fatalf("expected %d records, got %d", len(tests), len(records))
+	}
+	for i, test := range tests {
+		if records[i].GetSeverityNumber() != test.number || records[i].GetSeverityText() != test.text {
+			t.Errorf("expected severity %s %q for %s, got %s %q", test.number, test.text, test.level, records[i].GetSeverityNumber(), records[i].GetSeverityText())
+		}
+	}
+}
+
+func TestOtlpSinkRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol string
+		statuses []int
+		codes    []codes.Code
+		exports  int
+		failure  string
+	}{
+		{name: "http success", protocol: otlpProtocolHTTP, exports: 1},
+		{name: "http server error", protocol: otlpProtocolHTTP, statuses: []int{http.StatusServiceUnavailable, http.StatusBadGateway}, exports: 3},
+		{name: "http throttled", protocol: otlpProtocolHTTP, statuses: []int{http.StatusTooManyRequests}, exports: 2},
+		{name: "http retries exhausted", protocol: otlpProtocolHTTP, statuses: []int{500, 500, 500}, exports: 3, failure: "giving up after 3 attempts"},
+		{name: "http bad request", protocol: otlpProtocolHTTP, statuses: []int{http.StatusBadRequest}, exports: 1, failure: "unexpected status 400"},
+		{name: "http unauthorized", protocol: otlpProtocolHTTP, statuses: []int{http.StatusUnauthorized}, exports: 1, failure: "unexpected status 401"},
+		{name: "grpc success", protocol: otlpProtocolGRPC, exports: 1},
+		{name: "grpc unavailable", protocol: otlpProtocolGRPC, codes: []codes.Code{codes.Unavailable, codes.ResourceExhausted}, exports: 3},
+		{name: "grpc retryable codes", protocol: otlpProtocolGRPC, codes: []codes.Code{codes.Aborted, codes.OutOfRange}, exports: 3},
+		{name: "grpc data loss", protocol: otlpProtocolGRPC, codes: []codes.Code{codes.DataLoss}, exports: 2},
+		{name: "grpc retries exhausted", protocol: otlpProtocolGRPC, codes: []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable}, exports: 3, failure: "giving up after 3 attempts"},
+		{name: "grpc invalid argument", protocol: otlpProtocolGRPC, codes: []codes.Code{codes.InvalidArgument}, exports: 1, failure: "InvalidArgument"},
+		{name: "grpc unauthenticated", protocol: otlpProtocolGRPC, codes: []codes.Code{codes.Unauthenticated}, exports: 1, failure: "Unauthenticated"},
+		{name: "grpc internal", protocol: otlpProtocolGRPC, codes: []codes.Code{codes.Internal}, exports: 1, failure: "Internal"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, endpoint := newFakeOtlp(t, test.protocol)
+			fake.statuses, fake.codes = test.statuses, test.codes
+			setOtlpEnv(t, test.protocol, endpoint)
+
+			sink, err := newOtlpSink("")
+			if err != nil {
+				t.Fatalf("failed to create sink: %s", err)
+			}
+			if err := sink.Write(context.Background(), testSinkLogs("a", "b")); err != nil {
+				t.Fatalf("unexpected error on write: %s", err)
+			}
+
+			err = sink.Close()
+			switch {
+			case len(test.failure) == 0 && err != nil:
+				t.Errorf("unexpected error on close: %s", err)
+			case len(test.failure) > 0 && err == nil:
+				t.Errorf("expected an error on close")
+			case len(test.failure) > 0 && !strings.Contains(err.Error(), test.failure):
+				t.Errorf("expected an error containing %q on close, got %q", test.failure, err)
+			}
+			if exports := fake.records(); len(exports) != test.exports {
+				t.Errorf("expected %d exports, got %v", test.exports, exports)
+			}
+		})
+	}
+}
+
+func TestOtlpSinkFailedBatch(t *testing.T) {
+	fake, endpoint := newFakeOtlp(t, otlpProtocolHTTP)
+	fake.statuses = []int{http.StatusBadRequest}
+	setOtlpEnv(t, otlpProtocolHTTP, endpoint)
+	t.Setenv("OTLP_BATCH_SIZE", "2")
+
+	sink, err := newOtlpSink("")
+	if err != nil {
+		t.Fatalf("failed to create sink: %s", err)
+	}
+	if err := sink.Write(context.Background(), testSinkLogs("a", "b")); err == nil {
+		t.Errorf("expected the failed batch to fail the write")
+	}
+	if err := sink.Write(context.Background(), testSinkLogs("c", "d", "e")); err != nil {
+		t.Errorf("unexpected error on write: %s", err)
+	}
+	if err := sink.Close(); err == nil || !strings.Contains(err.Error(), "failed to export 2 records") {
+		t.Errorf("expected the failed batch to be reported on close, got %v", err)
+	}
+	if exports := fmt.Sprint(fake.records()); exports != "[[a b] [c d] [e]]" {
+		t.Errorf("unexpected exports %s", exports)
+	}
+}
+
+func TestOtlpSinkPartialSuccess(t *testing.T) {
+	for _, protocol := range []string{otlpProtocolGRPC, otlpProtocolHTTP} {
+		t.Run(protocol, func(t *testing.T) {
+			fake, endpoint := newFakeOtlp(t, protocol)
+			fake.rejected = 1
+			setOtlpEnv(t, protocol, endpoint)
+			t.Setenv("OTLP_COMPRESSION", "none")
+
+			sink, err := newOtlpSink("")
+			if err != nil {
+				t.Fatalf("failed to create sink: %s", err)
+			}
+			if err := sink.Write(context.Background(), testSinkLogs("a", "b")); err != nil {
+				t.Fatalf("unexpected error on write: %s", err)
+			}
+			if err := sink.Close(); err != nil {
+				t.Errorf("expected rejected records not to fail the sink, got %s", err)
+			}
+			if exports := fmt.Sprint(fake.records()); exports != "[[a b]]" {
+				t.Errorf("unexpected exports %s", exports)
+			}
+		})
+	}
+}
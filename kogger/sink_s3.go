@@ -0,0 +1,306 @@
+package kogger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	s3BundleExtension   = ".ndjson.gz"
+	s3ManifestExtension = ".manifest.json"
+)
+
+// s3Sink archives the collected logs in S3 compatible storage, as gzip
+// compressed NDJSON bundles of LogEntry messages, one per container and hour
+// of the entries:
+//
+//	<prefix>/<namespace>/<pod>/<container>/YYYY/MM/DD/HH/<run>.ndjson.gz
+//
+// <run> identifies the collector run, so that the runs collecting the same
+// hour do not overwrite each other. Bundles are buffered in temporary files
+// and uploaded, with multipart uploads past S3_PART_SIZE, once the collector
+// moves on to the next pod. Each bundle is followed by a <run>.manifest.json
+// describing it, so that a bundle without manifest is an incomplete upload.
+type s3Sink struct {
+	client    *minio.Client
+	bucket    string
+	prefix    string
+	run       string
+	partSize  uint64
+	bufferDir string
+
+	// pod is the <namespace>/<pod> of the open bundles.
+	pod     string
+	bundles map[string]*s3Bundle
+	// failed is the first bundle which could not be uploaded, reported again
+	// on Close so that the collector does not move its checkpoints.
+	failed error
+}
+
+// s3Bundle is a bundle being written to its temporary file.
+type s3Bundle struct {
+	manifest s3Manifest
+	file     *os.File
+	sum      hash.Hash
+	writer   *gzip.Writer
+	ids      map[string]bool
+}
+
+// s3Manifest is the content of the manifest of a bundle.
+type s3Manifest struct {
+	Key              string    `json:"key"`
+	Namespace        string    `json:"namespace"`
+	Pod              string    `json:"pod"`
+	Container        string    `json:"container"`
+	ContainerIDs     []string  `json:"containerIds"`
+	Hour             time.Time `json:"hour"`
+	Entries          int       `json:"entries"`
+	FirstTime        time.Time `json:"firstTime"`
+	LastTime         time.Time `json:"lastTime"`
+	Size             int64     `json:"size"`
+	UncompressedSize int64     `json:"uncompressedSize"`
+	Sha256           string    `json:"sha256"`
+	Run              string    `json:"run"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+func newS3Sink(grpcToken string) (LogSink, error) {
+	bucket := getEnv("S3_BUCKET", "")
+	if len(bucket) == 0 {
+		return nil, fmt.Errorf("S3_BUCKET environment variable is not set")
+	}
+
+	endpoint := getEnv("S3_ENDPOINT", "https://s3.amazonaws.com")
+	parsed, err := url.Parse(endpoint)
+	if err != nil || len(parsed.Host) == 0 || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3_ENDPOINT %q, expected an http or https URL", endpoint)
+	}
+
+	// Without static keys, credentials come from the AWS or MinIO variables,
+	// or from the IAM role of the pod.
+	creds := credentials.NewChainCredentials([]credentials.Provider{
+		&credentials.EnvAWS{},
+		&credentials.EnvMinio{},
+		&credentials.IAM{},
+	})
+	if accessKey := getEnv("S3_ACCESS_KEY", ""); len(accessKey) > 0 {
+		creds = credentials.NewStaticV4(accessKey, getEnv("S3_SECRET_KEY", ""), "")
+	}
+
+	lookup := minio.BucketLookupAuto
+	pathStyle, err := strconv.ParseBool(getEnv("S3_PATH_STYLE", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3_PATH_STYLE: %s", err)
+	}
+	if pathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(parsed.Host, &minio.Options{
+		Creds:        creds,
+		Secure:       parsed.Scheme == "https",
+		Region:       getEnv("S3_REGION", ""),
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	partSize, err := parseBytesEnv("S3_PART_SIZE")
+	if err != nil {
+		return nil, err
+	}
+	if partSize == 0 {
+		partSize = 16 * 1024 * 1024
+	}
+	if partSize < 5*1024*1024 {
+		return nil, fmt.Errorf("invalid S3_PART_SIZE %d, parts must be at least 5Mi", partSize)
+	}
+
+	run, err := newRunID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3Sink{
+		client:    client,
+		bucket:    bucket,
+		prefix:    strings.Trim(getEnv("S3_PREFIX", ""), "/"),
+		run:       run,
+		partSize:  uint64(partSize),
+		bufferDir: getEnv("S3_BUFFER_DIR", os.TempDir()),
+		bundles:   map[string]*s3Bundle{},
+	}, nil
+}
+
+func (s *s3Sink) Write(ctx context.Context, logs *Logs) error {
+	if pod := logs.GetNamespace() + "/" + logs.GetPod(); pod != s.pod {
+		if err := s.flush(ctx); err != nil {
+			return err
+		}
+		s.pod = pod
+	}
+
+	now := time.Now()
+	for _, entry := range logs.GetEntries() {
+		if len(entry.GetPod()) == 0 {
+			entry = proto.Clone(entry).(*LogEntry)
+			entry.Pod = logs.GetPod()
+		}
+		at := now
+		if entry.GetTime() != nil {
+			at = entry.GetTime().AsTime()
+		}
+
+		hour := at.UTC().Truncate(time.Hour)
+		key := path.Join(s.prefix, logs.GetNamespace(), entry.GetPod(), entry.GetContainer(), hour.Format("2006/01/02/15"), s.run)
+		bundle, ok := s.bundles[key]
+		if !ok {
+			var err error
+			if bundle, err = s.newBundle(key); err != nil {
+				return err
+			}
+			bundle.manifest.Namespace = logs.GetNamespace()
+			bundle.manifest.Pod = entry.GetPod()
+			bundle.manifest.Container = entry.GetContainer()
+			bundle.manifest.Hour = hour
+			s.bundles[key] = bundle
+		}
+
+		line, err := protojson.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+		if _, err := bundle.writer.Write(line); err != nil {
+			return err
+		}
+
+		manifest := &bundle.manifest
+		if manifest.Entries == 0 || at.Before(manifest.FirstTime) {
+			manifest.FirstTime = at
+		}
+		if at.After(manifest.LastTime) {
+			manifest.LastTime = at
+		}
+		manifest.Entries++
+		manifest.UncompressedSize += int64(len(line))
+		if id := entry.GetContainerId(); len(id) > 0 {
+			bundle.ids[id] = true
+		}
+	}
+	return nil
+}
+
+func (s *s3Sink) Close() error {
+	if err := s.flush(context.Background()); err != nil {
+		return err
+	}
+	return s.failed
+}
+
+func (s *s3Sink) newBundle(key string) (*s3Bundle, error) {
+	file, err := os.CreateTemp(s.bufferDir, "kogger-s3-*"+s3BundleExtension)
+	if err != nil {
+		return nil, err
+	}
+	bundle := &s3Bundle{
+		manifest: s3Manifest{Key: key + s3BundleExtension, Run: s.run},
+		file:     file,
+		sum:      sha256.New(),
+		ids:      map[string]bool{},
+	}
+	bundle.writer = gzip.NewWriter(io.MultiWriter(file, bundle.sum))
+	return bundle, nil
+}
+
+// flush uploads the open bundles and their manifest. Bundles are dropped even
+// when their upload fails, the failure is reported on Close.
+func (s *s3Sink) flush(ctx context.Context) error {
+	keys := make([]string, 0, len(s.bundles))
+	for key := range s.bundles {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var firstErr error
+	for _, key := range keys {
+		bundle := s.bundles[key]
+		if err := s.upload(ctx, bundle); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to upload %s to bucket %s: %s", bundle.manifest.Key, s.bucket, err)
+		}
+		bundle.file.Close()
+		os.Remove(bundle.file.Name())
+	}
+	s.bundles = map[string]*s3Bundle{}
+
+	if firstErr != nil && s.failed == nil {
+		s.failed = firstErr
+	}
+	return firstErr
+}
+
+func (s *s3Sink) upload(ctx context.Context, bundle *s3Bundle) error {
+	if err := bundle.writer.Close(); err != nil {
+		return err
+	}
+	size, err := bundle.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := bundle.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	manifest := &bundle.manifest
+	manifest.Size = size
+	manifest.Sha256 = hex.EncodeToString(bundle.sum.Sum(nil))
+	manifest.ContainerIDs = []string{}
+	for id := range bundle.ids {
+		manifest.ContainerIDs = append(manifest.ContainerIDs, id)
+	}
+	sort.Strings(manifest.ContainerIDs)
+
+	_, err = s.client.PutObject(ctx, s.bucket, manifest.Key, bundle.file, size, minio.PutObjectOptions{
+		ContentType: "application/gzip",
+		PartSize:    s.partSize,
+		UserMetadata: map[string]string{
+			"Kogger-Entries": strconv.Itoa(manifest.Entries),
+			"Kogger-Sha256":  manifest.Sha256,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	manifest.CreatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	manifestKey := strings.TrimSuffix(manifest.Key, s3BundleExtension) + s3ManifestExtension
+	_, err = s.client.PutObject(ctx, s.bucket, manifestKey, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: "application/json",
+	})
+	return err
+}
@@ -0,0 +1,329 @@
+package kogger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// fakeS3 stores the objects of single and multipart uploads in memory,
+// denying the uploads of the keys containing deny.
+type fakeS3 struct {
+	t    *testing.T
+	deny string
+
+	lock       sync.Mutex
+	objects    map[string][]byte
+	parts      map[string]map[int][]byte
+	multiparts int
+}
+
+func newFakeS3(t *testing.T, deny string) (*fakeS3, *httptest.Server) {
+	fake := &fakeS3{t: t, deny: deny, objects: map[string][]byte{}, parts: map[string]map[int][]byte{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		f.t.Errorf("failed to read request: %s", err)
+		return
+	}
+	if r.Header.Get("X-Amz-Content-Sha256") == "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		if body, err = decodeAWSChunked(body); err != nil {
+			f.t.Errorf("invalid chunked body: %s", err)
+			return
+		}
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/logs/")
+	if !strings.HasPrefix(r.URL.Path, "/logs/") {
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if len(f.deny) > 0 && strings.Contains(key, f.deny) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w, `<Error><Code>AccessDenied</Code><Message>Access Denied</Message><Key>%s</Key><BucketName>logs</BucketName></Error>`, key)
+		return
+	}
+
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.parts[key] = map[int][]byte{}
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>logs</Bucket><Key>%s</Key><UploadId>upload</UploadId></InitiateMultipartUploadResult>`, key)
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		f.parts[key][number] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"part%d"`, number))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		numbers := []int{}
+		for number := range f.parts[key] {
+			numbers = append(numbers, number)
+		}
+		sort.Ints(numbers)
+		object := []byte{}
+		for _, number := range numbers {
+			object = append(object, f.parts[key][number]...)
+		}
+		f.objects[key] = object
+		f.multiparts++
+		delete(f.parts, key)
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>logs</Bucket><Key>%s</Key><ETag>"object"</ETag></CompleteMultipartUploadResult>`, key)
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+		w.Header().Set("ETag", `"object"`)
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// decodeAWSChunked decodes a body signed with chunk signatures, made of
+// <size in hex>;chunk-signature=<signature>\r\n<data>\r\n chunks.
+func decodeAWSChunked(body []byte) ([]byte, error) {
+	decoded := []byte{}
+	reader := bufio.NewReader(bytes.NewReader(body))
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return decoded, nil
+		}
+		chunk := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, chunk[:size]...)
+	}
+}
+
+func setS3Env(t *testing.T, url string) {
+	setSinkEnv(t, "S3", map[string]string{
+		"ENDPOINT":   url,
+		"BUCKET":     "logs",
+		"PREFIX":     "/archive/",
+		"REGION":     "us-east-1",
+		"ACCESS_KEY": "access",
+		"SECRET_KEY": "secret",
+		"PATH_STYLE": "true",
+		"PART_SIZE":  "5Mi",
+		"BUFFER_DIR": t.TempDir(),
+	})
+}
+
+func TestS3SinkArchive(t *testing.T) {
+	fake, server := newFakeS3(t, "")
+	setS3Env(t, server.URL)
+
+	sink, err := newS3Sink("")
+	if err != nil {
+		t.Fatalf("failed to create sink: %s", err)
+	}
+	run := sink.(*s3Sink).run
+
+	at := time.Date(2026, 10, 17, 3, 59, 0, 0, time.UTC)
+	writes := []*Logs{
+		{Namespace: "default", Pod: "web-0", Entries: []*LogEntry{
+			testSinkEntry("app", "a", at),
+			testSinkEntry("app", "b", at.Add(2*time.Minute)),
+			testSinkEntry("sidecar", "c", at.Add(time.Second)),
+			testSinkEntry("app", "d", at.Add(-time.Minute)),
+		}},
+		{Namespace: "default", Pod: "web-1", Entries: []*LogEntry{
+			testSinkEntry("app", "e", at),
+		}},
+	}
+	for _, logs := range writes {
+		if err := sink.Write(context.Background(), logs); err != nil {
+			t.Fatalf("unexpected error on write: %s", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unexpected error on close: %s", err)
+	}
+
+	expected := map[string][]string{
+		"archive/default/web-0/app/2026/10/17/03/" + run:     {"a", "d"},
+		"archive/default/web-0/app/2026/10/17/04/" + run:     {"b"},
+		"archive/default/web-0/sidecar/2026/10/17/03/" + run: {"c"},
+		"archive/default/web-1/app/2026/10/17/03/" + run:     {"e"},
+	}
+	if len(fake.objects) != 2*len(expected) {
+		keys := []string{}
+		for key := range fake.objects {
+			keys = append(keys, key)
+		}
+		t.Errorf("expected %d objects, got %v", 2*len(expected), keys)
+	}
+
+	for key, messages := range expected {
+		bundle, ok := fake.objects[key+s3BundleExtension]
+		if !ok {
+			t.Errorf("missing bundle %s", key+s3BundleExtension)
+			continue
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(bundle))
+		if err != nil {
+			t.Fatalf("bundle %s is not gzip compressed: %s", key, err)
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("failed to decompress bundle %s: %s", key, err)
+		}
+
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		found := []string{}
+		for _, line := range lines {
+			entry := &LogEntry{}
+			if err := protojson.Unmarshal([]byte(line), entry); err != nil {
+				t.Fatalf("invalid line %q in bundle %s: %s", line, key, err)
+			}
+			if !strings.HasPrefix(key, "archive/default/"+entry.GetPod()+"/"+entry.GetContainer()+"/") {
+				t.Errorf("entry of pod %s and container %s in bundle %s", entry.GetPod(), entry.GetContainer(), key)
+			}
+			found = append(found, entry.GetMessage())
+		}
+		if fmt.Sprint(found) != fmt.Sprint(messages) {
+			t.Errorf("expected entries %v in bundle %s, got %v", messages, key, found)
+		}
+
+		data, ok = fake.objects[key+s3ManifestExtension]
+		if !ok {
+			t.Errorf("missing manifest %s", key+s3ManifestExtension)
+			continue
+		}
+		manifest := s3Manifest{}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatalf("invalid manifest %s: %s", key, err)
+		}
+		sum := sha256.Sum256(bundle)
+		if manifest.Key != key+s3BundleExtension || manifest.Run != run || manifest.Namespace != "default" {
+			t.Errorf("unexpected manifest %s: %+v", key, manifest)
+		}
+		if manifest.Entries != len(messages) || manifest.Size != int64(len(bundle)) || manifest.Sha256 != hex.EncodeToString(sum[:]) {
+			t.Errorf("manifest %s does not describe its bundle: %+v", key, manifest)
+		}
+		if manifest.UncompressedSize != int64(len(lines))+int64(len(strings.Join(lines, ""))) {
+			t.Errorf("manifest %s has uncompressed size %d", key, manifest.UncompressedSize)
+		}
+		if len(manifest.ContainerIDs) != 1 || manifest.ContainerIDs[0] != "containerd://"+manifest.Container {
+			t.Errorf("manifest %s has container ids %v", key, manifest.ContainerIDs)
+		}
+	}
+
+	manifest := s3Manifest{}
+	if err := json.Unmarshal(fake.objects["archive/default/web-0/app/2026/10/17/03/"+run+s3ManifestExtension], &manifest); err == nil {
+		if !manifest.FirstTime.Equal(at.Add(-time.Minute)) || !manifest.LastTime.Equal(at) || !manifest.Hour.Equal(at.Truncate(time.Hour)) {
+			t.Errorf("unexpected times in manifest %+v", manifest)
+		}
+	}
+}
+
+func TestS3SinkMultipart(t *testing.T) {
+	fake, server := newFakeS3(t, "")
+	setS3Env(t, server.URL)
+
+	sink, err := newS3Sink("")
+	if err != nil {
+		t.Fatalf("failed to create sink: %s", err)
+	}
+
+	// Random messages do not compress, so that the bundle spans two parts.
+	logs := &Logs{Namespace: "default", Pod: "web-0"}
+	at := time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC)
+	for i := 0; i < 1000; i++ {
+		data := make([]byte, 6000)
+		rand.Read(data)
+		logs.Entries = append(logs.Entries, testSinkEntry("app", base64.StdEncoding.EncodeToString(data), at.Add(time.Duration(i)*time.Second)))
+	}
+	if err := sink.Write(context.Background(), logs); err != nil {
+		t.Fatalf("unexpected error on write: %s", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unexpected error on close: %s", err)
+	}
+
+	if fake.multiparts != 1 {
+		t.Errorf("expected a multipart upload, got %d", fake.multiparts)
+	}
+	key := "archive/default/web-0/app/2026/10/17/03/" + sink.(*s3Sink).run
+	manifest := s3Manifest{}
+	if err := json.Unmarshal(fake.objects[key+s3ManifestExtension], &manifest); err != nil {
+		t.Fatalf("invalid manifest: %s", err)
+	}
+	sum := sha256.Sum256(fake.objects[key+s3BundleExtension])
+	if manifest.Entries != len(logs.Entries) || manifest.Sha256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("manifest does not describe the bundle: %+v", manifest)
+	}
+}
+
+func TestS3SinkFailedUpload(t *testing.T) {
+	fake, server := newFakeS3(t, "/sidecar/")
+	setS3Env(t, server.URL)
+
+	sink, err := newS3Sink("")
+	if err != nil {
+		t.Fatalf("failed to create sink: %s", err)
+	}
+	run := sink.(*s3Sink).run
+
+	at := time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC)
+	if err := sink.Write(context.Background(), &Logs{Namespace: "default", Pod: "web-0", Entries: []*LogEntry{
+		testSinkEntry("app", "a", at),
+		testSinkEntry("sidecar", "b", at),
+	}}); err != nil {
+		t.Fatalf("unexpected error on write: %s", err)
+	}
+	// Moving on to the next pod uploads the bundles of the first one, the
+	// entries of the next pod are not written.
+	if err := sink.Write(context.Background(), &Logs{Namespace: "default", Pod: "web-1", Entries: []*LogEntry{
+		testSinkEntry("app", "c", at),
+	}}); err == nil {
+		t.Errorf("expected an error on write")
+	}
+
+	if err := sink.Close(); err == nil || !strings.Contains(err.Error(), "failed to upload archive/default/web-0/sidecar/") {
+		t.Errorf("expected the failed upload to be reported on close, got %v", err)
+	}
+
+	key := "archive/default/web-0/app/2026/10/17/03/" + run
+	if _, ok := fake.objects[key+s3ManifestExtension]; !ok {
+		t.Errorf("missing manifest %s", key+s3ManifestExtension)
+	}
+	if len(fake.objects) != 2 {
+		t.Errorf("expected the bundle and manifest of a single container, got %d objects", len(fake.objects))
+	}
+}
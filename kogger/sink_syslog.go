@@ -0,0 +1,385 @@
+package kogger
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+)
+
+const (
+	syslogProtocolUDP = "udp"
+	syslogProtocolTCP = "tcp"
+	syslogProtocolTLS = "tls"
+
+	// syslogNilValue stands for a missing header field.
+	syslogNilValue = "-"
+	// syslogTimestampLayout is the RFC 5424 timestamp, limited to
+	// microseconds.
+	syslogTimestampLayout = "2006-01-02T15:04:05.000000Z07:00"
+	// syslogMaxSDName is the maximum length of SD-IDs and parameter names.
+	syslogMaxSDName = 32
+)
+
+// syslogFacilities maps the facility names accepted in SYSLOG_FACILITY to
+// their code.
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSeverities maps the levels to the syslog severities. Entries of
+// unknown level are sent as informational.
+var syslogSeverities = map[LogLevel]int{
+	LogLevel_LOG_LEVEL_UNKNOWN: 6,
+	LogLevel_LOG_LEVEL_TRACE:   7,
+	LogLevel_LOG_LEVEL_DEBUG:   7,
+	LogLevel_LOG_LEVEL_INFO:    6,
+	LogLevel_LOG_LEVEL_WARN:    4,
+	LogLevel_LOG_LEVEL_ERROR:   3,
+	LogLevel_LOG_LEVEL_FATAL:   2,
+}
+
+// syslogSink forwards the collected logs as RFC 5424 messages, one datagram
+// per message over UDP, and with octet-counting framing over TCP and TLS.
+// APP-NAME is the pod and PROCID the container, the kubernetes metadata and
+// the pod labels go in structured data.
+type syslogSink struct {
+	protocol       string
+	address        string
+	tlsConfig      *tls.Config
+	facility       int
+	hostname       string
+	sdID           string
+	labelsSDID     string
+	maxMessageSize int
+	timeout        time.Duration
+	retry          retryPolicy
+
+	conn net.Conn
+}
+
+func newSyslogSink(grpcToken string) (LogSink, error) {
+	sink := &syslogSink{
+		protocol:   strings.ToLower(getEnv("SYSLOG_PROTOCOL", syslogProtocolTCP)),
+		address:    getEnv("SYSLOG_ADDRESS", ""),
+		hostname:   getEnv("SYSLOG_HOSTNAME", ""),
+		sdID:       getEnv("SYSLOG_SD_ID", "kubernetes@32473"),
+		labelsSDID: getEnv("SYSLOG_LABELS_SD_ID", "labels@32473"),
+	}
+	if len(sink.address) == 0 {
+		return nil, fmt.Errorf("SYSLOG_ADDRESS environment variable is not set")
+	}
+	if _, _, err := net.SplitHostPort(sink.address); err != nil {
+		return nil, fmt.Errorf("invalid SYSLOG_ADDRESS %q: %s", sink.address, err)
+	}
+	for _, id := range []string{sink.sdID, sink.labelsSDID} {
+		if len(id) > 0 && !isSyslogSDName(id) {
+			return nil, fmt.Errorf("invalid structured data ID %q", id)
+		}
+	}
+
+	facility, ok := syslogFacilities[strings.ToLower(getEnv("SYSLOG_FACILITY", "local0"))]
+	if !ok {
+		return nil, fmt.Errorf("invalid SYSLOG_FACILITY %q", getEnv("SYSLOG_FACILITY", ""))
+	}
+	sink.facility = facility
+
+	if len(sink.hostname) == 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		sink.hostname = hostname
+	}
+	sink.hostname = syslogHeaderField(sink.hostname, 255)
+
+	var err error
+	if sink.maxMessageSize, err = parseIntEnv("SYSLOG_MAX_MESSAGE_SIZE", 8192); err != nil {
+		return nil, err
+	}
+	if sink.timeout, err = parseDurationEnv("SYSLOG_TIMEOUT"); err != nil {
+		return nil, err
+	}
+	if sink.timeout == 0 {
+		sink.timeout = 10 * time.Second
+	}
+	if sink.retry, err = newRetryPolicy("SYSLOG"); err != nil {
+		return nil, err
+	}
+
+	switch sink.protocol {
+	case syslogProtocolUDP, syslogProtocolTCP:
+	case syslogProtocolTLS:
+		if sink.tlsConfig, err = syslogTLSConfig(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid SYSLOG_PROTOCOL %q, expected udp, tcp or tls", sink.protocol)
+	}
+
+	return sink, nil
+}
+
+// syslogTLSConfig reads the CA used to verify the server and the optional
+// client certificate from SYSLOG_TLS_*.
+func syslogTLSConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: getEnv("SYSLOG_TLS_SERVER_NAME", ""),
+	}
+
+	if path := getEnv("SYSLOG_TLS_CA_FILE", ""); len(path) > 0 {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate found in %s", path)
+		}
+	}
+
+	certFile, keyFile := getEnv("SYSLOG_TLS_CERT_FILE", ""), getEnv("SYSLOG_TLS_KEY_FILE", "")
+	if len(certFile) > 0 || len(keyFile) > 0 {
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return config, nil
+}
+
+func (s *syslogSink) Write(ctx context.Context, logs *Logs) error {
+	messages := [][]byte{}
+	for _, entry := range logs.GetEntries() {
+		messages = append(messages, s.format(logs, entry))
+	}
+
+	for retry := 0; len(messages) > 0; retry++ {
+		if retry > 0 {
+			if err := s.retry.wait(ctx, retry, 0); err != nil {
+				return err
+			}
+		}
+
+		sent, err := s.send(ctx, messages)
+		messages = messages[sent:]
+		if err == nil {
+			return nil
+		}
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		if ctx.Err() != nil || retry >= s.retry.maxRetries {
+			return fmt.Errorf("failed to forward %d messages to syslog: %s", len(messages), err)
+		}
+	}
+	return nil
+}
+
+// send writes the messages on the connection, opening it when needed. It
+// returns the number of messages written in full. Over TCP, a message cut
+// short by a failed write is sent again whole on the next connection, the
+// server dropping the partial frame along with the connection.
+func (s *syslogSink) send(ctx context.Context, messages [][]byte) (int, error) {
+	if s.conn == nil {
+		dialer := &net.Dialer{Timeout: s.timeout}
+		var err error
+		switch s.protocol {
+		case syslogProtocolTLS:
+			s.conn, err = (&tls.Dialer{NetDialer: dialer, Config: s.tlsConfig}).DialContext(ctx, "tcp", s.address)
+		default:
+			s.conn, err = dialer.DialContext(ctx, s.protocol, s.address)
+		}
+		if err != nil {
+			s.conn = nil
+			return 0, err
+		}
+	}
+
+	if err := s.conn.SetWriteDeadline(time.Now().Add(s.timeout)); err != nil {
+		return 0, err
+	}
+
+	if s.protocol == syslogProtocolUDP {
+		for i, message := range messages {
+			if _, err := s.conn.Write(message); err != nil {
+				return i, err
+			}
+		}
+		return len(messages), nil
+	}
+
+	var frames bytes.Buffer
+	ends := make([]int, 0, len(messages))
+	for _, message := range messages {
+		frames.WriteString(strconv.Itoa(len(message)))
+		frames.WriteByte(' ')
+		frames.Write(message)
+		ends = append(ends, frames.Len())
+	}
+	if n, err := s.conn.Write(frames.Bytes()); err != nil {
+		// The frames ending within the bytes written went out in full.
+		return sort.SearchInts(ends, n+1), err
+	}
+	return len(messages), nil
+}
+
+func (s *syslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// format builds the RFC 5424 message of an entry:
+//
+//	<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (s *syslogSink) format(logs *Logs, entry *LogEntry) []byte {
+	pod := entry.GetPod()
+	if len(pod) == 0 {
+		pod = logs.GetPod()
+	}
+
+	timestamp := syslogNilValue
+	if entry.GetTime() != nil {
+		timestamp = entry.GetTime().AsTime().UTC().Format(syslogTimestampLayout)
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "<%d>1 %s %s %s %s %s ",
+		s.facility*8+syslogSeverities[entry.GetLevel()],
+		timestamp,
+		s.hostname,
+		syslogHeaderField(pod, 48),
+		syslogHeaderField(entry.GetContainer(), 128),
+		syslogNilValue,
+	)
+
+	structured := false
+	if len(s.sdID) > 0 {
+		writeSyslogSDElement(&message, s.sdID, [][2]string{
+			{"namespace", logs.GetNamespace()},
+			{"pod", pod},
+			{"container", entry.GetContainer()},
+			{"containerId", entry.GetContainerId()},
+			{"restartCount", strconv.Itoa(int(entry.GetRestartCount()))},
+		})
+		structured = true
+	}
+	if len(s.labelsSDID) > 0 && len(logs.GetPodLabels()) > 0 {
+		labels := [][2]string{}
+		for name, value := range logs.GetPodLabels() {
+			labels = append(labels, [2]string{syslogSDName(name), value})
+		}
+		sort.Slice(labels, func(i, j int) bool {
+			return labels[i][0] < labels[j][0]
+		})
+		if len(labels) > 0 {
+			writeSyslogSDElement(&message, s.labelsSDID, labels)
+			structured = true
+		}
+	}
+	if !structured {
+		message.WriteString(syslogNilValue)
+	}
+
+	text := entry.GetMessage()
+	if room := s.maxMessageSize - message.Len() - 1; s.maxMessageSize > 0 && len(text) > room {
+		text = truncateUTF8(text, max(room, 0))
+	}
+	if len(text) > 0 {
+		message.WriteByte(' ')
+		message.WriteString(text)
+	}
+	return message.Bytes()
+}
+
+// writeSyslogSDElement writes [id name="value" ...], escaping the values.
+func writeSyslogSDElement(buf *bytes.Buffer, id string, params [][2]string) {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+	buf.WriteByte('[')
+	buf.WriteString(id)
+	for _, param := range params {
+		buf.WriteByte(' ')
+		buf.WriteString(param[0])
+		buf.WriteString(`="`)
+		buf.WriteString(escaper.Replace(param[1]))
+		buf.WriteByte('"')
+	}
+	buf.WriteByte(']')
+}
+
+// isSyslogSDName tells whether name is a valid SD-ID or parameter name: up
+// to 32 printable ASCII characters, except '=', ']' and '"'.
+func isSyslogSDName(name string) bool {
+	if len(name) == 0 || len(name) > syslogMaxSDName {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			return false
+		}
+	}
+	return true
+}
+
+// syslogSDName turns a pod label name into a valid parameter name. The
+// characters not allowed are replaced with '_', and names longer than 32
+// characters keep their last 32, where the name follows its prefix.
+func syslogSDName(label string) string {
+	name := []byte(label)
+	for i, c := range name {
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			name[i] = '_'
+		}
+	}
+	if len(name) > syslogMaxSDName {
+		name = name[len(name)-syslogMaxSDName:]
+	}
+	if len(name) == 0 {
+		return "_"
+	}
+	return string(name)
+}
+
+// syslogHeaderField makes value a valid header field: printable ASCII only,
+// at most maxLength long, and the nil value when empty.
+func syslogHeaderField(value string, maxLength int) string {
+	field := []byte{}
+	for i := 0; i < len(value) && len(field) < maxLength; i++ {
+		if c := value[i]; c >= 33 && c <= 126 {
+			field = append(field, c)
+		}
+	}
+	if len(field) == 0 {
+		return syslogNilValue
+	}
+	return string(field)
+}
+
+// truncateUTF8 cuts text to at most n bytes without splitting a rune.
+func truncateUTF8(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
@@ -0,0 +1,328 @@
+package kogger
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeSyslog receives the messages sent over TCP, with octet-counting
+// framing, or over UDP, one per datagram.
+type fakeSyslog struct {
+	t *testing.T
+
+	lock     sync.Mutex
+	messages []string
+}
+
+func newFakeSyslog(t *testing.T, protocol string) (*fakeSyslog, string) {
+	fake := &fakeSyslog{t: t}
+
+	if protocol == syslogProtocolUDP {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %s", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		go func() {
+			buf := make([]byte, 65536)
+			for {
+				n, _, err := conn.ReadFrom(buf)
+				if err != nil {
+					return
+				}
+				fake.add(string(buf[:n]))
+			}
+		}()
+		return fake, conn.LocalAddr().String()
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go fake.readFrames(conn)
+		}
+	}()
+	return fake, listener.Addr().String()
+}
+
+// readFrames reads the "<length> <message>" frames of a connection.
+func (f *fakeSyslog) readFrames(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		prefix, err := reader.ReadString(' ')
+		if err != nil {
+			return
+		}
+		length, err := strconv.Atoi(strings.TrimSuffix(prefix, " "))
+		if err != nil {
+			f.t.Errorf("invalid frame length %q", prefix)
+			return
+		}
+		message := make([]byte, length)
+		if _, err := io.ReadFull(reader, message); err != nil {
+			f.t.Errorf("truncated frame: %s", err)
+			return
+		}
+		f.add(string(message))
+	}
+}
+
+func (f *fakeSyslog) add(message string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.messages = append(f.messages, message)
+}
+
+// wait returns the messages once count of them are received.
+func (f *fakeSyslog) wait(count int) []string {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		f.lock.Lock()
+		if len(f.messages) >= count {
+			messages := append([]string{}, f.messages...)
+			f.lock.Unlock()
+			return messages
+		}
+		f.lock.Unlock()
+	}
+	f.t.Fatalf("expected %d messages, got %v", count, f.messages)
+	return nil
+}
+
+func setSyslogEnv(t *testing.T, protocol, address string) {
+	setSinkEnv(t, "SYSLOG", map[string]string{
+		"PROTOCOL": protocol,
+		"ADDRESS":  address,
+		"HOSTNAME": "node-1",
+	})
+}
+
+func TestSyslogSinkTransport(t *testing.T) {
+	for _, protocol := range []string{syslogProtocolTCP, syslogProtocolUDP} {
+		t.Run(protocol, func(t *testing.T) {
+			fake, address := newFakeSyslog(t, protocol)
+			setSyslogEnv(t, protocol, address)
+			t.Setenv("SYSLOG_SD_ID", "")
+
+			sink, err := newSyslogSink("")
+			if err != nil {
+				t.Fatalf("failed to create sink: %s", err)
+			}
+			// The second message holds a newline and the length of the
+			// third, which the octet-counting framing must carry as is.
+			if err := sink.Write(context.Background(), testSinkLogs("started", "panic: boom\n12 goroutine 1", "")); err != nil {
+				t.Fatalf("unexpected error on write: %s", err)
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatalf("unexpected error on close: %s", err)
+			}
+
+			expected := []string{
+				"<134>1 2026-10-17T12:00:00.000000Z node-1 web-0 app - - started",
+				"<134>1 2026-10-17T12:00:01.000000Z node-1 web-0 app - - panic: boom\n12 goroutine 1",
+				"<134>1 2026-10-17T12:00:02.000000Z node-1 web-0 app - -",
+			}
+			messages := fake.wait(len(expected))
+			if fmt.Sprintf("%q", messages) != fmt.Sprintf("%q", expected) {
+				t.Errorf("expected messages %q, got %q", expected, messages)
+			}
+		})
+	}
+}
+
+func TestSyslogSinkFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		logs    *Logs
+		entry   *LogEntry
+		message string
+	}{
+		{
+			name:    "structured data",
+			logs:    &Logs{Namespace: "default", Pod: "web-0"},
+			entry:   &LogEntry{Container: "app", ContainerId: "containerd://1", RestartCount: 2, Message: "started"},
+			message: `<134>1 - node-1 web-0 app - [kubernetes@32473 namespace="default" pod="web-0" container="app" containerId="containerd://1" restartCount="2"] started`,
+		},
+		{
+			name:    "escaped values",
+			env:     map[string]string{"SYSLOG_SD_ID": ""},
+			logs:    &Logs{Namespace: "default", Pod: "web-0", PodLabels: map[string]string{"note": `say "hi" \o/ [ok]`}},
+			entry:   &LogEntry{Container: "app", Message: "started"},
+			message: `<134>1 - node-1 web-0 app - [labels@32473 note="say \"hi\" \\o/ [ok\]"] started`,
+		},
+		{
+			name: "label names",
+			env:  map[string]string{"SYSLOG_SD_ID": ""},
+			logs: &Logs{Namespace: "default", Pod: "web-0", PodLabels: map[string]string{
+				"app.kubernetes.io/name":                        "web",
+				"team.platform.example.com/deployment-revision": "3",
+				"a=b": "c",
+			}},
+			entry:   &LogEntry{Container: "app", Message: "started"},
+			message: `<134>1 - node-1 web-0 app - [labels@32473 .example.com/deployment-revision="3" a_b="c" app.kubernetes.io/name="web"] started`,
+		},
+		{
+			name:    "truncated header",
+			env:     map[string]string{"SYSLOG_SD_ID": "", "SYSLOG_HOSTNAME": "node 1"},
+			logs:    &Logs{Namespace: "default"},
+			entry:   &LogEntry{Pod: strings.Repeat("p", 60), Container: "app", Message: "started"},
+			message: `<134>1 - node1 ` + strings.Repeat("p", 48) + ` app - - started`,
+		},
+		{
+			name:    "missing header fields",
+			env:     map[string]string{"SYSLOG_SD_ID": ""},
+			logs:    &Logs{Namespace: "default"},
+			entry:   &LogEntry{Message: "started"},
+			message: `<134>1 - node-1 - - - - started`,
+		},
+		{
+			name:    "timestamp",
+			env:     map[string]string{"SYSLOG_SD_ID": ""},
+			logs:    &Logs{Namespace: "default", Pod: "web-0"},
+			entry:   &LogEntry{Time: timestamppb.New(time.Date(2026, 10, 17, 12, 0, 0, 123456789, time.UTC)), Container: "app", Message: "started"},
+			message: `<134>1 2026-10-17T12:00:00.123456Z node-1 web-0 app - - started`,
+		},
+		{
+			name:    "severity",
+			env:     map[string]string{"SYSLOG_SD_ID": ""},
+			logs:    &Logs{Namespace: "default", Pod: "web-0"},
+			entry:   &LogEntry{Container: "app", Level: LogLevel_LOG_LEVEL_ERROR, Message: "failed"},
+			message: `<131>1 - node-1 web-0 app - - failed`,
+		},
+		{
+			name:    "facility",
+			env:     map[string]string{"SYSLOG_SD_ID": "", "SYSLOG_FACILITY": "daemon"},
+			logs:    &Logs{Namespace: "default", Pod: "web-0"},
+			entry:   &LogEntry{Container: "app", Level: LogLevel_LOG_LEVEL_DEBUG, Message: "cache miss"},
+			message: `<31>1 - node-1 web-0 app - - cache miss`,
+		},
+		{
+			name:    "kern facility",
+			env:     map[string]string{"SYSLOG_SD_ID": "", "SYSLOG_FACILITY": "kern"},
+			logs:    &Logs{Namespace: "default", Pod: "web-0"},
+			entry:   &LogEntry{Container: "app", Level: LogLevel_LOG_LEVEL_FATAL, Message: "out of memory"},
+			message: `<2>1 - node-1 web-0 app - - out of memory`,
+		},
+		{
+			name:    "truncated message",
+			env:     map[string]string{"SYSLOG_SD_ID": "", "SYSLOG_MAX_MESSAGE_SIZE": "38"},
+			logs:    &Logs{Namespace: "default", Pod: "web-0"},
+			entry:   &LogEntry{Container: "app", Message: "démarré après une longue attente"},
+			message: `<134>1 - node-1 web-0 app - - démarr`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setSyslogEnv(t, syslogProtocolTCP, "127.0.0.1:514")
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			sink, err := newSyslogSink("")
+			if err != nil {
+				t.Fatalf("failed to create sink: %s", err)
+			}
+			if message := string(sink.(*syslogSink).format(test.logs, test.entry)); message != test.message {
+				t.Errorf("expected %q, got %q", test.message, message)
+			}
+		})
+	}
+}
+
+// shortConn accepts limit bytes, then fails the write and every later one.
+type shortConn struct {
+	net.Conn
+
+	limit   int
+	written []byte
+}
+
+func (c *shortConn) Write(p []byte) (int, error) {
+	n := min(len(p), c.limit-len(c.written))
+	c.written = append(c.written, p[:n]...)
+	if n < len(p) {
+		return n, errors.New("connection reset by peer")
+	}
+	return n, nil
+}
+
+func (c *shortConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *shortConn) Close() error {
+	return nil
+}
+
+func TestSyslogSinkPartialWrite(t *testing.T) {
+	tests := []struct {
+		name string
+		// limit is the number of bytes written before the connection fails,
+		// in frames of 60 bytes.
+		limit   int
+		written int
+		resent  []string
+	}{
+		{name: "nothing written", limit: 0, resent: []string{"a", "b", "c"}},
+		{name: "first frame cut short", limit: 30, resent: []string{"a", "b", "c"}},
+		{name: "first frame written", limit: 60, written: 1, resent: []string{"b", "c"}},
+		{name: "second frame cut short", limit: 90, written: 1, resent: []string{"b", "c"}},
+		{name: "last frame cut short", limit: 150, written: 2, resent: []string{"c"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, address := newFakeSyslog(t, syslogProtocolTCP)
+			setSyslogEnv(t, syslogProtocolTCP, address)
+			t.Setenv("SYSLOG_SD_ID", "")
+
+			sink, err := newSyslogSink("")
+			if err != nil {
+				t.Fatalf("failed to create sink: %s", err)
+			}
+			conn := &shortConn{limit: test.limit}
+			sink.(*syslogSink).conn = conn
+
+			if err := sink.Write(context.Background(), testSinkLogs("a", "b", "c")); err != nil {
+				t.Fatalf("unexpected error on write: %s", err)
+			}
+			if err := sink.Close(); err != nil {
+				t.Fatalf("unexpected error on close: %s", err)
+			}
+
+			if written := strings.Count(string(conn.written[:test.written*60]), " node-1 "); written != test.written {
+				t.Errorf("expected %d frames written before the failure, got %d", test.written, written)
+			}
+			messages := fake.wait(len(test.resent))
+			resent := []string{}
+			for _, message := range messages {
+				resent = append(resent, message[strings.LastIndex(message, " ")+1:])
+			}
+			if fmt.Sprint(resent) != fmt.Sprint(test.resent) {
+				t.Errorf("expected %v sent again, got %v", test.resent, resent)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package kogger
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// setSinkEnv sets the variables of the sink reading them with the given
+// prefix until the test ends. Unless env says otherwise, the sink retries
+// twice, a few milliseconds apart.
+func setSinkEnv(t *testing.T, prefix string, env map[string]string) {
+	t.Setenv(prefix+"_MAX_RETRIES", "2")
+	t.Setenv(prefix+"_MIN_BACKOFF", "1ms")
+	t.Setenv(prefix+"_MAX_BACKOFF", "2ms")
+	for name, value := range env {
+		t.Setenv(prefix+"_"+name, value)
+	}
+}
+
+// testSinkEntry returns an entry of the container at the given time.
+func testSinkEntry(container, message string, at time.Time) *LogEntry {
+	return &LogEntry{
+		Time:        timestamppb.New(at),
+		Container:   container,
+		ContainerId: "containerd://" + container,
+		Message:     message,
+	}
+}
+
+// testSinkLogs returns the logs of the pod web-0 in the default namespace,
+// with an entry of the app container per message, one second apart from
+// testCollectorTime on.
+func testSinkLogs(messages ...string) *Logs {
+	logs := &Logs{Namespace: "default", Pod: "web-0"}
+	for i, message := range messages {
+		logs.Entries = append(logs.Entries, testSinkEntry("app", message, testCollectorTime.Add(time.Duration(i)*time.Second)))
+	}
+	return logs
+}
@@ -0,0 +1,97 @@
+package kogger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+)
+
+// LogStore persists the collected logs so they outlive the pods they come from.
+type LogStore interface {
+	// Append stores the entries of logs under logs.Namespace.
+	Append(ctx context.Context, logs *Logs) error
+	// Query returns the stored entries matching the query, oldest first.
+	Query(ctx context.Context, query *LogStoreQuery) ([]*LogEntry, error)
+	// Delete removes the logs of namespace, or of every namespace when empty,
+	// stored before the given time. It returns the number of bytes freed.
+	Delete(ctx context.Context, namespace string, before time.Time) (int64, error)
+	// Downsample drops the entries below minLevel from the logs of namespace,
+	// or of every namespace when empty, stored before the given time. Entries
+	// of unknown level are kept. It returns the number of bytes freed.
+	Downsample(ctx context.Context, namespace string, before time.Time, minLevel LogLevel) (int64, error)
+	// Stats reports the space used by the store.
+	Stats(ctx context.Context) (*LogStoreStats, error)
+	// Search returns the stored entries holding every phrase of the query,
+	// best matches first.
+	Search(ctx context.Context, query *LogSearchQuery) (*LogSearchResult, error)
+}
+
+// LogStoreQuery selects stored entries. Empty fields match everything.
+type LogStoreQuery struct {
+	Namespace string
+	Pod       string
+	Container string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// LogSearchQuery is a full-text search. Each phrase is a list of tokens, as
+// returned by tokenize, which must appear next to each other. Empty
+// namespaces search every namespace.
+type LogSearchQuery struct {
+	Phrases    [][]string
+	Namespaces []string
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+// LogSearchResult holds the hits of a search, along with the number of
+// segments read and of segments skipped thanks to the index.
+type LogSearchResult struct {
+	Hits            []*LogSearchHit
+	ScannedSegments int
+	SkippedSegments int
+}
+
+// LogSearchHit is an entry matching a search, scored with BM25.
+type LogSearchHit struct {
+	Namespace string
+	Entry     *LogEntry
+	Score     float64
+}
+
+// LogStoreStats is the space used by a store, in total and per namespace.
+type LogStoreStats struct {
+	Bytes      int64
+	Segments   int
+	Namespaces map[string]*NamespaceStoreStats
+}
+
+// NamespaceStoreStats is the space used by the logs of a namespace, along with
+// the time range they cover.
+type NamespaceStoreStats struct {
+	Bytes    int64
+	Segments int
+	Oldest   time.Time
+	Newest   time.Time
+}
+
+// logStore is the store of the server, nil when storage is disabled.
+var logStore LogStore
+
+// newLogStore builds the store selected by KOGGER_STORAGE. It returns nil when
+// storage is disabled.
+func newLogStore() (LogStore, error) {
+	switch kind := getEnv("KOGGER_STORAGE", ""); kind {
+	case "":
+		return nil, nil
+	case "filesystem":
+		return newFilesystemLogStore(getEnv("KOGGER_STORAGE_PATH", "/var/lib/kogger/store"))
+	default:
+		return nil, fmt.Errorf("unknown storage %q, expected filesystem", kind)
+	}
+}
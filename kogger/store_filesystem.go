@@ -0,0 +1,563 @@
+package kogger
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	grpctoken "github.com/ZolaraProject/library/grpctoken"
+	logger "github.com/ZolaraProject/library/logger"
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// partitionLayout names the hourly partitions of a namespace.
+	partitionLayout = "2006010215"
+	// segmentSuffix ends the name of the segment files.
+	segmentSuffix = ".ndjson.gz"
+	// downsampledSuffix ends the name of the downsampled segment files, which
+	// are never appended to nor downsampled again.
+	downsampledSuffix = ".downsampled" + segmentSuffix
+	// maxSegmentBytes is the size after which a new segment is started.
+	maxSegmentBytes = 64 * 1024 * 1024
+)
+
+// filesystemLogStore stores the logs as gzip compressed, newline delimited JSON
+// entries in <dir>/<namespace>/<YYYYMMDDHH>/<seq>.ndjson.gz, partitioned by the
+// hour of the entries. Each Append adds a gzip member to the last segment of
+// the partition, so a segment is readable as soon as Append returns.
+type filesystemLogStore struct {
+	mu  sync.RWMutex
+	dir string
+}
+
+func newFilesystemLogStore(dir string) (*filesystemLogStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &filesystemLogStore{dir: dir}, nil
+}
+
+// segment is a segment file along with the partition it belongs to. Size
+// includes the size of its index.
+type segment struct {
+	namespace string
+	partition time.Time
+	path      string
+	size      int64
+	indexSize int64
+}
+
+func (s *filesystemLogStore) Append(ctx context.Context, logs *Logs) error {
+	namespace := logs.GetNamespace()
+	if err := validateStoreNamespace(namespace); err != nil {
+		return err
+	}
+
+	partitions := map[string][]*LogEntry{}
+	now := time.Now()
+	for _, entry := range logs.GetEntries() {
+		if len(entry.GetPod()) == 0 {
+			entry = proto.Clone(entry).(*LogEntry)
+			entry.Pod = logs.GetPod()
+		}
+
+		at := now
+		if entry.GetTime() != nil {
+			at = entry.GetTime().AsTime()
+		}
+		partition := at.UTC().Format(partitionLayout)
+		partitions[partition] = append(partitions[partition], entry)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for partition, entries := range partitions {
+		if err := s.appendPartition(ctx, filepath.Join(s.dir, namespace, partition), entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendPartition writes the entries to the last segment of the partition,
+// starting a new one when it is full, and adds them to the segment index.
+func (s *filesystemLogStore) appendPartition(ctx context.Context, dir string, entries []*LogEntry) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	seq, size, downsampled, err := lastSegment(dir)
+	if err != nil {
+		return err
+	}
+	if seq == 0 || downsampled || size >= maxSegmentBytes {
+		seq++
+	}
+
+	path := filepath.Join(dir, segmentName(seq))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	compressor := gzip.NewWriter(writer)
+	for _, entry := range entries {
+		data, err := protojson.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := compressor.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	if err := compressor.Close(); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+
+	// A missing or stale index is rebuilt by the next search.
+	if err := updateSegmentIndex(path, info.Size(), entries); err != nil {
+		logger.Warn(grpctoken.GetToken(ctx), "Failed to index segment %s: %s", path, err)
+	}
+	return nil
+}
+
+// updateSegmentIndex adds the entries appended to a segment to its index,
+// provided the index covers the segment as it was before the append.
+func updateSegmentIndex(path string, previousSize int64, entries []*LogEntry) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	idx, err := readSegmentIndex(indexPath(path))
+	if err != nil || idx.size != previousSize {
+		if previousSize > 0 {
+			os.Remove(indexPath(path))
+			return nil
+		}
+		idx = newSegmentIndex()
+	}
+	for _, entry := range entries {
+		idx.add(entry)
+	}
+	idx.size = info.Size()
+	return writeSegmentIndex(indexPath(path), idx)
+}
+
+// validateStoreNamespace rejects the namespaces which are not valid directory
+// names, such as paths escaping the store.
+func validateStoreNamespace(namespace string) error {
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace %q: %s", namespace, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func segmentName(seq int) string {
+	return fmt.Sprintf("%06d%s", seq, segmentSuffix)
+}
+
+// segmentSeq parses the sequence number of a segment file name.
+func segmentSeq(name string) (seq int, downsampled bool, ok bool) {
+	if !strings.HasSuffix(name, segmentSuffix) {
+		return 0, false, false
+	}
+	downsampled = strings.HasSuffix(name, downsampledSuffix)
+	n, err := strconv.Atoi(strings.SplitN(name, ".", 2)[0])
+	if err != nil {
+		return 0, false, false
+	}
+	return n, downsampled, true
+}
+
+// lastSegment returns the sequence number, size and kind of the last segment
+// of a partition, or 0 when it has none.
+func lastSegment(dir string) (int, int64, bool, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	seq, size, downsampled := 0, int64(0), false
+	for _, file := range files {
+		n, isDownsampled, ok := segmentSeq(file.Name())
+		if !ok || n <= seq {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return 0, 0, false, err
+		}
+		seq, size, downsampled = n, info.Size(), isDownsampled
+	}
+	return seq, size, downsampled, nil
+}
+
+func (s *filesystemLogStore) Query(ctx context.Context, query *LogStoreQuery) ([]*LogEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	segments, err := s.segments(query.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*LogEntry{}
+	for i, seg := range segments {
+		if !query.Since.IsZero() && !seg.partition.Add(time.Hour).After(query.Since) {
+			continue
+		}
+		if !query.Until.IsZero() && seg.partition.After(query.Until) {
+			continue
+		}
+		// Partitions do not overlap, so the page is complete once the
+		// partition holding the last entry has been read.
+		if query.Limit > 0 && len(entries) >= query.Limit && seg.partition.After(segments[i-1].partition) {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		err := readSegment(seg.path, func(entry *LogEntry) {
+			entry.Namespace = seg.namespace
+			if len(query.Pod) > 0 && entry.GetPod() != query.Pod {
+				return
+			}
+			if len(query.Container) > 0 && entry.GetContainer() != query.Container {
+				return
+			}
+			if entry.GetTime() != nil {
+				at := entry.GetTime().AsTime()
+				if (!query.Since.IsZero() && at.Before(query.Since)) || (!query.Until.IsZero() && at.After(query.Until)) {
+					return
+				}
+			}
+			entries = append(entries, entry)
+		})
+		if err != nil {
+			logger.Warn(grpctoken.GetToken(ctx), "Partially read segment %s: %s", seg.path, err)
+		}
+	}
+
+	sortLogEntries(entries)
+	if query.Limit > 0 && len(entries) > query.Limit {
+		entries = entries[:query.Limit]
+	}
+	return entries, nil
+}
+
+// readSegment calls fn with every entry of a segment. Entries read before an
+// error, such as a member truncated by a crash, are still passed to fn.
+func readSegment(path string, fn func(entry *LogEntry)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decompressor, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return err
+	}
+	defer decompressor.Close()
+
+	reader := bufio.NewReader(decompressor)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && err == nil {
+			entry := &LogEntry{}
+			if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(line, entry); err != nil {
+				return err
+			}
+			fn(entry)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *filesystemLogStore) Delete(ctx context.Context, namespace string, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	segments, err := s.segments(namespace)
+	if err != nil {
+		return 0, err
+	}
+
+	freed := int64(0)
+	for _, seg := range segments {
+		if seg.partition.Add(time.Hour).After(before) {
+			continue
+		}
+		if err := os.Remove(seg.path); err != nil {
+			return freed, err
+		}
+		os.Remove(indexPath(seg.path))
+		freed += seg.size
+
+		// Drop the partition and namespace directories once empty.
+		partitionDir := filepath.Dir(seg.path)
+		if os.Remove(partitionDir) == nil {
+			os.Remove(filepath.Dir(partitionDir))
+		}
+	}
+	return freed, nil
+}
+
+func (s *filesystemLogStore) Downsample(ctx context.Context, namespace string, before time.Time, minLevel LogLevel) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	segments, err := s.segments(namespace)
+	if err != nil {
+		return 0, err
+	}
+
+	freed := int64(0)
+	for _, seg := range segments {
+		if seg.partition.Add(time.Hour).After(before) || strings.HasSuffix(seg.path, downsampledSuffix) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return freed, err
+		}
+
+		size, err := downsampleSegment(seg.path, minLevel)
+		if err != nil {
+			return freed, fmt.Errorf("failed to downsample segment %s: %s", seg.path, err)
+		}
+		freed += seg.size - size
+	}
+	return freed, nil
+}
+
+// downsampleSegment rewrites a segment without the entries below minLevel,
+// under a new sequence number so that it does not clash with segments added
+// to the partition later on. It returns the size of the new segment.
+func downsampleSegment(path string, minLevel LogLevel) (int64, error) {
+	entries := []*LogEntry{}
+	err := readSegment(path, func(entry *LogEntry) {
+		if entry.GetLevel() == LogLevel_LOG_LEVEL_UNKNOWN || entry.GetLevel() >= minLevel {
+			entries = append(entries, entry)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	os.Remove(indexPath(path))
+	if len(entries) == 0 {
+		return 0, os.Remove(path)
+	}
+
+	dir := filepath.Dir(path)
+	seq, _, _, err := lastSegment(dir)
+	if err != nil {
+		return 0, err
+	}
+	tmp := filepath.Join(dir, fmt.Sprintf(".%06d%s.tmp", seq+1, downsampledSuffix))
+	target := filepath.Join(dir, fmt.Sprintf("%06d%s", seq+1, downsampledSuffix))
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	compressor := gzip.NewWriter(writer)
+	for _, entry := range entries {
+		data, err := protojson.Marshal(entry)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := compressor.Write(append(data, '\n')); err != nil {
+			return 0, err
+		}
+	}
+	if err := compressor.Close(); err != nil {
+		return 0, err
+	}
+	if err := writer.Flush(); err != nil {
+		return 0, err
+	}
+	if err := file.Sync(); err != nil {
+		return 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		return 0, err
+	}
+	if err := os.Remove(path); err != nil {
+		return 0, err
+	}
+
+	idx := newSegmentIndex()
+	for _, entry := range entries {
+		idx.add(entry)
+	}
+	idx.size = info.Size()
+	if err := writeSegmentIndex(indexPath(target), idx); err != nil {
+		return info.Size(), err
+	}
+	if indexInfo, err := os.Stat(indexPath(target)); err == nil {
+		return info.Size() + indexInfo.Size(), nil
+	}
+	return info.Size(), nil
+}
+
+// volumeUsage returns the bytes used on the volume holding the store and its
+// capacity.
+func (s *filesystemLogStore) volumeUsage() (int64, int64, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(s.dir, &fs); err != nil {
+		return 0, 0, err
+	}
+	capacity := int64(fs.Blocks) * int64(fs.Bsize)
+	used := capacity - int64(fs.Bfree)*int64(fs.Bsize)
+	return used, capacity, nil
+}
+
+func (s *filesystemLogStore) Stats(ctx context.Context) (*LogStoreStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	segments, err := s.segments("")
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &LogStoreStats{
+		Namespaces: map[string]*NamespaceStoreStats{},
+	}
+	for _, seg := range segments {
+		stats.Bytes += seg.size
+		stats.Segments++
+
+		ns, ok := stats.Namespaces[seg.namespace]
+		if !ok {
+			ns = &NamespaceStoreStats{Oldest: seg.partition}
+			stats.Namespaces[seg.namespace] = ns
+		}
+		ns.Bytes += seg.size
+		ns.Segments++
+		ns.Newest = seg.partition.Add(time.Hour)
+	}
+	return stats, nil
+}
+
+// segments lists the segments of namespace, or of every namespace when empty,
+// ordered by partition, then namespace and sequence number.
+func (s *filesystemLogStore) segments(namespace string) ([]segment, error) {
+	namespaces := []string{namespace}
+	if len(namespace) > 0 {
+		if err := validateStoreNamespace(namespace); err != nil {
+			return nil, err
+		}
+	} else {
+		dirs, err := os.ReadDir(s.dir)
+		if err != nil {
+			return nil, err
+		}
+		namespaces = namespaces[:0]
+		for _, dir := range dirs {
+			if dir.IsDir() {
+				namespaces = append(namespaces, dir.Name())
+			}
+		}
+	}
+
+	segments := []segment{}
+	for _, namespace := range namespaces {
+		partitions, err := os.ReadDir(filepath.Join(s.dir, namespace))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dir := range partitions {
+			partition, err := time.Parse(partitionLayout, dir.Name())
+			if err != nil || !dir.IsDir() {
+				continue
+			}
+
+			files, err := os.ReadDir(filepath.Join(s.dir, namespace, dir.Name()))
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range files {
+				if !strings.HasSuffix(file.Name(), segmentSuffix) {
+					continue
+				}
+				info, err := file.Info()
+				if err != nil {
+					return nil, err
+				}
+				seg := segment{
+					namespace: namespace,
+					partition: partition,
+					path:      filepath.Join(s.dir, namespace, dir.Name(), file.Name()),
+					size:      info.Size(),
+				}
+				if indexInfo, err := os.Stat(indexPath(seg.path)); err == nil {
+					seg.indexSize = indexInfo.Size()
+					seg.size += seg.indexSize
+				}
+				segments = append(segments, seg)
+			}
+		}
+	}
+
+	sort.SliceStable(segments, func(i, j int) bool {
+		if !segments[i].partition.Equal(segments[j].partition) {
+			return segments[i].partition.Before(segments[j].partition)
+		}
+		if segments[i].namespace != segments[j].namespace {
+			return segments[i].namespace < segments[j].namespace
+		}
+		return segments[i].path < segments[j].path
+	})
+	return segments, nil
+}
@@ -0,0 +1,269 @@
+package kogger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// useFilesystemLogStore sets the store of the server to a new filesystem store
+// until the test ends.
+func useFilesystemLogStore(t *testing.T) *filesystemLogStore {
+	t.Helper()
+	store, err := newFilesystemLogStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create store: %s", err)
+	}
+	previous := logStore
+	logStore = store
+	t.Cleanup(func() { logStore = previous })
+	return store
+}
+
+// testStoreEntry returns an entry of the container at testCollectorTime plus
+// the given offset.
+func testStoreEntry(pod, container, message string, offset time.Duration) *LogEntry {
+	return &LogEntry{
+		Pod:       pod,
+		Container: container,
+		Message:   message,
+		Time:      timestamppb.New(testCollectorTime.Add(offset)),
+	}
+}
+
+// testStoredMessages lists the entries as namespace/pod/container: message.
+func testStoredMessages(entries []*LogEntry) string {
+	messages := []string{}
+	for _, entry := range entries {
+		messages = append(messages, fmt.Sprintf("%s/%s/%s: %s", entry.GetNamespace(), entry.GetPod(), entry.GetContainer(), entry.GetMessage()))
+	}
+	return strings.Join(messages, ", ")
+}
+
+func TestFilesystemLogStoreQuery(t *testing.T) {
+	store := useFilesystemLogStore(t)
+	ctx := context.Background()
+
+	// The entries span the 11:00, 12:00 and 13:00 partitions.
+	for _, logs := range []*Logs{
+		{Namespace: "default", Pod: "web-0", Entries: []*LogEntry{
+			testStoreEntry("", "app", "before noon", -time.Second),
+			testStoreEntry("", "app", "noon", 0),
+			testStoreEntry("", "sidecar", "half past", 30*time.Minute),
+			testStoreEntry("", "app", "one", time.Hour),
+		}},
+		{Namespace: "default", Entries: []*LogEntry{
+			testStoreEntry("web-1", "app", "web-1 noon", time.Millisecond),
+		}},
+		{Namespace: "monitoring", Pod: "prometheus-0", Entries: []*LogEntry{
+			testStoreEntry("", "prometheus", "scraping", 59*time.Minute+59*time.Second),
+		}},
+	} {
+		if err := store.Append(ctx, logs); err != nil {
+			t.Fatalf("failed to append: %s", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		query    *LogStoreQuery
+		messages string
+	}{
+		{
+			name:     "namespace",
+			query:    &LogStoreQuery{Namespace: "default"},
+			messages: "default/web-0/app: before noon, default/web-0/app: noon, default/web-1/app: web-1 noon, default/web-0/sidecar: half past, default/web-0/app: one",
+		},
+		{
+			name:     "every namespace",
+			query:    &LogStoreQuery{Since: testCollectorTime.Add(30 * time.Minute)},
+			messages: "default/web-0/sidecar: half past, monitoring/prometheus-0/prometheus: scraping, default/web-0/app: one",
+		},
+		{
+			name:     "pod",
+			query:    &LogStoreQuery{Namespace: "default", Pod: "web-1"},
+			messages: "default/web-1/app: web-1 noon",
+		},
+		{
+			name:     "container",
+			query:    &LogStoreQuery{Namespace: "default", Pod: "web-0", Container: "app"},
+			messages: "default/web-0/app: before noon, default/web-0/app: noon, default/web-0/app: one",
+		},
+		{
+			name:     "hour boundaries",
+			query:    &LogStoreQuery{Namespace: "default", Since: testCollectorTime, Until: testCollectorTime.Add(time.Hour - time.Nanosecond)},
+			messages: "default/web-0/app: noon, default/web-1/app: web-1 noon, default/web-0/sidecar: half past",
+		},
+		{
+			name:     "until included",
+			query:    &LogStoreQuery{Namespace: "default", Until: testCollectorTime},
+			messages: "default/web-0/app: before noon, default/web-0/app: noon",
+		},
+		{
+			name:     "limit across partitions",
+			query:    &LogStoreQuery{Namespace: "default", Limit: 2},
+			messages: "default/web-0/app: before noon, default/web-0/app: noon",
+		},
+		{
+			name:  "unknown namespace",
+			query: &LogStoreQuery{Namespace: "kube-system"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := store.Query(ctx, test.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if messages := testStoredMessages(entries); messages != test.messages {
+				t.Errorf("expected %s, got %s", test.messages, messages)
+			}
+		})
+	}
+}
+
+func TestFilesystemLogStoreDelete(t *testing.T) {
+	store := useFilesystemLogStore(t)
+	ctx := context.Background()
+
+	for _, namespace := range []string{"default", "monitoring"} {
+		err := store.Append(ctx, &Logs{Namespace: namespace, Pod: "web-0", Entries: []*LogEntry{
+			testStoreEntry("", "app", "before noon", -time.Second),
+			testStoreEntry("", "app", "noon", 0),
+			testStoreEntry("", "app", "one", time.Hour),
+		}})
+		if err != nil {
+			t.Fatalf("failed to append: %s", err)
+		}
+	}
+
+	before, err := store.Stats(ctx)
+	if err != nil {
+		t.Fatalf("failed to get stats: %s", err)
+	}
+
+	// Only the partitions ending by the given time are deleted.
+	freed, err := store.Delete(ctx, "default", testCollectorTime.Add(time.Hour-time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for namespace, expected := range map[string]string{
+		"default":    "default/web-0/app: noon, default/web-0/app: one",
+		"monitoring": "monitoring/web-0/app: before noon, monitoring/web-0/app: noon, monitoring/web-0/app: one",
+	} {
+		entries, err := store.Query(ctx, &LogStoreQuery{Namespace: namespace})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if messages := testStoredMessages(entries); messages != expected {
+			t.Errorf("expected %s, got %s", expected, messages)
+		}
+	}
+
+	after, err := store.Stats(ctx)
+	if err != nil {
+		t.Fatalf("failed to get stats: %s", err)
+	}
+	if freed <= 0 || after.Bytes != before.Bytes-freed {
+		t.Errorf("expected %d bytes freed out of %d, %d left", freed, before.Bytes, after.Bytes)
+	}
+	if oldest := after.Namespaces["default"].Oldest; !oldest.Equal(testCollectorTime) {
+		t.Errorf("expected the oldest partition of default at %s, got %s", testCollectorTime, oldest)
+	}
+
+	if _, err := store.Delete(ctx, "", testCollectorTime.Add(2*time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	stats, err := store.Stats(ctx)
+	if err != nil {
+		t.Fatalf("failed to get stats: %s", err)
+	}
+	if stats.Bytes != 0 || len(stats.Namespaces) != 0 {
+		t.Errorf("expected an empty store, got %d bytes in %d namespaces", stats.Bytes, len(stats.Namespaces))
+	}
+}
+
+func TestStoreLogs(t *testing.T) {
+	tests := []struct {
+		name string
+		logs *Logs
+		err  string
+	}{
+		{
+			name: "pod of the logs",
+			logs: &Logs{Namespace: "default", Pod: "web-0", Entries: []*LogEntry{testStoreEntry("", "app", "started", 0)}},
+		},
+		{
+			name: "pod of the entries",
+			logs: &Logs{Namespace: "default", Entries: []*LogEntry{testStoreEntry("web-0", "app", "started", 0)}},
+		},
+		{
+			name: "missing timestamp",
+			logs: &Logs{Namespace: "default", Pod: "web-0", Entries: []*LogEntry{{Container: "app", Message: "banner", MissingTimestamp: true}}},
+		},
+		{
+			name: "no namespace",
+			logs: &Logs{Pod: "web-0", Entries: []*LogEntry{testStoreEntry("", "app", "started", 0)}},
+			err:  "namespace not specified",
+		},
+		{
+			name: "invalid namespace",
+			logs: &Logs{Namespace: "../etc", Pod: "web-0", Entries: []*LogEntry{testStoreEntry("", "app", "started", 0)}},
+			err:  "invalid namespace",
+		},
+		{
+			name: "no pod",
+			logs: &Logs{Namespace: "default", Entries: []*LogEntry{testStoreEntry("web-0", "app", "started", 0), testStoreEntry("", "app", "started", 0)}},
+			err:  "entry 1 has no pod",
+		},
+		{
+			name: "other namespace",
+			logs: &Logs{Namespace: "default", Pod: "web-0", Entries: []*LogEntry{{Namespace: "monitoring", Container: "app", Message: "started", Time: timestamppb.New(testCollectorTime)}}},
+			err:  "entry 0 belongs to namespace monitoring",
+		},
+		{
+			name: "no time",
+			logs: &Logs{Namespace: "default", Pod: "web-0", Entries: []*LogEntry{{Container: "app", Message: "started"}}},
+			err:  "entry 0 has no time",
+		},
+		{
+			name: "invalid time",
+			logs: &Logs{Namespace: "default", Pod: "web-0", Entries: []*LogEntry{{Container: "app", Message: "started", Time: &timestamppb.Timestamp{Nanos: -1}}}},
+			err:  "entry 0 has an invalid time",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := useFilesystemLogStore(t)
+
+			_, err := (&server{}).StoreLogs(context.Background(), test.logs)
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("expected error %q, got %v", test.err, err)
+				}
+				if stats, _ := store.Stats(context.Background()); stats.Bytes != 0 {
+					t.Errorf("expected nothing stored, got %d bytes", stats.Bytes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			stored, err := (&server{}).QueryStoredLogs(context.Background(), &QueryStoredLogsRequest{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(stored.GetEntries()) != 1 || stored.GetEntries()[0].GetPod() != "web-0" || stored.GetEntries()[0].GetNamespace() != "default" {
+				t.Errorf("unexpected stored entries %v", stored.GetEntries())
+			}
+		})
+	}
+}
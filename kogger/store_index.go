@@ -0,0 +1,338 @@
+package kogger
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	grpctoken "github.com/ZolaraProject/library/grpctoken"
+	logger "github.com/ZolaraProject/library/logger"
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+)
+
+const (
+	// indexSuffix is appended to the segment path to name its index.
+	indexSuffix = ".idx"
+	// indexMagic starts the index files, followed by the format version.
+	indexMagic = "KIDX2"
+	// maxIndexedToken is the length above which tokens are not indexed. Such
+	// tokens are still matched, by scanning the segments.
+	maxIndexedToken = 128
+)
+
+// segmentIndex is the inverted index of a segment. Entries are numbered in
+// the order they appear in the segment, and the postings list the entries
+// holding each token. Size is the size of the segment when it was indexed,
+// which tells whether the index is up to date.
+type segmentIndex struct {
+	size     int64
+	entries  int
+	tokens   int
+	postings map[string][]int
+}
+
+func newSegmentIndex() *segmentIndex {
+	return &segmentIndex{postings: map[string][]int{}}
+}
+
+// add indexes the next entry of the segment, its message and the string
+// values of its structured fields.
+func (idx *segmentIndex) add(entry *LogEntry) {
+	ordinal := idx.entries
+	idx.entries++
+
+	for _, t := range entryTokens(entry) {
+		idx.tokens++
+		if len(t) > maxIndexedToken {
+			continue
+		}
+		postings := idx.postings[t]
+		if len(postings) == 0 || postings[len(postings)-1] != ordinal {
+			idx.postings[t] = append(postings, ordinal)
+		}
+	}
+}
+
+// candidates returns the entries which may hold every phrase, or nil when
+// the index cannot narrow the search.
+func (idx *segmentIndex) candidates(phrases [][]string) map[int]bool {
+	var result map[int]bool
+	for _, phrase := range phrases {
+		for _, t := range phrase {
+			if len(t) > maxIndexedToken {
+				continue
+			}
+			next := map[int]bool{}
+			for _, ordinal := range idx.postings[t] {
+				if result == nil || result[ordinal] {
+					next[ordinal] = true
+				}
+			}
+			result = next
+		}
+	}
+	return result
+}
+
+func indexPath(segmentPath string) string {
+	return segmentPath + indexSuffix
+}
+
+// buildSegmentIndex indexes a whole segment.
+func buildSegmentIndex(path string) (*segmentIndex, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := newSegmentIndex()
+	if err := readSegment(path, idx.add); err != nil {
+		return nil, err
+	}
+	idx.size = info.Size()
+	return idx, nil
+}
+
+// loadSegmentIndex reads the index of a segment, rebuilding and saving it when
+// it is missing or out of date.
+func loadSegmentIndex(path string, size int64) (*segmentIndex, error) {
+	idx, err := readSegmentIndex(indexPath(path))
+	if err == nil && idx.size == size {
+		return idx, nil
+	}
+
+	if idx, err = buildSegmentIndex(path); err != nil {
+		return nil, err
+	}
+	return idx, writeSegmentIndex(indexPath(path), idx)
+}
+
+// writeSegmentIndex saves an index as the header, then each token followed by
+// its postings, delta encoded. All numbers are uvarints.
+func writeSegmentIndex(path string, idx *segmentIndex) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".idx-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	tokens := make([]string, 0, len(idx.postings))
+	for t := range idx.postings {
+		tokens = append(tokens, t)
+	}
+	sort.Strings(tokens)
+
+	writer := bufio.NewWriter(tmp)
+	buf := []byte(indexMagic)
+	buf = binary.AppendUvarint(buf, uint64(idx.size))
+	buf = binary.AppendUvarint(buf, uint64(idx.entries))
+	buf = binary.AppendUvarint(buf, uint64(idx.tokens))
+	buf = binary.AppendUvarint(buf, uint64(len(tokens)))
+	for _, t := range tokens {
+		buf = binary.AppendUvarint(buf, uint64(len(t)))
+		buf = append(buf, t...)
+		buf = binary.AppendUvarint(buf, uint64(len(idx.postings[t])))
+		previous := 0
+		for _, ordinal := range idx.postings[t] {
+			buf = binary.AppendUvarint(buf, uint64(ordinal-previous))
+			previous = ordinal
+		}
+		if _, err := writer.Write(buf); err != nil {
+			return err
+		}
+		buf = buf[:0]
+	}
+	if _, err := writer.Write(buf); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func readSegmentIndex(path string) (*segmentIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+
+	magic := make([]byte, len(indexMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != indexMagic {
+		return nil, fmt.Errorf("invalid index %s", path)
+	}
+
+	header := make([]uint64, 4)
+	for i := range header {
+		if header[i], err = binary.ReadUvarint(reader); err != nil {
+			return nil, err
+		}
+	}
+	idx := &segmentIndex{
+		size:     int64(header[0]),
+		entries:  int(header[1]),
+		tokens:   int(header[2]),
+		postings: make(map[string][]int, header[3]),
+	}
+
+	for i := uint64(0); i < header[3]; i++ {
+		length, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, err
+		}
+		if length > maxIndexedToken {
+			return nil, fmt.Errorf("invalid index %s", path)
+		}
+		t := make([]byte, length)
+		if _, err := io.ReadFull(reader, t); err != nil {
+			return nil, err
+		}
+
+		count, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, err
+		}
+		if count > uint64(idx.entries) {
+			return nil, fmt.Errorf("invalid index %s", path)
+		}
+		postings := make([]int, count)
+		ordinal := 0
+		for j := range postings {
+			delta, err := binary.ReadUvarint(reader)
+			if err != nil {
+				return nil, err
+			}
+			ordinal += int(delta)
+			postings[j] = ordinal
+		}
+		idx.postings[string(t)] = postings
+	}
+	return idx, nil
+}
+
+func (s *filesystemLogStore) Search(ctx context.Context, query *LogSearchQuery) (*LogSearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	namespaces := query.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+	segments := []segment{}
+	for _, namespace := range namespaces {
+		found, err := s.segments(namespace)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, found...)
+	}
+
+	queryTokens := []string{}
+	for _, phrase := range query.Phrases {
+		queryTokens = append(queryTokens, phrase...)
+	}
+
+	result := &LogSearchResult{Hits: []*LogSearchHit{}}
+	for _, seg := range segments {
+		if !query.Since.IsZero() && !seg.partition.Add(time.Hour).After(query.Since) {
+			continue
+		}
+		if !query.Until.IsZero() && seg.partition.After(query.Until) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		idx, err := loadSegmentIndex(seg.path, seg.size-seg.indexSize)
+		if err != nil {
+			logger.Warn(grpctoken.GetToken(ctx), "Failed to index segment %s: %s", seg.path, err)
+			continue
+		}
+		candidates := idx.candidates(query.Phrases)
+		if candidates != nil && len(candidates) == 0 {
+			result.SkippedSegments++
+			continue
+		}
+		result.ScannedSegments++
+
+		frequencies := map[string]int{}
+		for _, t := range queryTokens {
+			frequencies[t] = len(idx.postings[t])
+		}
+		averageLength := 0.0
+		if idx.entries > 0 {
+			averageLength = float64(idx.tokens) / float64(idx.entries)
+		}
+
+		ordinal := -1
+		err = readSegment(seg.path, func(entry *LogEntry) {
+			ordinal++
+			if candidates != nil && !candidates[ordinal] {
+				return
+			}
+			if entry.GetTime() != nil {
+				at := entry.GetTime().AsTime()
+				if (!query.Since.IsZero() && at.Before(query.Since)) || (!query.Until.IsZero() && at.After(query.Until)) {
+					return
+				}
+			}
+
+			texts := [][]string{}
+			tokens := []string{}
+			for _, text := range entryTexts(entry) {
+				texts = append(texts, tokenTexts(text))
+				tokens = append(tokens, texts[len(texts)-1]...)
+			}
+			for _, phrase := range query.Phrases {
+				// Phrases do not span the message and the fields.
+				if !slices.ContainsFunc(texts, func(text []string) bool { return containsPhrase(text, phrase) }) {
+					return
+				}
+			}
+			result.Hits = append(result.Hits, &LogSearchHit{
+				Namespace: seg.namespace,
+				Entry:     entry,
+				Score:     bm25(tokens, queryTokens, idx.entries, averageLength, frequencies),
+			})
+		})
+		if err != nil {
+			logger.Warn(grpctoken.GetToken(ctx), "Partially read segment %s: %s", seg.path, err)
+		}
+
+		// Keep the memory bounded on queries matching a lot of entries.
+		if query.Limit > 0 && len(result.Hits) > 2*query.Limit {
+			sortSearchHits(result.Hits)
+			result.Hits = result.Hits[:query.Limit]
+		}
+	}
+
+	sortSearchHits(result.Hits)
+	if query.Limit > 0 && len(result.Hits) > query.Limit {
+		result.Hits = result.Hits[:query.Limit]
+	}
+	return result, nil
+}
+
+// sortSearchHits orders the hits by decreasing score, then most recent first.
+func sortSearchHits(hits []*LogSearchHit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Entry.GetTime().AsTime().After(hits[j].Entry.GetTime().AsTime())
+	})
+}
@@ -0,0 +1,81 @@
+package kogger
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestFilesystemLogStoreSearchFields(t *testing.T) {
+	store, err := newFilesystemLogStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create store: %s", err)
+	}
+
+	fields, err := structpb.NewStruct(map[string]any{
+		"msg":     "user logged in",
+		"user":    "alice",
+		"request": map[string]any{"path": "/api/orders", "tags": []any{"checkout flow"}},
+		"status":  200.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := timestamppb.New(time.Now().Add(-time.Minute))
+	logs := &Logs{
+		Namespace: "default",
+		Pod:       "web-0",
+		Entries: []*LogEntry{
+			{Time: at, Container: "app", Message: "user logged in", Fields: fields},
+			{Time: at, Container: "app", Message: "cache warmed for alice"},
+		},
+	}
+	if err := store.Append(context.Background(), logs); err != nil {
+		t.Fatalf("failed to append: %s", err)
+	}
+
+	tests := []struct {
+		query    string
+		messages []string
+	}{
+		{`logged`, []string{"user logged in"}},
+		{`alice`, []string{"cache warmed for alice", "user logged in"}},
+		{`orders`, []string{"user logged in"}},
+		{`"checkout flow"`, []string{"user logged in"}},
+		{`"in alice"`, nil},
+		{`200`, nil},
+		{`user alice warmed`, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			phrases, err := parseSearchQuery(test.query)
+			if err != nil {
+				t.Fatalf("invalid query: %s", err)
+			}
+			result, err := store.Search(context.Background(), &LogSearchQuery{Phrases: phrases})
+			if err != nil {
+				t.Fatalf("failed to search: %s", err)
+			}
+
+			messages := []string{}
+			for _, hit := range result.Hits {
+				messages = append(messages, hit.Entry.GetMessage())
+			}
+			sort.Strings(messages)
+			if len(messages) != len(test.messages) {
+				t.Fatalf("expected hits %v, got %v", test.messages, messages)
+			}
+			for i := range messages {
+				if messages[i] != test.messages[i] {
+					t.Errorf("expected hits %v, got %v", test.messages, messages)
+				}
+			}
+		})
+	}
+}
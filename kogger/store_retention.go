@@ -0,0 +1,232 @@
+package kogger
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/ZolaraProject/library/logger"
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// compactorToken tags the log lines of the compactor, which runs outside of
+// any gRPC call.
+const compactorToken = "compactor"
+
+// RetentionPolicy bounds the logs kept by a store. Zero values disable a limit.
+type RetentionPolicy struct {
+	// MaxAge is how long logs are kept.
+	MaxAge time.Duration
+	// MaxNamespaceBytes is the space the logs of a single namespace may use.
+	MaxNamespaceBytes int64
+	// MaxTotalBytes is the space the whole store may use.
+	MaxTotalBytes int64
+	// MaxVolumeUsage is the percentage of the storage volume which may be
+	// used, for stores backed by a volume.
+	MaxVolumeUsage int
+	// DownsampleAfter is the age after which the entries below
+	// DownsampleLevel are dropped.
+	DownsampleAfter time.Duration
+	DownsampleLevel LogLevel
+}
+
+// volumeStore is implemented by the stores which know the usage of their
+// storage volume.
+type volumeStore interface {
+	volumeUsage() (used int64, capacity int64, err error)
+}
+
+// newRetentionPolicy reads the policy from the KOGGER_STORAGE_* variables.
+// Byte sizes accept quantities such as 10Gi.
+func newRetentionPolicy() (*RetentionPolicy, error) {
+	policy := &RetentionPolicy{
+		DownsampleLevel: LogLevel_LOG_LEVEL_INFO,
+	}
+
+	var err error
+	if policy.MaxAge, err = parseDurationEnv("KOGGER_STORAGE_MAX_AGE"); err != nil {
+		return nil, err
+	}
+	if policy.MaxNamespaceBytes, err = parseBytesEnv("KOGGER_STORAGE_MAX_NAMESPACE_BYTES"); err != nil {
+		return nil, err
+	}
+	if policy.MaxTotalBytes, err = parseBytesEnv("KOGGER_STORAGE_MAX_TOTAL_BYTES"); err != nil {
+		return nil, err
+	}
+	if policy.DownsampleAfter, err = parseDurationEnv("KOGGER_STORAGE_DOWNSAMPLE_AFTER"); err != nil {
+		return nil, err
+	}
+
+	if usage := getEnv("KOGGER_STORAGE_MAX_USAGE", ""); len(usage) > 0 {
+		percent, err := strconv.Atoi(usage)
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("invalid KOGGER_STORAGE_MAX_USAGE %q, expected a percentage", usage)
+		}
+		policy.MaxVolumeUsage = percent
+	}
+
+	if level := getEnv("KOGGER_STORAGE_DOWNSAMPLE_LEVEL", ""); len(level) > 0 {
+		parsed, ok := logLevels[strings.ToLower(level)]
+		if !ok {
+			return nil, fmt.Errorf("invalid KOGGER_STORAGE_DOWNSAMPLE_LEVEL %q", level)
+		}
+		policy.DownsampleLevel = parsed
+	}
+
+	return policy, nil
+}
+
+func parseDurationEnv(key string) (time.Duration, error) {
+	value := getEnv(key, "")
+	if len(value) == 0 {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a duration such as 168h", key, value)
+	}
+	return duration, nil
+}
+
+func parseBytesEnv(key string) (int64, error) {
+	value := getEnv(key, "")
+	if len(value) == 0 {
+		return 0, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil || quantity.Sign() < 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a size such as 10Gi", key, value)
+	}
+	return quantity.Value(), nil
+}
+
+// runCompactor enforces the policy on the store every interval until ctx is
+// done.
+func runCompactor(ctx context.Context, store LogStore, policy *RetentionPolicy, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := compactLogStore(ctx, store, policy); err != nil && ctx.Err() == nil {
+			logger.Err(compactorToken, "Log store compaction failed: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// compactLogStore enforces the policy once. Expired logs are deleted and old
+// logs downsampled first, then the oldest hours are deleted until the store
+// fits in its quotas.
+func compactLogStore(ctx context.Context, store LogStore, policy *RetentionPolicy) error {
+	now := time.Now()
+
+	if policy.MaxAge > 0 {
+		freed, err := store.Delete(ctx, "", now.Add(-policy.MaxAge))
+		if err != nil {
+			return err
+		}
+		if freed > 0 {
+			logger.Info(compactorToken, "Deleted %d bytes of logs older than %s", freed, policy.MaxAge)
+		}
+	}
+
+	if policy.DownsampleAfter > 0 {
+		freed, err := store.Downsample(ctx, "", now.Add(-policy.DownsampleAfter), policy.DownsampleLevel)
+		if err != nil {
+			return err
+		}
+		if freed > 0 {
+			logger.Info(compactorToken, "Downsampled logs older than %s to %s, freed %d bytes", policy.DownsampleAfter, policy.DownsampleLevel, freed)
+		}
+	}
+
+	stats, err := store.Stats(ctx)
+	if err != nil {
+		return err
+	}
+
+	if policy.MaxNamespaceBytes > 0 {
+		for namespace, ns := range stats.Namespaces {
+			if ns.Bytes <= policy.MaxNamespaceBytes {
+				continue
+			}
+			freed, err := trimLogStore(ctx, store, namespace, ns.Bytes-policy.MaxNamespaceBytes)
+			if err != nil {
+				return err
+			}
+			logger.Info(compactorToken, "Namespace %s used %d bytes out of %d, deleted its oldest %d bytes", namespace, ns.Bytes, policy.MaxNamespaceBytes, freed)
+		}
+
+		if stats, err = store.Stats(ctx); err != nil {
+			return err
+		}
+	}
+
+	// A volume limit may drop to zero, which then deletes every log.
+	limit, limited := policy.MaxTotalBytes, policy.MaxTotalBytes > 0
+	if policy.MaxVolumeUsage > 0 {
+		if volume, ok := store.(volumeStore); ok {
+			used, capacity, err := volume.volumeUsage()
+			if err != nil {
+				return err
+			}
+			// Other files may share the volume, only the logs can be freed.
+			allowed := capacity * int64(policy.MaxVolumeUsage) / 100
+			if volumeLimit := stats.Bytes - (used - allowed); used > allowed && (!limited || volumeLimit < limit) {
+				limit, limited = max(volumeLimit, 0), true
+				logger.Warn(compactorToken, "Storage volume is %d%% used, above the %d%% allowed", used*100/capacity, policy.MaxVolumeUsage)
+			}
+		}
+	}
+
+	if limited && stats.Bytes > limit {
+		freed, err := trimLogStore(ctx, store, "", stats.Bytes-limit)
+		if err != nil {
+			return err
+		}
+		logger.Info(compactorToken, "Log store used %d bytes out of %d, deleted the oldest %d bytes", stats.Bytes, limit, freed)
+	}
+
+	return nil
+}
+
+// trimLogStore deletes the oldest hours of logs of namespace, or of the whole
+// store when empty, until at least excess bytes are freed.
+func trimLogStore(ctx context.Context, store LogStore, namespace string, excess int64) (int64, error) {
+	freed := int64(0)
+	for freed < excess {
+		stats, err := store.Stats(ctx)
+		if err != nil {
+			return freed, err
+		}
+
+		oldest := time.Time{}
+		for name, ns := range stats.Namespaces {
+			if (len(namespace) == 0 || name == namespace) && (oldest.IsZero() || ns.Oldest.Before(oldest)) {
+				oldest = ns.Oldest
+			}
+		}
+		if oldest.IsZero() {
+			return freed, nil
+		}
+
+		deleted, err := store.Delete(ctx, namespace, oldest.Add(time.Hour))
+		if err != nil {
+			return freed, err
+		}
+		if deleted == 0 {
+			return freed, nil
+		}
+		freed += deleted
+	}
+	return freed, nil
+}
@@ -0,0 +1,173 @@
+package kogger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fakeVolumeStore is a store of hourly partitions of a given size on a volume
+// shared with other files. Only the methods used by the compactor are
+// implemented.
+type fakeVolumeStore struct {
+	LogStore
+
+	// partitions maps the namespaces to the size of their partitions.
+	partitions map[string]map[time.Time]int64
+	// other is the space used by other files on the volume.
+	other    int64
+	capacity int64
+}
+
+func (s *fakeVolumeStore) Delete(ctx context.Context, namespace string, before time.Time) (int64, error) {
+	freed := int64(0)
+	for name, partitions := range s.partitions {
+		if len(namespace) > 0 && name != namespace {
+			continue
+		}
+		for partition, size := range partitions {
+			if !partition.Add(time.Hour).After(before) {
+				freed += size
+				delete(partitions, partition)
+			}
+		}
+	}
+	return freed, nil
+}
+
+func (s *fakeVolumeStore) Stats(ctx context.Context) (*LogStoreStats, error) {
+	stats := &LogStoreStats{Namespaces: map[string]*NamespaceStoreStats{}}
+	for name, partitions := range s.partitions {
+		for partition, size := range partitions {
+			ns, ok := stats.Namespaces[name]
+			if !ok {
+				ns = &NamespaceStoreStats{Oldest: partition, Newest: partition.Add(time.Hour)}
+				stats.Namespaces[name] = ns
+			}
+			ns.Bytes += size
+			ns.Segments++
+			if partition.Before(ns.Oldest) {
+				ns.Oldest = partition
+			}
+			if partition.Add(time.Hour).After(ns.Newest) {
+				ns.Newest = partition.Add(time.Hour)
+			}
+			stats.Bytes += size
+			stats.Segments++
+		}
+	}
+	return stats, nil
+}
+
+func (s *fakeVolumeStore) volumeUsage() (int64, int64, error) {
+	stats, _ := s.Stats(context.Background())
+	return s.other + stats.Bytes, s.capacity, nil
+}
+
+// kept lists the partitions left, as namespace/hours ago.
+func (s *fakeVolumeStore) kept(now time.Time) []string {
+	kept := []string{}
+	for name, partitions := range s.partitions {
+		for partition := range partitions {
+			kept = append(kept, fmt.Sprintf("%s/%d", name, int(now.Sub(partition).Hours())))
+		}
+	}
+	sort.Strings(kept)
+	return kept
+}
+
+func TestCompactLogStore(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Hour)
+	hoursAgo := func(hours int) time.Time {
+		return now.Add(-time.Duration(hours) * time.Hour)
+	}
+	// Every test starts from 3 hours of 100 bytes in a, and 1 in b.
+	partitions := func() map[string]map[time.Time]int64 {
+		return map[string]map[time.Time]int64{
+			"a": {hoursAgo(72): 100, hoursAgo(24): 100, hoursAgo(1): 100},
+			"b": {hoursAgo(2): 100},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		other  int64
+		kept   string
+	}{
+		{
+			name:   "no limit",
+			policy: RetentionPolicy{},
+			kept:   "[a/1 a/24 a/72 b/2]",
+		},
+		{
+			name:   "age cutoff",
+			policy: RetentionPolicy{MaxAge: 48 * time.Hour},
+			kept:   "[a/1 a/24 b/2]",
+		},
+		{
+			name:   "age cutoff within an hour",
+			policy: RetentionPolicy{MaxAge: 22*time.Hour + 30*time.Minute},
+			kept:   "[a/1 b/2]",
+		},
+		{
+			name:   "namespace quota",
+			policy: RetentionPolicy{MaxNamespaceBytes: 150},
+			kept:   "[a/1 b/2]",
+		},
+		{
+			name:   "namespace within its quota",
+			policy: RetentionPolicy{MaxNamespaceBytes: 300},
+			kept:   "[a/1 a/24 a/72 b/2]",
+		},
+		{
+			name:   "total quota",
+			policy: RetentionPolicy{MaxTotalBytes: 250},
+			kept:   "[a/1 b/2]",
+		},
+		{
+			name:   "volume overage",
+			policy: RetentionPolicy{MaxVolumeUsage: 50},
+			other:  300,
+			kept:   "[a/1 b/2]",
+		},
+		{
+			name:   "volume overage below the total quota",
+			policy: RetentionPolicy{MaxTotalBytes: 300, MaxVolumeUsage: 50},
+			other:  300,
+			kept:   "[a/1 b/2]",
+		},
+		{
+			name:   "volume within its usage",
+			policy: RetentionPolicy{MaxVolumeUsage: 50},
+			other:  100,
+			kept:   "[a/1 a/24 a/72 b/2]",
+		},
+		{
+			name:   "volume full of other files",
+			policy: RetentionPolicy{MaxVolumeUsage: 50},
+			other:  900,
+			kept:   "[]",
+		},
+		{
+			name:   "volume full of other files with a total quota",
+			policy: RetentionPolicy{MaxTotalBytes: 1000, MaxVolumeUsage: 50},
+			other:  500,
+			kept:   "[]",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &fakeVolumeStore{partitions: partitions(), other: test.other, capacity: 1000}
+			if err := compactLogStore(context.Background(), store, &test.policy); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if kept := fmt.Sprint(store.kept(now)); kept != test.kept {
+				t.Errorf("expected %s to be kept, got %s", test.kept, kept)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package kogger
+
+import (
+	"context"
+	"fmt"
+
+	logger "github.com/ZolaraProject/library/logger"
+	. "github.com/k-ogger/kogger-service/koggerservicerpc"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// workloadPods returns the pods managed by a workload. Pods are first listed
+// through the workload selector, then only the ones controlled by the
+// workload (or by one of its ReplicaSets for a Deployment) are kept, so pods of
+// another workload sharing the same labels are left out.
+func workloadPods(ctx context.Context, grpcToken, namespace string, resourceType ResourceType, name string) ([]v1.Pod, error) {
+	var selector *metav1.LabelSelector
+	owners := make(map[types.UID]bool)
+
+	switch resourceType {
+	case ResourceType_RESOURCE_TYPE_DEPLOYMENT:
+		deployment, err := Clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = deployment.Spec.Selector
+
+		labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return nil, err
+		}
+		replicasets, err := Clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: labelSelector.String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, replicaset := range replicasets.Items {
+			if owner := metav1.GetControllerOf(&replicaset); owner != nil && owner.UID == deployment.UID {
+				owners[replicaset.UID] = true
+			}
+		}
+	case ResourceType_RESOURCE_TYPE_STATEFULSET:
+		statefulset, err := Clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = statefulset.Spec.Selector
+		owners[statefulset.UID] = true
+	case ResourceType_RESOURCE_TYPE_DAEMONSET:
+		daemonset, err := Clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = daemonset.Spec.Selector
+		owners[daemonset.UID] = true
+	case ResourceType_RESOURCE_TYPE_REPLICASET:
+		replicaset, err := Clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = replicaset.Spec.Selector
+		owners[replicaset.UID] = true
+	case ResourceType_RESOURCE_TYPE_JOB:
+		job, err := Clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		selector = job.Spec.Selector
+		owners[job.UID] = true
+	default:
+		return nil, fmt.Errorf("unsupported workload type: %s", resourceType)
+	}
+
+	if selector == nil {
+		return nil, fmt.Errorf("%s %s has no selector", ResourceTypeToString(resourceType), name)
+	}
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	workloadPods := []v1.Pod{}
+	for _, pod := range pods.Items {
+		if owner := metav1.GetControllerOf(&pod); owner != nil && owners[owner.UID] {
+			workloadPods = append(workloadPods, pod)
+		}
+	}
+
+	logger.Debug(grpcToken, "Found %d pods for %s %s in namespace %s", len(workloadPods), ResourceTypeToString(resourceType), name, namespace)
+	return workloadPods, nil
+}
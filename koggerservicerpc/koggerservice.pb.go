@@ -10,6 +10,7 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -119,6 +120,171 @@ func (ResourceType) EnumDescriptor() ([]byte, []int) {
 	return file_koggerservice_proto_rawDescGZIP(), []int{0}
 }
 
+type ContainerKind int32
+
+const (
+	ContainerKind_CONTAINER_KIND_UNKNOWN   ContainerKind = 0
+	ContainerKind_CONTAINER_KIND_REGULAR   ContainerKind = 1
+	ContainerKind_CONTAINER_KIND_INIT      ContainerKind = 2
+	ContainerKind_CONTAINER_KIND_SIDECAR   ContainerKind = 3
+	ContainerKind_CONTAINER_KIND_EPHEMERAL ContainerKind = 4
+)
+
+// Enum value maps for ContainerKind.
+var (
+	ContainerKind_name = map[int32]string{
+		0: "CONTAINER_KIND_UNKNOWN",
+		1: "CONTAINER_KIND_REGULAR",
+		2: "CONTAINER_KIND_INIT",
+		3: "CONTAINER_KIND_SIDECAR",
+		4: "CONTAINER_KIND_EPHEMERAL",
+	}
+	ContainerKind_value = map[string]int32{
+		"CONTAINER_KIND_UNKNOWN":   0,
+		"CONTAINER_KIND_REGULAR":   1,
+		"CONTAINER_KIND_INIT":      2,
+		"CONTAINER_KIND_SIDECAR":   3,
+		"CONTAINER_KIND_EPHEMERAL": 4,
+	}
+)
+
+func (x ContainerKind) Enum() *ContainerKind {
+	p := new(ContainerKind)
+	*p = x
+	return p
+}
+
+func (x ContainerKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ContainerKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_koggerservice_proto_enumTypes[1].Descriptor()
+}
+
+func (ContainerKind) Type() protoreflect.EnumType {
+	return &file_koggerservice_proto_enumTypes[1]
+}
+
+func (x ContainerKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ContainerKind.Descriptor instead.
+func (ContainerKind) EnumDescriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{1}
+}
+
+type LogLevel int32
+
+const (
+	LogLevel_LOG_LEVEL_UNKNOWN LogLevel = 0
+	LogLevel_LOG_LEVEL_TRACE   LogLevel = 1
+	LogLevel_LOG_LEVEL_DEBUG   LogLevel = 2
+	LogLevel_LOG_LEVEL_INFO    LogLevel = 3
+	LogLevel_LOG_LEVEL_WARN    LogLevel = 4
+	LogLevel_LOG_LEVEL_ERROR   LogLevel = 5
+	LogLevel_LOG_LEVEL_FATAL   LogLevel = 6
+)
+
+// Enum value maps for LogLevel.
+var (
+	LogLevel_name = map[int32]string{
+		0: "LOG_LEVEL_UNKNOWN",
+		1: "LOG_LEVEL_TRACE",
+		2: "LOG_LEVEL_DEBUG",
+		3: "LOG_LEVEL_INFO",
+		4: "LOG_LEVEL_WARN",
+		5: "LOG_LEVEL_ERROR",
+		6: "LOG_LEVEL_FATAL",
+	}
+	LogLevel_value = map[string]int32{
+		"LOG_LEVEL_UNKNOWN": 0,
+		"LOG_LEVEL_TRACE":   1,
+		"LOG_LEVEL_DEBUG":   2,
+		"LOG_LEVEL_INFO":    3,
+		"LOG_LEVEL_WARN":    4,
+		"LOG_LEVEL_ERROR":   5,
+		"LOG_LEVEL_FATAL":   6,
+	}
+)
+
+func (x LogLevel) Enum() *LogLevel {
+	p := new(LogLevel)
+	*p = x
+	return p
+}
+
+func (x LogLevel) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LogLevel) Descriptor() protoreflect.EnumDescriptor {
+	return file_koggerservice_proto_enumTypes[2].Descriptor()
+}
+
+func (LogLevel) Type() protoreflect.EnumType {
+	return &file_koggerservice_proto_enumTypes[2]
+}
+
+func (x LogLevel) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LogLevel.Descriptor instead.
+func (LogLevel) EnumDescriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{2}
+}
+
+type Compression int32
+
+const (
+	Compression_COMPRESSION_UNKNOWN Compression = 0
+	Compression_COMPRESSION_GZIP    Compression = 1
+	Compression_COMPRESSION_ZSTD    Compression = 2
+)
+
+// Enum value maps for Compression.
+var (
+	Compression_name = map[int32]string{
+		0: "COMPRESSION_UNKNOWN",
+		1: "COMPRESSION_GZIP",
+		2: "COMPRESSION_ZSTD",
+	}
+	Compression_value = map[string]int32{
+		"COMPRESSION_UNKNOWN": 0,
+		"COMPRESSION_GZIP":    1,
+		"COMPRESSION_ZSTD":    2,
+	}
+)
+
+func (x Compression) Enum() *Compression {
+	p := new(Compression)
+	*p = x
+	return p
+}
+
+func (x Compression) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Compression) Descriptor() protoreflect.EnumDescriptor {
+	return file_koggerservice_proto_enumTypes[3].Descriptor()
+}
+
+func (Compression) Type() protoreflect.EnumType {
+	return &file_koggerservice_proto_enumTypes[3]
+}
+
+func (x Compression) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Compression.Descriptor instead.
+func (Compression) EnumDescriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{3}
+}
+
 type Void struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -179,58 +345,610 @@ func (*ListResourcesRequest) ProtoMessage() {}
 func (x *ListResourcesRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_koggerservice_proto_msgTypes[1]
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResourcesRequest.ProtoReflect.Descriptor instead.
+func (*ListResourcesRequest) Descriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListResourcesRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ListResourcesRequest) GetResourceType() string {
+	if x != nil {
+		return x.ResourceType
+	}
+	return ""
+}
+
+type ResourceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	ResourceType  ResourceType           `protobuf:"varint,2,opt,name=resourceType,proto3,enum=koggerservicerpc.ResourceType" json:"resourceType,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	TailLines     int64                  `protobuf:"varint,4,opt,name=tailLines,proto3" json:"tailLines,omitempty"`
+	SinceSeconds  int64                  `protobuf:"varint,5,opt,name=sinceSeconds,proto3" json:"sinceSeconds,omitempty"`
+	SinceTime     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=sinceTime,proto3" json:"sinceTime,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResourceRequest) Reset() {
+	*x = ResourceRequest{}
+	mi := &file_koggerservice_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceRequest) ProtoMessage() {}
+
+func (x *ResourceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_koggerservice_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceRequest.ProtoReflect.Descriptor instead.
+func (*ResourceRequest) Descriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ResourceRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ResourceRequest) GetResourceType() ResourceType {
+	if x != nil {
+		return x.ResourceType
+	}
+	return ResourceType_RESOURCE_TYPE_UNKNOWN
+}
+
+func (x *ResourceRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ResourceRequest) GetTailLines() int64 {
+	if x != nil {
+		return x.TailLines
+	}
+	return 0
+}
+
+func (x *ResourceRequest) GetSinceSeconds() int64 {
+	if x != nil {
+		return x.SinceSeconds
+	}
+	return 0
+}
+
+func (x *ResourceRequest) GetSinceTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SinceTime
+	}
+	return nil
+}
+
+type PodsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PodsRequest) Reset() {
+	*x = PodsRequest{}
+	mi := &file_koggerservice_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PodsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PodsRequest) ProtoMessage() {}
+
+func (x *PodsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_koggerservice_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PodsRequest.ProtoReflect.Descriptor instead.
+func (*PodsRequest) Descriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PodsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+type LogsRequest struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Namespace            string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pod                  string                 `protobuf:"bytes,2,opt,name=pod,proto3" json:"pod,omitempty"`
+	Container            string                 `protobuf:"bytes,3,opt,name=container,proto3" json:"container,omitempty"`
+	TailLines            int64                  `protobuf:"varint,4,opt,name=tailLines,proto3" json:"tailLines,omitempty"`
+	SinceSeconds         int64                  `protobuf:"varint,5,opt,name=sinceSeconds,proto3" json:"sinceSeconds,omitempty"`
+	SinceTime            *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=sinceTime,proto3" json:"sinceTime,omitempty"`
+	LimitBytes           int64                  `protobuf:"varint,7,opt,name=limitBytes,proto3" json:"limitBytes,omitempty"`
+	Previous             bool                   `protobuf:"varint,8,opt,name=previous,proto3" json:"previous,omitempty"`
+	LabelSelector        string                 `protobuf:"bytes,9,opt,name=labelSelector,proto3" json:"labelSelector,omitempty"`
+	FieldSelector        string                 `protobuf:"bytes,10,opt,name=fieldSelector,proto3" json:"fieldSelector,omitempty"`
+	IncludeRegex         string                 `protobuf:"bytes,11,opt,name=includeRegex,proto3" json:"includeRegex,omitempty"`
+	ExcludeRegex         string                 `protobuf:"bytes,12,opt,name=excludeRegex,proto3" json:"excludeRegex,omitempty"`
+	IncludeSubstrings    []string               `protobuf:"bytes,13,rep,name=includeSubstrings,proto3" json:"includeSubstrings,omitempty"`
+	ExcludeSubstrings    []string               `protobuf:"bytes,14,rep,name=excludeSubstrings,proto3" json:"excludeSubstrings,omitempty"`
+	MinLevel             LogLevel               `protobuf:"varint,15,opt,name=minLevel,proto3,enum=koggerservicerpc.LogLevel" json:"minLevel,omitempty"`
+	Multiline            bool                   `protobuf:"varint,16,opt,name=multiline,proto3" json:"multiline,omitempty"`
+	ContinuationPatterns []string               `protobuf:"bytes,17,rep,name=continuationPatterns,proto3" json:"continuationPatterns,omitempty"`
+	PageSize             int32                  `protobuf:"varint,18,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	Cursor               string                 `protobuf:"bytes,19,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *LogsRequest) Reset() {
+	*x = LogsRequest{}
+	mi := &file_koggerservice_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogsRequest) ProtoMessage() {}
+
+func (x *LogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_koggerservice_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogsRequest.ProtoReflect.Descriptor instead.
+func (*LogsRequest) Descriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LogsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *LogsRequest) GetPod() string {
+	if x != nil {
+		return x.Pod
+	}
+	return ""
+}
+
+func (x *LogsRequest) GetContainer() string {
+	if x != nil {
+		return x.Container
+	}
+	return ""
+}
+
+func (x *LogsRequest) GetTailLines() int64 {
+	if x != nil {
+		return x.TailLines
+	}
+	return 0
+}
+
+func (x *LogsRequest) GetSinceSeconds() int64 {
+	if x != nil {
+		return x.SinceSeconds
+	}
+	return 0
+}
+
+func (x *LogsRequest) GetSinceTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SinceTime
+	}
+	return nil
+}
+
+func (x *LogsRequest) GetLimitBytes() int64 {
+	if x != nil {
+		return x.LimitBytes
+	}
+	return 0
+}
+
+func (x *LogsRequest) GetPrevious() bool {
+	if x != nil {
+		return x.Previous
+	}
+	return false
+}
+
+func (x *LogsRequest) GetLabelSelector() string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return ""
+}
+
+func (x *LogsRequest) GetFieldSelector() string {
+	if x != nil {
+		return x.FieldSelector
+	}
+	return ""
+}
+
+func (x *LogsRequest) GetIncludeRegex() string {
+	if x != nil {
+		return x.IncludeRegex
+	}
+	return ""
+}
+
+func (x *LogsRequest) GetExcludeRegex() string {
+	if x != nil {
+		return x.ExcludeRegex
+	}
+	return ""
+}
+
+func (x *LogsRequest) GetIncludeSubstrings() []string {
+	if x != nil {
+		return x.IncludeSubstrings
+	}
+	return nil
+}
+
+func (x *LogsRequest) GetExcludeSubstrings() []string {
+	if x != nil {
+		return x.ExcludeSubstrings
+	}
+	return nil
+}
+
+func (x *LogsRequest) GetMinLevel() LogLevel {
+	if x != nil {
+		return x.MinLevel
+	}
+	return LogLevel_LOG_LEVEL_UNKNOWN
+}
+
+func (x *LogsRequest) GetMultiline() bool {
+	if x != nil {
+		return x.Multiline
+	}
+	return false
+}
+
+func (x *LogsRequest) GetContinuationPatterns() []string {
+	if x != nil {
+		return x.ContinuationPatterns
+	}
+	return nil
+}
+
+func (x *LogsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *LogsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type DownloadLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pod           string                 `protobuf:"bytes,2,opt,name=pod,proto3" json:"pod,omitempty"`
+	ResourceType  ResourceType           `protobuf:"varint,3,opt,name=resourceType,proto3,enum=koggerservicerpc.ResourceType" json:"resourceType,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Compression   Compression            `protobuf:"varint,5,opt,name=compression,proto3,enum=koggerservicerpc.Compression" json:"compression,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadLogsRequest) Reset() {
+	*x = DownloadLogsRequest{}
+	mi := &file_koggerservice_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadLogsRequest) ProtoMessage() {}
+
+func (x *DownloadLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_koggerservice_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadLogsRequest.ProtoReflect.Descriptor instead.
+func (*DownloadLogsRequest) Descriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DownloadLogsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *DownloadLogsRequest) GetPod() string {
+	if x != nil {
+		return x.Pod
+	}
+	return ""
+}
+
+func (x *DownloadLogsRequest) GetResourceType() ResourceType {
+	if x != nil {
+		return x.ResourceType
+	}
+	return ResourceType_RESOURCE_TYPE_UNKNOWN
+}
+
+func (x *DownloadLogsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DownloadLogsRequest) GetCompression() Compression {
+	if x != nil {
+		return x.Compression
+	}
+	return Compression_COMPRESSION_UNKNOWN
+}
+
+type QueryStoredLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Pod           string                 `protobuf:"bytes,2,opt,name=pod,proto3" json:"pod,omitempty"`
+	Container     string                 `protobuf:"bytes,3,opt,name=container,proto3" json:"container,omitempty"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=since,proto3" json:"since,omitempty"`
+	Until         *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=until,proto3" json:"until,omitempty"`
+	Limit         int32                  `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryStoredLogsRequest) Reset() {
+	*x = QueryStoredLogsRequest{}
+	mi := &file_koggerservice_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryStoredLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryStoredLogsRequest) ProtoMessage() {}
+
+func (x *QueryStoredLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_koggerservice_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryStoredLogsRequest.ProtoReflect.Descriptor instead.
+func (*QueryStoredLogsRequest) Descriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *QueryStoredLogsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *QueryStoredLogsRequest) GetPod() string {
+	if x != nil {
+		return x.Pod
+	}
+	return ""
+}
+
+func (x *QueryStoredLogsRequest) GetContainer() string {
+	if x != nil {
+		return x.Container
+	}
+	return ""
+}
+
+func (x *QueryStoredLogsRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *QueryStoredLogsRequest) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+func (x *QueryStoredLogsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type SearchLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Namespaces    []string               `protobuf:"bytes,2,rep,name=namespaces,proto3" json:"namespaces,omitempty"`
+	Since         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=since,proto3" json:"since,omitempty"`
+	Until         *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=until,proto3" json:"until,omitempty"`
+	Limit         int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchLogsRequest) Reset() {
+	*x = SearchLogsRequest{}
+	mi := &file_koggerservice_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchLogsRequest) ProtoMessage() {}
+
+func (x *SearchLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_koggerservice_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchLogsRequest.ProtoReflect.Descriptor instead.
+func (*SearchLogsRequest) Descriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SearchLogsRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchLogsRequest) GetNamespaces() []string {
+	if x != nil {
+		return x.Namespaces
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use ListResourcesRequest.ProtoReflect.Descriptor instead.
-func (*ListResourcesRequest) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{1}
+func (x *SearchLogsRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
 }
 
-func (x *ListResourcesRequest) GetNamespace() string {
+func (x *SearchLogsRequest) GetUntil() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Namespace
+		return x.Until
 	}
-	return ""
+	return nil
 }
 
-func (x *ListResourcesRequest) GetResourceType() string {
+func (x *SearchLogsRequest) GetLimit() int32 {
 	if x != nil {
-		return x.ResourceType
+		return x.Limit
 	}
-	return ""
+	return 0
 }
 
-type ResourceRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
-	ResourceType  ResourceType           `protobuf:"varint,2,opt,name=resourceType,proto3,enum=koggerservicerpc.ResourceType" json:"resourceType,omitempty"`
-	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type SearchLogsResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Hits            []*SearchHit           `protobuf:"bytes,1,rep,name=hits,proto3" json:"hits,omitempty"`
+	ScannedSegments int64                  `protobuf:"varint,2,opt,name=scannedSegments,proto3" json:"scannedSegments,omitempty"`
+	SkippedSegments int64                  `protobuf:"varint,3,opt,name=skippedSegments,proto3" json:"skippedSegments,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *ResourceRequest) Reset() {
-	*x = ResourceRequest{}
-	mi := &file_koggerservice_proto_msgTypes[2]
+func (x *SearchLogsResponse) Reset() {
+	*x = SearchLogsResponse{}
+	mi := &file_koggerservice_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ResourceRequest) String() string {
+func (x *SearchLogsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResourceRequest) ProtoMessage() {}
+func (*SearchLogsResponse) ProtoMessage() {}
 
-func (x *ResourceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_koggerservice_proto_msgTypes[2]
+func (x *SearchLogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_koggerservice_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -241,54 +959,58 @@ func (x *ResourceRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResourceRequest.ProtoReflect.Descriptor instead.
-func (*ResourceRequest) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use SearchLogsResponse.ProtoReflect.Descriptor instead.
+func (*SearchLogsResponse) Descriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *ResourceRequest) GetNamespace() string {
+func (x *SearchLogsResponse) GetHits() []*SearchHit {
 	if x != nil {
-		return x.Namespace
+		return x.Hits
 	}
-	return ""
+	return nil
 }
 
-func (x *ResourceRequest) GetResourceType() ResourceType {
+func (x *SearchLogsResponse) GetScannedSegments() int64 {
 	if x != nil {
-		return x.ResourceType
+		return x.ScannedSegments
 	}
-	return ResourceType_RESOURCE_TYPE_UNKNOWN
+	return 0
 }
 
-func (x *ResourceRequest) GetName() string {
+func (x *SearchLogsResponse) GetSkippedSegments() int64 {
 	if x != nil {
-		return x.Name
+		return x.SkippedSegments
 	}
-	return ""
+	return 0
 }
 
-type PodsRequest struct {
+type SearchHit struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Entry         *LogEntry              `protobuf:"bytes,2,opt,name=entry,proto3" json:"entry,omitempty"`
+	Score         float64                `protobuf:"fixed64,3,opt,name=score,proto3" json:"score,omitempty"`
+	Snippet       string                 `protobuf:"bytes,4,opt,name=snippet,proto3" json:"snippet,omitempty"`
+	Highlights    []*TextRange           `protobuf:"bytes,5,rep,name=highlights,proto3" json:"highlights,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PodsRequest) Reset() {
-	*x = PodsRequest{}
-	mi := &file_koggerservice_proto_msgTypes[3]
+func (x *SearchHit) Reset() {
+	*x = SearchHit{}
+	mi := &file_koggerservice_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PodsRequest) String() string {
+func (x *SearchHit) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PodsRequest) ProtoMessage() {}
+func (*SearchHit) ProtoMessage() {}
 
-func (x *PodsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_koggerservice_proto_msgTypes[3]
+func (x *SearchHit) ProtoReflect() protoreflect.Message {
+	mi := &file_koggerservice_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -299,41 +1021,69 @@ func (x *PodsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PodsRequest.ProtoReflect.Descriptor instead.
-func (*PodsRequest) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use SearchHit.ProtoReflect.Descriptor instead.
+func (*SearchHit) Descriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *PodsRequest) GetNamespace() string {
+func (x *SearchHit) GetNamespace() string {
 	if x != nil {
 		return x.Namespace
 	}
 	return ""
 }
 
-type LogsRequest struct {
+func (x *SearchHit) GetEntry() *LogEntry {
+	if x != nil {
+		return x.Entry
+	}
+	return nil
+}
+
+func (x *SearchHit) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *SearchHit) GetSnippet() string {
+	if x != nil {
+		return x.Snippet
+	}
+	return ""
+}
+
+func (x *SearchHit) GetHighlights() []*TextRange {
+	if x != nil {
+		return x.Highlights
+	}
+	return nil
+}
+
+type TextRange struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
-	Pod           string                 `protobuf:"bytes,2,opt,name=pod,proto3" json:"pod,omitempty"`
+	Start         int32                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           int32                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogsRequest) Reset() {
-	*x = LogsRequest{}
-	mi := &file_koggerservice_proto_msgTypes[4]
+func (x *TextRange) Reset() {
+	*x = TextRange{}
+	mi := &file_koggerservice_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogsRequest) String() string {
+func (x *TextRange) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogsRequest) ProtoMessage() {}
+func (*TextRange) ProtoMessage() {}
 
-func (x *LogsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_koggerservice_proto_msgTypes[4]
+func (x *TextRange) ProtoReflect() protoreflect.Message {
+	mi := &file_koggerservice_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -344,23 +1094,23 @@ func (x *LogsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogsRequest.ProtoReflect.Descriptor instead.
-func (*LogsRequest) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use TextRange.ProtoReflect.Descriptor instead.
+func (*TextRange) Descriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *LogsRequest) GetNamespace() string {
+func (x *TextRange) GetStart() int32 {
 	if x != nil {
-		return x.Namespace
+		return x.Start
 	}
-	return ""
+	return 0
 }
 
-func (x *LogsRequest) GetPod() string {
+func (x *TextRange) GetEnd() int32 {
 	if x != nil {
-		return x.Pod
+		return x.End
 	}
-	return ""
+	return 0
 }
 
 type Namespaces struct {
@@ -372,7 +1122,7 @@ type Namespaces struct {
 
 func (x *Namespaces) Reset() {
 	*x = Namespaces{}
-	mi := &file_koggerservice_proto_msgTypes[5]
+	mi := &file_koggerservice_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -384,7 +1134,7 @@ func (x *Namespaces) String() string {
 func (*Namespaces) ProtoMessage() {}
 
 func (x *Namespaces) ProtoReflect() protoreflect.Message {
-	mi := &file_koggerservice_proto_msgTypes[5]
+	mi := &file_koggerservice_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -397,7 +1147,7 @@ func (x *Namespaces) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Namespaces.ProtoReflect.Descriptor instead.
 func (*Namespaces) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{5}
+	return file_koggerservice_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *Namespaces) GetNamespaces() []*Namespace {
@@ -416,7 +1166,7 @@ type Namespace struct {
 
 func (x *Namespace) Reset() {
 	*x = Namespace{}
-	mi := &file_koggerservice_proto_msgTypes[6]
+	mi := &file_koggerservice_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -428,7 +1178,7 @@ func (x *Namespace) String() string {
 func (*Namespace) ProtoMessage() {}
 
 func (x *Namespace) ProtoReflect() protoreflect.Message {
-	mi := &file_koggerservice_proto_msgTypes[6]
+	mi := &file_koggerservice_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -441,7 +1191,7 @@ func (x *Namespace) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Namespace.ProtoReflect.Descriptor instead.
 func (*Namespace) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{6}
+	return file_koggerservice_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *Namespace) GetName() string {
@@ -460,7 +1210,7 @@ type ResourceInlist struct {
 
 func (x *ResourceInlist) Reset() {
 	*x = ResourceInlist{}
-	mi := &file_koggerservice_proto_msgTypes[7]
+	mi := &file_koggerservice_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -472,7 +1222,7 @@ func (x *ResourceInlist) String() string {
 func (*ResourceInlist) ProtoMessage() {}
 
 func (x *ResourceInlist) ProtoReflect() protoreflect.Message {
-	mi := &file_koggerservice_proto_msgTypes[7]
+	mi := &file_koggerservice_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -485,7 +1235,7 @@ func (x *ResourceInlist) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ResourceInlist.ProtoReflect.Descriptor instead.
 func (*ResourceInlist) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{7}
+	return file_koggerservice_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *ResourceInlist) GetName() string {
@@ -505,7 +1255,7 @@ type ResourcesList struct {
 
 func (x *ResourcesList) Reset() {
 	*x = ResourcesList{}
-	mi := &file_koggerservice_proto_msgTypes[8]
+	mi := &file_koggerservice_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -517,7 +1267,7 @@ func (x *ResourcesList) String() string {
 func (*ResourcesList) ProtoMessage() {}
 
 func (x *ResourcesList) ProtoReflect() protoreflect.Message {
-	mi := &file_koggerservice_proto_msgTypes[8]
+	mi := &file_koggerservice_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -530,7 +1280,7 @@ func (x *ResourcesList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ResourcesList.ProtoReflect.Descriptor instead.
 func (*ResourcesList) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{8}
+	return file_koggerservice_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *ResourcesList) GetResourceType() string {
@@ -557,7 +1307,7 @@ type ResourcesResponse struct {
 
 func (x *ResourcesResponse) Reset() {
 	*x = ResourcesResponse{}
-	mi := &file_koggerservice_proto_msgTypes[9]
+	mi := &file_koggerservice_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -569,7 +1319,7 @@ func (x *ResourcesResponse) String() string {
 func (*ResourcesResponse) ProtoMessage() {}
 
 func (x *ResourcesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_koggerservice_proto_msgTypes[9]
+	mi := &file_koggerservice_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -582,7 +1332,7 @@ func (x *ResourcesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ResourcesResponse.ProtoReflect.Descriptor instead.
 func (*ResourcesResponse) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{9}
+	return file_koggerservice_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *ResourcesResponse) GetNamespace() string {
@@ -608,7 +1358,7 @@ type Resources struct {
 
 func (x *Resources) Reset() {
 	*x = Resources{}
-	mi := &file_koggerservice_proto_msgTypes[10]
+	mi := &file_koggerservice_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -620,7 +1370,7 @@ func (x *Resources) String() string {
 func (*Resources) ProtoMessage() {}
 
 func (x *Resources) ProtoReflect() protoreflect.Message {
-	mi := &file_koggerservice_proto_msgTypes[10]
+	mi := &file_koggerservice_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -633,7 +1383,7 @@ func (x *Resources) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Resources.ProtoReflect.Descriptor instead.
 func (*Resources) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{10}
+	return file_koggerservice_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *Resources) GetResources() []*Resource {
@@ -652,7 +1402,7 @@ type AdjustableFields struct {
 
 func (x *AdjustableFields) Reset() {
 	*x = AdjustableFields{}
-	mi := &file_koggerservice_proto_msgTypes[11]
+	mi := &file_koggerservice_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -664,7 +1414,7 @@ func (x *AdjustableFields) String() string {
 func (*AdjustableFields) ProtoMessage() {}
 
 func (x *AdjustableFields) ProtoReflect() protoreflect.Message {
-	mi := &file_koggerservice_proto_msgTypes[11]
+	mi := &file_koggerservice_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -677,7 +1427,7 @@ func (x *AdjustableFields) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AdjustableFields.ProtoReflect.Descriptor instead.
 func (*AdjustableFields) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{11}
+	return file_koggerservice_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *AdjustableFields) GetFields() map[string]*structpb.Value {
@@ -699,7 +1449,7 @@ type Resource struct {
 
 func (x *Resource) Reset() {
 	*x = Resource{}
-	mi := &file_koggerservice_proto_msgTypes[12]
+	mi := &file_koggerservice_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -711,7 +1461,7 @@ func (x *Resource) String() string {
 func (*Resource) ProtoMessage() {}
 
 func (x *Resource) ProtoReflect() protoreflect.Message {
-	mi := &file_koggerservice_proto_msgTypes[12]
+	mi := &file_koggerservice_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -724,7 +1474,7 @@ func (x *Resource) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Resource.ProtoReflect.Descriptor instead.
 func (*Resource) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{12}
+	return file_koggerservice_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *Resource) GetNamespace() string {
@@ -756,17 +1506,21 @@ func (x *Resource) GetFields() *AdjustableFields {
 }
 
 type Logs struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Pod           string                 `protobuf:"bytes,1,opt,name=pod,proto3" json:"pod,omitempty"`
-	Namespace     string                 `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
-	Entries       []*LogEntry            `protobuf:"bytes,3,rep,name=entries,proto3" json:"entries,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Pod               string                 `protobuf:"bytes,1,opt,name=pod,proto3" json:"pod,omitempty"`
+	Namespace         string                 `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Entries           []*LogEntry            `protobuf:"bytes,3,rep,name=entries,proto3" json:"entries,omitempty"`
+	MissingTimestamps int64                  `protobuf:"varint,4,opt,name=missingTimestamps,proto3" json:"missingTimestamps,omitempty"`
+	NextCursor        string                 `protobuf:"bytes,5,opt,name=nextCursor,proto3" json:"nextCursor,omitempty"`
+	PreviousCursor    string                 `protobuf:"bytes,6,opt,name=previousCursor,proto3" json:"previousCursor,omitempty"`
+	PodLabels         map[string]string      `protobuf:"bytes,7,rep,name=podLabels,proto3" json:"podLabels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *Logs) Reset() {
 	*x = Logs{}
-	mi := &file_koggerservice_proto_msgTypes[13]
+	mi := &file_koggerservice_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -778,7 +1532,7 @@ func (x *Logs) String() string {
 func (*Logs) ProtoMessage() {}
 
 func (x *Logs) ProtoReflect() protoreflect.Message {
-	mi := &file_koggerservice_proto_msgTypes[13]
+	mi := &file_koggerservice_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -791,7 +1545,7 @@ func (x *Logs) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Logs.ProtoReflect.Descriptor instead.
 func (*Logs) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{13}
+	return file_koggerservice_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *Logs) GetPod() string {
@@ -815,18 +1569,55 @@ func (x *Logs) GetEntries() []*LogEntry {
 	return nil
 }
 
+func (x *Logs) GetMissingTimestamps() int64 {
+	if x != nil {
+		return x.MissingTimestamps
+	}
+	return 0
+}
+
+func (x *Logs) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+func (x *Logs) GetPreviousCursor() string {
+	if x != nil {
+		return x.PreviousCursor
+	}
+	return ""
+}
+
+func (x *Logs) GetPodLabels() map[string]string {
+	if x != nil {
+		return x.PodLabels
+	}
+	return nil
+}
+
 type LogEntry struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Container     string                 `protobuf:"bytes,1,opt,name=container,proto3" json:"container,omitempty"`
-	Timestamp     string                 `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Container        string                 `protobuf:"bytes,1,opt,name=container,proto3" json:"container,omitempty"`
+	Timestamp        string                 `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Message          string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	RestartCount     int32                  `protobuf:"varint,4,opt,name=restartCount,proto3" json:"restartCount,omitempty"`
+	ContainerKind    ContainerKind          `protobuf:"varint,5,opt,name=containerKind,proto3,enum=koggerservicerpc.ContainerKind" json:"containerKind,omitempty"`
+	Pod              string                 `protobuf:"bytes,6,opt,name=pod,proto3" json:"pod,omitempty"`
+	Level            LogLevel               `protobuf:"varint,7,opt,name=level,proto3,enum=koggerservicerpc.LogLevel" json:"level,omitempty"`
+	Fields           *structpb.Struct       `protobuf:"bytes,8,opt,name=fields,proto3" json:"fields,omitempty"`
+	Time             *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=time,proto3" json:"time,omitempty"`
+	MissingTimestamp bool                   `protobuf:"varint,10,opt,name=missingTimestamp,proto3" json:"missingTimestamp,omitempty"`
+	ContainerId      string                 `protobuf:"bytes,11,opt,name=containerId,proto3" json:"containerId,omitempty"`
+	Namespace        string                 `protobuf:"bytes,12,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *LogEntry) Reset() {
 	*x = LogEntry{}
-	mi := &file_koggerservice_proto_msgTypes[14]
+	mi := &file_koggerservice_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -838,7 +1629,7 @@ func (x *LogEntry) String() string {
 func (*LogEntry) ProtoMessage() {}
 
 func (x *LogEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_koggerservice_proto_msgTypes[14]
+	mi := &file_koggerservice_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -851,7 +1642,7 @@ func (x *LogEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
 func (*LogEntry) Descriptor() ([]byte, []int) {
-	return file_koggerservice_proto_rawDescGZIP(), []int{14}
+	return file_koggerservice_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *LogEntry) GetContainer() string {
@@ -875,24 +1666,190 @@ func (x *LogEntry) GetMessage() string {
 	return ""
 }
 
+func (x *LogEntry) GetRestartCount() int32 {
+	if x != nil {
+		return x.RestartCount
+	}
+	return 0
+}
+
+func (x *LogEntry) GetContainerKind() ContainerKind {
+	if x != nil {
+		return x.ContainerKind
+	}
+	return ContainerKind_CONTAINER_KIND_UNKNOWN
+}
+
+func (x *LogEntry) GetPod() string {
+	if x != nil {
+		return x.Pod
+	}
+	return ""
+}
+
+func (x *LogEntry) GetLevel() LogLevel {
+	if x != nil {
+		return x.Level
+	}
+	return LogLevel_LOG_LEVEL_UNKNOWN
+}
+
+func (x *LogEntry) GetFields() *structpb.Struct {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *LogEntry) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *LogEntry) GetMissingTimestamp() bool {
+	if x != nil {
+		return x.MissingTimestamp
+	}
+	return false
+}
+
+func (x *LogEntry) GetContainerId() string {
+	if x != nil {
+		return x.ContainerId
+	}
+	return ""
+}
+
+func (x *LogEntry) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+type LogChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogChunk) Reset() {
+	*x = LogChunk{}
+	mi := &file_koggerservice_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogChunk) ProtoMessage() {}
+
+func (x *LogChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_koggerservice_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogChunk.ProtoReflect.Descriptor instead.
+func (*LogChunk) Descriptor() ([]byte, []int) {
+	return file_koggerservice_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *LogChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
 var File_koggerservice_proto protoreflect.FileDescriptor
 
 const file_koggerservice_proto_rawDesc = "" +
 	"\n" +
-	"\x13koggerservice.proto\x12\x10koggerservicerpc\x1a\x1cgoogle/protobuf/struct.proto\"\x06\n" +
+	"\x13koggerservice.proto\x12\x10koggerservicerpc\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x06\n" +
 	"\x04Void\"X\n" +
 	"\x14ListResourcesRequest\x12\x1c\n" +
 	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\"\n" +
-	"\fresourceType\x18\x02 \x01(\tR\fresourceType\"\x87\x01\n" +
+	"\fresourceType\x18\x02 \x01(\tR\fresourceType\"\x83\x02\n" +
 	"\x0fResourceRequest\x12\x1c\n" +
 	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12B\n" +
 	"\fresourceType\x18\x02 \x01(\x0e2\x1e.koggerservicerpc.ResourceTypeR\fresourceType\x12\x12\n" +
-	"\x04name\x18\x03 \x01(\tR\x04name\"+\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x1c\n" +
+	"\ttailLines\x18\x04 \x01(\x03R\ttailLines\x12\"\n" +
+	"\fsinceSeconds\x18\x05 \x01(\x03R\fsinceSeconds\x128\n" +
+	"\tsinceTime\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tsinceTime\"+\n" +
 	"\vPodsRequest\x12\x1c\n" +
-	"\tnamespace\x18\x01 \x01(\tR\tnamespace\"=\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\"\xc1\x05\n" +
 	"\vLogsRequest\x12\x1c\n" +
 	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x10\n" +
-	"\x03pod\x18\x02 \x01(\tR\x03pod\"I\n" +
+	"\x03pod\x18\x02 \x01(\tR\x03pod\x12\x1c\n" +
+	"\tcontainer\x18\x03 \x01(\tR\tcontainer\x12\x1c\n" +
+	"\ttailLines\x18\x04 \x01(\x03R\ttailLines\x12\"\n" +
+	"\fsinceSeconds\x18\x05 \x01(\x03R\fsinceSeconds\x128\n" +
+	"\tsinceTime\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tsinceTime\x12\x1e\n" +
+	"\n" +
+	"limitBytes\x18\a \x01(\x03R\n" +
+	"limitBytes\x12\x1a\n" +
+	"\bprevious\x18\b \x01(\bR\bprevious\x12$\n" +
+	"\rlabelSelector\x18\t \x01(\tR\rlabelSelector\x12$\n" +
+	"\rfieldSelector\x18\n" +
+	" \x01(\tR\rfieldSelector\x12\"\n" +
+	"\fincludeRegex\x18\v \x01(\tR\fincludeRegex\x12\"\n" +
+	"\fexcludeRegex\x18\f \x01(\tR\fexcludeRegex\x12,\n" +
+	"\x11includeSubstrings\x18\r \x03(\tR\x11includeSubstrings\x12,\n" +
+	"\x11excludeSubstrings\x18\x0e \x03(\tR\x11excludeSubstrings\x126\n" +
+	"\bminLevel\x18\x0f \x01(\x0e2\x1a.koggerservicerpc.LogLevelR\bminLevel\x12\x1c\n" +
+	"\tmultiline\x18\x10 \x01(\bR\tmultiline\x122\n" +
+	"\x14continuationPatterns\x18\x11 \x03(\tR\x14continuationPatterns\x12\x1a\n" +
+	"\bpageSize\x18\x12 \x01(\x05R\bpageSize\x12\x16\n" +
+	"\x06cursor\x18\x13 \x01(\tR\x06cursor\"\xde\x01\n" +
+	"\x13DownloadLogsRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x10\n" +
+	"\x03pod\x18\x02 \x01(\tR\x03pod\x12B\n" +
+	"\fresourceType\x18\x03 \x01(\x0e2\x1e.koggerservicerpc.ResourceTypeR\fresourceType\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12?\n" +
+	"\vcompression\x18\x05 \x01(\x0e2\x1d.koggerservicerpc.CompressionR\vcompression\"\xe0\x01\n" +
+	"\x16QueryStoredLogsRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x10\n" +
+	"\x03pod\x18\x02 \x01(\tR\x03pod\x12\x1c\n" +
+	"\tcontainer\x18\x03 \x01(\tR\tcontainer\x120\n" +
+	"\x05since\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x120\n" +
+	"\x05until\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\x12\x14\n" +
+	"\x05limit\x18\x06 \x01(\x05R\x05limit\"\xc3\x01\n" +
+	"\x11SearchLogsRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1e\n" +
+	"\n" +
+	"namespaces\x18\x02 \x03(\tR\n" +
+	"namespaces\x120\n" +
+	"\x05since\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x120\n" +
+	"\x05until\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\"\x99\x01\n" +
+	"\x12SearchLogsResponse\x12/\n" +
+	"\x04hits\x18\x01 \x03(\v2\x1b.koggerservicerpc.SearchHitR\x04hits\x12(\n" +
+	"\x0fscannedSegments\x18\x02 \x01(\x03R\x0fscannedSegments\x12(\n" +
+	"\x0fskippedSegments\x18\x03 \x01(\x03R\x0fskippedSegments\"\xc8\x01\n" +
+	"\tSearchHit\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x120\n" +
+	"\x05entry\x18\x02 \x01(\v2\x1a.koggerservicerpc.LogEntryR\x05entry\x12\x14\n" +
+	"\x05score\x18\x03 \x01(\x01R\x05score\x12\x18\n" +
+	"\asnippet\x18\x04 \x01(\tR\asnippet\x12;\n" +
+	"\n" +
+	"highlights\x18\x05 \x03(\v2\x1b.koggerservicerpc.TextRangeR\n" +
+	"highlights\"3\n" +
+	"\tTextRange\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x05R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x05R\x03end\"I\n" +
 	"\n" +
 	"Namespaces\x12;\n" +
 	"\n" +
@@ -919,15 +1876,36 @@ const file_koggerservice_proto_rawDesc = "" +
 	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
 	"\x06status\x18\x03 \x01(\tR\x06status\x12:\n" +
-	"\x06fields\x18\x04 \x01(\v2\".koggerservicerpc.AdjustableFieldsR\x06fields\"l\n" +
+	"\x06fields\x18\x04 \x01(\v2\".koggerservicerpc.AdjustableFieldsR\x06fields\"\xe5\x02\n" +
 	"\x04Logs\x12\x10\n" +
 	"\x03pod\x18\x01 \x01(\tR\x03pod\x12\x1c\n" +
 	"\tnamespace\x18\x02 \x01(\tR\tnamespace\x124\n" +
-	"\aentries\x18\x03 \x03(\v2\x1a.koggerservicerpc.LogEntryR\aentries\"`\n" +
+	"\aentries\x18\x03 \x03(\v2\x1a.koggerservicerpc.LogEntryR\aentries\x12,\n" +
+	"\x11missingTimestamps\x18\x04 \x01(\x03R\x11missingTimestamps\x12\x1e\n" +
+	"\n" +
+	"nextCursor\x18\x05 \x01(\tR\n" +
+	"nextCursor\x12&\n" +
+	"\x0epreviousCursor\x18\x06 \x01(\tR\x0epreviousCursor\x12C\n" +
+	"\tpodLabels\x18\a \x03(\v2%.koggerservicerpc.Logs.PodLabelsEntryR\tpodLabels\x1a<\n" +
+	"\x0ePodLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xdc\x03\n" +
 	"\bLogEntry\x12\x1c\n" +
 	"\tcontainer\x18\x01 \x01(\tR\tcontainer\x12\x1c\n" +
 	"\ttimestamp\x18\x02 \x01(\tR\ttimestamp\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage*\xbb\x04\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\"\n" +
+	"\frestartCount\x18\x04 \x01(\x05R\frestartCount\x12E\n" +
+	"\rcontainerKind\x18\x05 \x01(\x0e2\x1f.koggerservicerpc.ContainerKindR\rcontainerKind\x12\x10\n" +
+	"\x03pod\x18\x06 \x01(\tR\x03pod\x120\n" +
+	"\x05level\x18\a \x01(\x0e2\x1a.koggerservicerpc.LogLevelR\x05level\x12/\n" +
+	"\x06fields\x18\b \x01(\v2\x17.google.protobuf.StructR\x06fields\x12.\n" +
+	"\x04time\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12*\n" +
+	"\x10missingTimestamp\x18\n" +
+	" \x01(\bR\x10missingTimestamp\x12 \n" +
+	"\vcontainerId\x18\v \x01(\tR\vcontainerId\x12\x1c\n" +
+	"\tnamespace\x18\f \x01(\tR\tnamespace\"\x1e\n" +
+	"\bLogChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data*\xbb\x04\n" +
 	"\fResourceType\x12\x19\n" +
 	"\x15RESOURCE_TYPE_UNKNOWN\x10\x00\x12\x15\n" +
 	"\x11RESOURCE_TYPE_POD\x10\x01\x12\x19\n" +
@@ -948,12 +1926,38 @@ const file_koggerservice_proto_rawDesc = "" +
 	"\x1cRESOURCE_TYPE_SERVICEACCOUNT\x10\x0f\x12\x1b\n" +
 	"\x17RESOURCE_TYPE_ENDPOINTS\x10\x10\x12\x16\n" +
 	"\x12RESOURCE_TYPE_ROLE\x10\x11\x12\x1d\n" +
-	"\x19RESOURCE_TYPE_ROLEBINDING\x10\x122\xc4\x02\n" +
+	"\x19RESOURCE_TYPE_ROLEBINDING\x10\x12*\x9a\x01\n" +
+	"\rContainerKind\x12\x1a\n" +
+	"\x16CONTAINER_KIND_UNKNOWN\x10\x00\x12\x1a\n" +
+	"\x16CONTAINER_KIND_REGULAR\x10\x01\x12\x17\n" +
+	"\x13CONTAINER_KIND_INIT\x10\x02\x12\x1a\n" +
+	"\x16CONTAINER_KIND_SIDECAR\x10\x03\x12\x1c\n" +
+	"\x18CONTAINER_KIND_EPHEMERAL\x10\x04*\x9d\x01\n" +
+	"\bLogLevel\x12\x15\n" +
+	"\x11LOG_LEVEL_UNKNOWN\x10\x00\x12\x13\n" +
+	"\x0fLOG_LEVEL_TRACE\x10\x01\x12\x13\n" +
+	"\x0fLOG_LEVEL_DEBUG\x10\x02\x12\x12\n" +
+	"\x0eLOG_LEVEL_INFO\x10\x03\x12\x12\n" +
+	"\x0eLOG_LEVEL_WARN\x10\x04\x12\x13\n" +
+	"\x0fLOG_LEVEL_ERROR\x10\x05\x12\x13\n" +
+	"\x0fLOG_LEVEL_FATAL\x10\x06*R\n" +
+	"\vCompression\x12\x17\n" +
+	"\x13COMPRESSION_UNKNOWN\x10\x00\x12\x14\n" +
+	"\x10COMPRESSION_GZIP\x10\x01\x12\x14\n" +
+	"\x10COMPRESSION_ZSTD\x10\x022\x9d\x06\n" +
 	"\rKoggerService\x12E\n" +
 	"\rGetNamespaces\x12\x16.koggerservicerpc.Void\x1a\x1c.koggerservicerpc.Namespaces\x12\\\n" +
 	"\rListResources\x12&.koggerservicerpc.ListResourcesRequest\x1a#.koggerservicerpc.ResourcesResponse\x12L\n" +
 	"\vGetResource\x12!.koggerservicerpc.ResourceRequest\x1a\x1a.koggerservicerpc.Resource\x12@\n" +
-	"\aGetLogs\x12\x1d.koggerservicerpc.LogsRequest\x1a\x16.koggerservicerpc.LogsB4Z2github.com/k-ogger/kogger-service/koggerservicerpcb\x06proto3"
+	"\aGetLogs\x12\x1d.koggerservicerpc.LogsRequest\x1a\x16.koggerservicerpc.Logs\x12I\n" +
+	"\n" +
+	"FollowLogs\x12\x1d.koggerservicerpc.LogsRequest\x1a\x1a.koggerservicerpc.LogEntry0\x01\x12L\n" +
+	"\x0fGetWorkloadLogs\x12!.koggerservicerpc.ResourceRequest\x1a\x16.koggerservicerpc.Logs\x12S\n" +
+	"\fDownloadLogs\x12%.koggerservicerpc.DownloadLogsRequest\x1a\x1a.koggerservicerpc.LogChunk0\x01\x12;\n" +
+	"\tStoreLogs\x12\x16.koggerservicerpc.Logs\x1a\x16.koggerservicerpc.Void\x12S\n" +
+	"\x0fQueryStoredLogs\x12(.koggerservicerpc.QueryStoredLogsRequest\x1a\x16.koggerservicerpc.Logs\x12W\n" +
+	"\n" +
+	"SearchLogs\x12#.koggerservicerpc.SearchLogsRequest\x1a$.koggerservicerpc.SearchLogsResponseB4Z2github.com/k-ogger/kogger-service/koggerservicerpcb\x06proto3"
 
 var (
 	file_koggerservice_proto_rawDescOnce sync.Once
@@ -967,51 +1971,93 @@ func file_koggerservice_proto_rawDescGZIP() []byte {
 	return file_koggerservice_proto_rawDescData
 }
 
-var file_koggerservice_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_koggerservice_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_koggerservice_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_koggerservice_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
 var file_koggerservice_proto_goTypes = []any{
-	(ResourceType)(0),            // 0: koggerservicerpc.ResourceType
-	(*Void)(nil),                 // 1: koggerservicerpc.Void
-	(*ListResourcesRequest)(nil), // 2: koggerservicerpc.ListResourcesRequest
-	(*ResourceRequest)(nil),      // 3: koggerservicerpc.ResourceRequest
-	(*PodsRequest)(nil),          // 4: koggerservicerpc.PodsRequest
-	(*LogsRequest)(nil),          // 5: koggerservicerpc.LogsRequest
-	(*Namespaces)(nil),           // 6: koggerservicerpc.Namespaces
-	(*Namespace)(nil),            // 7: koggerservicerpc.Namespace
-	(*ResourceInlist)(nil),       // 8: koggerservicerpc.ResourceInlist
-	(*ResourcesList)(nil),        // 9: koggerservicerpc.ResourcesList
-	(*ResourcesResponse)(nil),    // 10: koggerservicerpc.ResourcesResponse
-	(*Resources)(nil),            // 11: koggerservicerpc.Resources
-	(*AdjustableFields)(nil),     // 12: koggerservicerpc.AdjustableFields
-	(*Resource)(nil),             // 13: koggerservicerpc.Resource
-	(*Logs)(nil),                 // 14: koggerservicerpc.Logs
-	(*LogEntry)(nil),             // 15: koggerservicerpc.LogEntry
-	nil,                          // 16: koggerservicerpc.AdjustableFields.FieldsEntry
-	(*structpb.Value)(nil),       // 17: google.protobuf.Value
+	(ResourceType)(0),              // 0: koggerservicerpc.ResourceType
+	(ContainerKind)(0),             // 1: koggerservicerpc.ContainerKind
+	(LogLevel)(0),                  // 2: koggerservicerpc.LogLevel
+	(Compression)(0),               // 3: koggerservicerpc.Compression
+	(*Void)(nil),                   // 4: koggerservicerpc.Void
+	(*ListResourcesRequest)(nil),   // 5: koggerservicerpc.ListResourcesRequest
+	(*ResourceRequest)(nil),        // 6: koggerservicerpc.ResourceRequest
+	(*PodsRequest)(nil),            // 7: koggerservicerpc.PodsRequest
+	(*LogsRequest)(nil),            // 8: koggerservicerpc.LogsRequest
+	(*DownloadLogsRequest)(nil),    // 9: koggerservicerpc.DownloadLogsRequest
+	(*QueryStoredLogsRequest)(nil), // 10: koggerservicerpc.QueryStoredLogsRequest
+	(*SearchLogsRequest)(nil),      // 11: koggerservicerpc.SearchLogsRequest
+	(*SearchLogsResponse)(nil),     // 12: koggerservicerpc.SearchLogsResponse
+	(*SearchHit)(nil),              // 13: koggerservicerpc.SearchHit
+	(*TextRange)(nil),              // 14: koggerservicerpc.TextRange
+	(*Namespaces)(nil),             // 15: koggerservicerpc.Namespaces
+	(*Namespace)(nil),              // 16: koggerservicerpc.Namespace
+	(*ResourceInlist)(nil),         // 17: koggerservicerpc.ResourceInlist
+	(*ResourcesList)(nil),          // 18: koggerservicerpc.ResourcesList
+	(*ResourcesResponse)(nil),      // 19: koggerservicerpc.ResourcesResponse
+	(*Resources)(nil),              // 20: koggerservicerpc.Resources
+	(*AdjustableFields)(nil),       // 21: koggerservicerpc.AdjustableFields
+	(*Resource)(nil),               // 22: koggerservicerpc.Resource
+	(*Logs)(nil),                   // 23: koggerservicerpc.Logs
+	(*LogEntry)(nil),               // 24: koggerservicerpc.LogEntry
+	(*LogChunk)(nil),               // 25: koggerservicerpc.LogChunk
+	nil,                            // 26: koggerservicerpc.AdjustableFields.FieldsEntry
+	nil,                            // 27: koggerservicerpc.Logs.PodLabelsEntry
+	(*timestamppb.Timestamp)(nil),  // 28: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),        // 29: google.protobuf.Struct
+	(*structpb.Value)(nil),         // 30: google.protobuf.Value
 }
 var file_koggerservice_proto_depIdxs = []int32{
 	0,  // 0: koggerservicerpc.ResourceRequest.resourceType:type_name -> koggerservicerpc.ResourceType
-	7,  // 1: koggerservicerpc.Namespaces.namespaces:type_name -> koggerservicerpc.Namespace
-	8,  // 2: koggerservicerpc.ResourcesList.resources:type_name -> koggerservicerpc.ResourceInlist
-	9,  // 3: koggerservicerpc.ResourcesResponse.resourcesList:type_name -> koggerservicerpc.ResourcesList
-	13, // 4: koggerservicerpc.Resources.resources:type_name -> koggerservicerpc.Resource
-	16, // 5: koggerservicerpc.AdjustableFields.fields:type_name -> koggerservicerpc.AdjustableFields.FieldsEntry
-	12, // 6: koggerservicerpc.Resource.fields:type_name -> koggerservicerpc.AdjustableFields
-	15, // 7: koggerservicerpc.Logs.entries:type_name -> koggerservicerpc.LogEntry
-	17, // 8: koggerservicerpc.AdjustableFields.FieldsEntry.value:type_name -> google.protobuf.Value
-	1,  // 9: koggerservicerpc.KoggerService.GetNamespaces:input_type -> koggerservicerpc.Void
-	2,  // 10: koggerservicerpc.KoggerService.ListResources:input_type -> koggerservicerpc.ListResourcesRequest
-	3,  // 11: koggerservicerpc.KoggerService.GetResource:input_type -> koggerservicerpc.ResourceRequest
-	5,  // 12: koggerservicerpc.KoggerService.GetLogs:input_type -> koggerservicerpc.LogsRequest
-	6,  // 13: koggerservicerpc.KoggerService.GetNamespaces:output_type -> koggerservicerpc.Namespaces
-	10, // 14: koggerservicerpc.KoggerService.ListResources:output_type -> koggerservicerpc.ResourcesResponse
-	13, // 15: koggerservicerpc.KoggerService.GetResource:output_type -> koggerservicerpc.Resource
-	14, // 16: koggerservicerpc.KoggerService.GetLogs:output_type -> koggerservicerpc.Logs
-	13, // [13:17] is the sub-list for method output_type
-	9,  // [9:13] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	28, // 1: koggerservicerpc.ResourceRequest.sinceTime:type_name -> google.protobuf.Timestamp
+	28, // 2: koggerservicerpc.LogsRequest.sinceTime:type_name -> google.protobuf.Timestamp
+	2,  // 3: koggerservicerpc.LogsRequest.minLevel:type_name -> koggerservicerpc.LogLevel
+	0,  // 4: koggerservicerpc.DownloadLogsRequest.resourceType:type_name -> koggerservicerpc.ResourceType
+	3,  // 5: koggerservicerpc.DownloadLogsRequest.compression:type_name -> koggerservicerpc.Compression
+	28, // 6: koggerservicerpc.QueryStoredLogsRequest.since:type_name -> google.protobuf.Timestamp
+	28, // 7: koggerservicerpc.QueryStoredLogsRequest.until:type_name -> google.protobuf.Timestamp
+	28, // 8: koggerservicerpc.SearchLogsRequest.since:type_name -> google.protobuf.Timestamp
+	28, // 9: koggerservicerpc.SearchLogsRequest.until:type_name -> google.protobuf.Timestamp
+	13, // 10: koggerservicerpc.SearchLogsResponse.hits:type_name -> koggerservicerpc.SearchHit
+	24, // 11: koggerservicerpc.SearchHit.entry:type_name -> koggerservicerpc.LogEntry
+	14, // 12: koggerservicerpc.SearchHit.highlights:type_name -> koggerservicerpc.TextRange
+	16, // 13: koggerservicerpc.Namespaces.namespaces:type_name -> koggerservicerpc.Namespace
+	17, // 14: koggerservicerpc.ResourcesList.resources:type_name -> koggerservicerpc.ResourceInlist
+	18, // 15: koggerservicerpc.ResourcesResponse.resourcesList:type_name -> koggerservicerpc.ResourcesList
+	22, // 16: koggerservicerpc.Resources.resources:type_name -> koggerservicerpc.Resource
+	26, // 17: koggerservicerpc.AdjustableFields.fields:type_name -> koggerservicerpc.AdjustableFields.FieldsEntry
+	21, // 18: koggerservicerpc.Resource.fields:type_name -> koggerservicerpc.AdjustableFields
+	24, // 19: koggerservicerpc.Logs.entries:type_name -> koggerservicerpc.LogEntry
+	27, // 20: koggerservicerpc.Logs.podLabels:type_name -> koggerservicerpc.Logs.PodLabelsEntry
+	1,  // 21: koggerservicerpc.LogEntry.containerKind:type_name -> koggerservicerpc.ContainerKind
+	2,  // 22: koggerservicerpc.LogEntry.level:type_name -> koggerservicerpc.LogLevel
+	29, // 23: koggerservicerpc.LogEntry.fields:type_name -> google.protobuf.Struct
+	28, // 24: koggerservicerpc.LogEntry.time:type_name -> google.protobuf.Timestamp
+	30, // 25: koggerservicerpc.AdjustableFields.FieldsEntry.value:type_name -> google.protobuf.Value
+	4,  // 26: koggerservicerpc.KoggerService.GetNamespaces:input_type -> koggerservicerpc.Void
+	5,  // 27: koggerservicerpc.KoggerService.ListResources:input_type -> koggerservicerpc.ListResourcesRequest
+	6,  // 28: koggerservicerpc.KoggerService.GetResource:input_type -> koggerservicerpc.ResourceRequest
+	8,  // 29: koggerservicerpc.KoggerService.GetLogs:input_type -> koggerservicerpc.LogsRequest
+	8,  // 30: koggerservicerpc.KoggerService.FollowLogs:input_type -> koggerservicerpc.LogsRequest
+	6,  // 31: koggerservicerpc.KoggerService.GetWorkloadLogs:input_type -> koggerservicerpc.ResourceRequest
+	9,  // 32: koggerservicerpc.KoggerService.DownloadLogs:input_type -> koggerservicerpc.DownloadLogsRequest
+	23, // 33: koggerservicerpc.KoggerService.StoreLogs:input_type -> koggerservicerpc.Logs
+	10, // 34: koggerservicerpc.KoggerService.QueryStoredLogs:input_type -> koggerservicerpc.QueryStoredLogsRequest
+	11, // 35: koggerservicerpc.KoggerService.SearchLogs:input_type -> koggerservicerpc.SearchLogsRequest
+	15, // 36: koggerservicerpc.KoggerService.GetNamespaces:output_type -> koggerservicerpc.Namespaces
+	19, // 37: koggerservicerpc.KoggerService.ListResources:output_type -> koggerservicerpc.ResourcesResponse
+	22, // 38: koggerservicerpc.KoggerService.GetResource:output_type -> koggerservicerpc.Resource
+	23, // 39: koggerservicerpc.KoggerService.GetLogs:output_type -> koggerservicerpc.Logs
+	24, // 40: koggerservicerpc.KoggerService.FollowLogs:output_type -> koggerservicerpc.LogEntry
+	23, // 41: koggerservicerpc.KoggerService.GetWorkloadLogs:output_type -> koggerservicerpc.Logs
+	25, // 42: koggerservicerpc.KoggerService.DownloadLogs:output_type -> koggerservicerpc.LogChunk
+	4,  // 43: koggerservicerpc.KoggerService.StoreLogs:output_type -> koggerservicerpc.Void
+	23, // 44: koggerservicerpc.KoggerService.QueryStoredLogs:output_type -> koggerservicerpc.Logs
+	12, // 45: koggerservicerpc.KoggerService.SearchLogs:output_type -> koggerservicerpc.SearchLogsResponse
+	36, // [36:46] is the sub-list for method output_type
+	26, // [26:36] is the sub-list for method input_type
+	26, // [26:26] is the sub-list for extension type_name
+	26, // [26:26] is the sub-list for extension extendee
+	0,  // [0:26] is the sub-list for field type_name
 }
 
 func init() { file_koggerservice_proto_init() }
@@ -1024,8 +2070,8 @@ func file_koggerservice_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_koggerservice_proto_rawDesc), len(file_koggerservice_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   16,
+			NumEnums:      4,
+			NumMessages:   24,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,10 +19,16 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	KoggerService_GetNamespaces_FullMethodName = "/koggerservicerpc.KoggerService/GetNamespaces"
-	KoggerService_ListResources_FullMethodName = "/koggerservicerpc.KoggerService/ListResources"
-	KoggerService_GetResource_FullMethodName   = "/koggerservicerpc.KoggerService/GetResource"
-	KoggerService_GetLogs_FullMethodName       = "/koggerservicerpc.KoggerService/GetLogs"
+	KoggerService_GetNamespaces_FullMethodName   = "/koggerservicerpc.KoggerService/GetNamespaces"
+	KoggerService_ListResources_FullMethodName   = "/koggerservicerpc.KoggerService/ListResources"
+	KoggerService_GetResource_FullMethodName     = "/koggerservicerpc.KoggerService/GetResource"
+	KoggerService_GetLogs_FullMethodName         = "/koggerservicerpc.KoggerService/GetLogs"
+	KoggerService_FollowLogs_FullMethodName      = "/koggerservicerpc.KoggerService/FollowLogs"
+	KoggerService_GetWorkloadLogs_FullMethodName = "/koggerservicerpc.KoggerService/GetWorkloadLogs"
+	KoggerService_DownloadLogs_FullMethodName    = "/koggerservicerpc.KoggerService/DownloadLogs"
+	KoggerService_StoreLogs_FullMethodName       = "/koggerservicerpc.KoggerService/StoreLogs"
+	KoggerService_QueryStoredLogs_FullMethodName = "/koggerservicerpc.KoggerService/QueryStoredLogs"
+	KoggerService_SearchLogs_FullMethodName      = "/koggerservicerpc.KoggerService/SearchLogs"
 )
 
 // KoggerServiceClient is the client API for KoggerService service.
@@ -33,6 +39,12 @@ type KoggerServiceClient interface {
 	ListResources(ctx context.Context, in *ListResourcesRequest, opts ...grpc.CallOption) (*ResourcesResponse, error)
 	GetResource(ctx context.Context, in *ResourceRequest, opts ...grpc.CallOption) (*Resource, error)
 	GetLogs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (*Logs, error)
+	FollowLogs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogEntry], error)
+	GetWorkloadLogs(ctx context.Context, in *ResourceRequest, opts ...grpc.CallOption) (*Logs, error)
+	DownloadLogs(ctx context.Context, in *DownloadLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogChunk], error)
+	StoreLogs(ctx context.Context, in *Logs, opts ...grpc.CallOption) (*Void, error)
+	QueryStoredLogs(ctx context.Context, in *QueryStoredLogsRequest, opts ...grpc.CallOption) (*Logs, error)
+	SearchLogs(ctx context.Context, in *SearchLogsRequest, opts ...grpc.CallOption) (*SearchLogsResponse, error)
 }
 
 type koggerServiceClient struct {
@@ -83,6 +95,84 @@ func (c *koggerServiceClient) GetLogs(ctx context.Context, in *LogsRequest, opts
 	return out, nil
 }
 
+func (c *koggerServiceClient) FollowLogs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogEntry], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KoggerService_ServiceDesc.Streams[0], KoggerService_FollowLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LogsRequest, LogEntry]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KoggerService_FollowLogsClient = grpc.ServerStreamingClient[LogEntry]
+
+func (c *koggerServiceClient) GetWorkloadLogs(ctx context.Context, in *ResourceRequest, opts ...grpc.CallOption) (*Logs, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Logs)
+	err := c.cc.Invoke(ctx, KoggerService_GetWorkloadLogs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *koggerServiceClient) DownloadLogs(ctx context.Context, in *DownloadLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KoggerService_ServiceDesc.Streams[1], KoggerService_DownloadLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DownloadLogsRequest, LogChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KoggerService_DownloadLogsClient = grpc.ServerStreamingClient[LogChunk]
+
+func (c *koggerServiceClient) StoreLogs(ctx context.Context, in *Logs, opts ...grpc.CallOption) (*Void, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Void)
+	err := c.cc.Invoke(ctx, KoggerService_StoreLogs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *koggerServiceClient) QueryStoredLogs(ctx context.Context, in *QueryStoredLogsRequest, opts ...grpc.CallOption) (*Logs, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Logs)
+	err := c.cc.Invoke(ctx, KoggerService_QueryStoredLogs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *koggerServiceClient) SearchLogs(ctx context.Context, in *SearchLogsRequest, opts ...grpc.CallOption) (*SearchLogsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchLogsResponse)
+	err := c.cc.Invoke(ctx, KoggerService_SearchLogs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KoggerServiceServer is the server API for KoggerService service.
 // All implementations must embed UnimplementedKoggerServiceServer
 // for forward compatibility.
@@ -91,6 +181,12 @@ type KoggerServiceServer interface {
 	ListResources(context.Context, *ListResourcesRequest) (*ResourcesResponse, error)
 	GetResource(context.Context, *ResourceRequest) (*Resource, error)
 	GetLogs(context.Context, *LogsRequest) (*Logs, error)
+	FollowLogs(*LogsRequest, grpc.ServerStreamingServer[LogEntry]) error
+	GetWorkloadLogs(context.Context, *ResourceRequest) (*Logs, error)
+	DownloadLogs(*DownloadLogsRequest, grpc.ServerStreamingServer[LogChunk]) error
+	StoreLogs(context.Context, *Logs) (*Void, error)
+	QueryStoredLogs(context.Context, *QueryStoredLogsRequest) (*Logs, error)
+	SearchLogs(context.Context, *SearchLogsRequest) (*SearchLogsResponse, error)
 	mustEmbedUnimplementedKoggerServiceServer()
 }
 
@@ -113,6 +209,24 @@ func (UnimplementedKoggerServiceServer) GetResource(context.Context, *ResourceRe
 func (UnimplementedKoggerServiceServer) GetLogs(context.Context, *LogsRequest) (*Logs, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetLogs not implemented")
 }
+func (UnimplementedKoggerServiceServer) FollowLogs(*LogsRequest, grpc.ServerStreamingServer[LogEntry]) error {
+	return status.Errorf(codes.Unimplemented, "method FollowLogs not implemented")
+}
+func (UnimplementedKoggerServiceServer) GetWorkloadLogs(context.Context, *ResourceRequest) (*Logs, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWorkloadLogs not implemented")
+}
+func (UnimplementedKoggerServiceServer) DownloadLogs(*DownloadLogsRequest, grpc.ServerStreamingServer[LogChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadLogs not implemented")
+}
+func (UnimplementedKoggerServiceServer) StoreLogs(context.Context, *Logs) (*Void, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StoreLogs not implemented")
+}
+func (UnimplementedKoggerServiceServer) QueryStoredLogs(context.Context, *QueryStoredLogsRequest) (*Logs, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryStoredLogs not implemented")
+}
+func (UnimplementedKoggerServiceServer) SearchLogs(context.Context, *SearchLogsRequest) (*SearchLogsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchLogs not implemented")
+}
 func (UnimplementedKoggerServiceServer) mustEmbedUnimplementedKoggerServiceServer() {}
 func (UnimplementedKoggerServiceServer) testEmbeddedByValue()                       {}
 
@@ -206,6 +320,100 @@ func _KoggerService_GetLogs_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KoggerService_FollowLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KoggerServiceServer).FollowLogs(m, &grpc.GenericServerStream[LogsRequest, LogEntry]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KoggerService_FollowLogsServer = grpc.ServerStreamingServer[LogEntry]
+
+func _KoggerService_GetWorkloadLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KoggerServiceServer).GetWorkloadLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KoggerService_GetWorkloadLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KoggerServiceServer).GetWorkloadLogs(ctx, req.(*ResourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KoggerService_DownloadLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KoggerServiceServer).DownloadLogs(m, &grpc.GenericServerStream[DownloadLogsRequest, LogChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KoggerService_DownloadLogsServer = grpc.ServerStreamingServer[LogChunk]
+
+func _KoggerService_StoreLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Logs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KoggerServiceServer).StoreLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KoggerService_StoreLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KoggerServiceServer).StoreLogs(ctx, req.(*Logs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KoggerService_QueryStoredLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryStoredLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KoggerServiceServer).QueryStoredLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KoggerService_QueryStoredLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KoggerServiceServer).QueryStoredLogs(ctx, req.(*QueryStoredLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KoggerService_SearchLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KoggerServiceServer).SearchLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KoggerService_SearchLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KoggerServiceServer).SearchLogs(ctx, req.(*SearchLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // KoggerService_ServiceDesc is the grpc.ServiceDesc for KoggerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -229,7 +437,34 @@ var KoggerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetLogs",
 			Handler:    _KoggerService_GetLogs_Handler,
 		},
+		{
+			MethodName: "GetWorkloadLogs",
+			Handler:    _KoggerService_GetWorkloadLogs_Handler,
+		},
+		{
+			MethodName: "StoreLogs",
+			Handler:    _KoggerService_StoreLogs_Handler,
+		},
+		{
+			MethodName: "QueryStoredLogs",
+			Handler:    _KoggerService_QueryStoredLogs_Handler,
+		},
+		{
+			MethodName: "SearchLogs",
+			Handler:    _KoggerService_SearchLogs_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "FollowLogs",
+			Handler:       _KoggerService_FollowLogs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "DownloadLogs",
+			Handler:       _KoggerService_DownloadLogs_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "koggerservice.proto",
 }
@@ -18,18 +18,21 @@ func main() {
 
 	if cronjobEnv == "true" {
 		fmt.Println("Running in cronjob mode")
-		// runCronJob()
+		if err := runCronJob(); err != nil {
+			fmt.Printf("ERROR: cronjob failed: %s\n", err)
+			os.Exit(1)
+		}
 		return
 	} else {
 		kubeconfig, err := rest.InClusterConfig()
 		if err != nil {
-			fmt.Errorf("Failed to retrieve in-cluster Kubernetes config: %s", err)
+			fmt.Printf("ERROR: Failed to retrieve in-cluster Kubernetes config: %s\n", err)
 			return
 		}
 
 		clientset, err := kubernetes.NewForConfig(kubeconfig)
 		if err != nil {
-			fmt.Errorf("Failed to initialize Kubernetes client: %s", err)
+			fmt.Printf("ERROR: Failed to initialize Kubernetes client: %s\n", err)
 			return
 		}
 
@@ -40,71 +43,30 @@ func main() {
 	}
 }
 
-// func runCronJob() {
-// 	fmt.Println("Starting cronjob execution")
-// 	ctx, _ := createContextFromHeader(&http.Request{})
-// 	var ok bool
-// 	if _, ok = os.LookupEnv("KOGGER_HOST"); !ok {
-// 		fmt.Println("ERROR: KOGGER_HOST environment variable is not set")
-// 		return
-// 	} else {
-// 		server.KoggerHost = os.Getenv("KOGGER_HOST")
-// 		fmt.Printf("KOGGER_HOST: %s\n", server.KoggerHost)
-// 	}
-// 	if _, ok = os.LookupEnv("KOGGER_PORT"); !ok {
-// 		fmt.Println("ERROR: KOGGER_PORT environment variable is not set")
-// 		return
-// 	} else {
-// 		server.KoggerPort = os.Getenv("KOGGER_PORT")
-// 		fmt.Printf("KOGGER_PORT: %s\n", server.KoggerPort)
-// 	}
-
-// 	fmt.Printf("Attempting to connect to %v:%v\n", server.KoggerHost, server.KoggerPort)
-// 	conn, err := grpc.Dial(fmt.Sprintf("%v:%v", server.KoggerHost, server.KoggerPort), grpc.WithInsecure(), grpc.WithBlock())
-// 	if err != nil {
-// 		fmt.Printf("ERROR: failed to establish gRPC connection: %v\n", err)
-// 		return
-// 	}
-// 	defer conn.Close()
-// 	fmt.Println("gRPC connection established successfully")
-// 	client := koggerservicerpc.NewKoggerServiceClient(conn)
-
-// 	pods, err := client.GetResources(ctx, &koggerservicerpc.ResourcesRequest{
-// 		Namespace:    "default",
-// 		ResourceType: koggerservicerpc.ResourceType_RESOURCE_TYPE_POD,
-// 	})
-// 	if err != nil {
-// 		fmt.Printf("ERROR: failed to get pods: %v\n", err)
-// 		return
-// 	}
-// 	if len(pods.GetResources()) == 0 {
-// 		fmt.Println("No pods found")
-// 		return
-// 	}
-// 	for _, pod := range pods.GetResources() {
-// 		fmt.Println("########### Processing pod ###########")
-// 		fmt.Printf("Pod Name: %s, Namespace: %s\n", pod.Name, pod.Namespace)
-// 		fmt.Printf("Pod Status: %s\n", pod.Status)
+func runCronJob() error {
+	fmt.Println("Starting cronjob execution")
+	ctx, grpcToken := createContextFromHeader(&http.Request{})
+	var ok bool
+	if _, ok = os.LookupEnv("KOGGER_HOST"); !ok {
+		return fmt.Errorf("KOGGER_HOST environment variable is not set")
+	} else {
+		server.KoggerHost = os.Getenv("KOGGER_HOST")
+		fmt.Printf("KOGGER_HOST: %s\n", server.KoggerHost)
+	}
+	if _, ok = os.LookupEnv("KOGGER_PORT"); !ok {
+		return fmt.Errorf("KOGGER_PORT environment variable is not set")
+	} else {
+		server.KoggerPort = os.Getenv("KOGGER_PORT")
+		fmt.Printf("KOGGER_PORT: %s\n", server.KoggerPort)
+	}
 
-// 		logs, err := client.GetLogs(ctx, &koggerservicerpc.LogsRequest{
-// 			Pod:       pod.Name,
-// 			Namespace: pod.Namespace,
-// 		})
-// 		if err != nil {
-// 			fmt.Printf("ERROR: failed to get logs for pod %s in namespace %s: %v\n", pod.Name, pod.Namespace, err)
-// 			continue
-// 		}
-// 		if len(logs.GetEntries()) == 0 {
-// 			fmt.Printf("No logs found for pod %s in namespace %s\n", pod.Name, pod.Namespace)
-// 			continue
-// 		}
-// 		for _, log := range logs.GetEntries() {
-// 			fmt.Printf("Log Container: %s, Timestamp: %s, Message: %s\n", log.GetContainer(), log.GetTimestamp(), log.GetMessage())
-// 		}
-// 	}
+	if err := server.RunCollector(ctx, grpcToken); err != nil {
+		return err
+	}
 
-// 	fmt.Println("Cron job completed successfully")
-// }
+	fmt.Println("Cron job completed successfully")
+	return nil
+}
 
 var (
 	grpcTokenAlphabet = []byte("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ23456789")
@@ -121,7 +83,7 @@ func generateGrpcToken() string {
 func createContextFromHeader(r *http.Request) (context.Context, string) {
 	grpcToken := generateGrpcToken()
 
-	ctx := metadata.AppendToOutgoingContext(r.Context(), "grpc-token", grpcToken)
+	ctx := metadata.AppendToOutgoingContext(r.Context(), "zolara-grpc-token", grpcToken)
 
 	return ctx, grpcToken
 }